@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type AdjustmentController struct {
+	DB *gorm.DB
+}
+
+func (c *AdjustmentController) GetReasons(ctx *fiber.Ctx) error {
+	var reasons []models.AdjustmentReason
+	if err := c.DB.Order("reason_code").Find(&reasons).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": reasons})
+}
+
+func (c *AdjustmentController) SaveReason(ctx *fiber.Ctx) error {
+	var input struct {
+		ReasonCode  string `json:"reason_code"`
+		Description string `json:"description"`
+		IsActive    *bool  `json:"is_active"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if input.ReasonCode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Reason code is required"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var reason models.AdjustmentReason
+	err := c.DB.Where("reason_code = ?", input.ReasonCode).First(&reason).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reason.ReasonCode = input.ReasonCode
+	reason.Description = input.Description
+	reason.IsActive = input.IsActive == nil || *input.IsActive
+	reason.UpdatedBy = userID
+
+	if reason.ID == 0 {
+		reason.CreatedBy = userID
+		err = c.DB.Create(&reason).Error
+	} else {
+		err = c.DB.Save(&reason).Error
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Reason " + reason.ReasonCode + " saved successfully", "data": reason})
+}
+
+func (c *AdjustmentController) DeleteReason(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var reason models.AdjustmentReason
+	if err := c.DB.Where("id = ?", ctx.Params("id")).First(&reason).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Reason not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reason.DeletedBy = userID
+	if err := c.DB.Save(&reason).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := c.DB.Delete(&reason).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Reason deleted successfully"})
+}
+
+func (c *AdjustmentController) GetAdjustments(ctx *fiber.Ctx) error {
+	list, err := repositories.NewAdjustmentRepository(c.DB).GetAdjustmentList(ctx.Query("status"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": list})
+}
+
+func (c *AdjustmentController) findAdjustment(ctx *fiber.Ctx) (models.AdjustmentHeader, error) {
+	var header models.AdjustmentHeader
+	err := c.DB.Preload("Details").Where("adjustment_no = ?", ctx.Params("adjustment_no")).First(&header).Error
+	return header, err
+}
+
+func adjustmentNotFound(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Adjustment not found"})
+	}
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (c *AdjustmentController) GetAdjustmentByNo(ctx *fiber.Ctx) error {
+	header, err := c.findAdjustment(ctx)
+	if err != nil {
+		return adjustmentNotFound(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": header})
+}
+
+// CreateAdjustment adjustment manual, langsung diposting kalau nilainya tidak melewati limit owner
+func (c *AdjustmentController) CreateAdjustment(ctx *fiber.Ctx) error {
+	var input struct {
+		OwnerCode  string                        `json:"owner_code"`
+		WhsCode    string                        `json:"whs_code"`
+		ReasonCode string                        `json:"reason_code"`
+		Remarks    string                        `json:"remarks"`
+		Lines      []repositories.AdjustmentLine `json:"lines"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	header := models.AdjustmentHeader{
+		OwnerCode:  input.OwnerCode,
+		WhsCode:    input.WhsCode,
+		SourceType: repositories.AdjustmentManual,
+		ReasonCode: input.ReasonCode,
+		Remarks:    input.Remarks,
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		return repositories.NewAdjustmentRepository(tx).Create(&header, input.Lines, userID)
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "message": "Adjustment " + header.AdjustmentNo + " is " + header.Status, "data": header})
+}
+
+func (c *AdjustmentController) ApproveAdjustment(ctx *fiber.Ctx) error {
+	header, err := c.findAdjustment(ctx)
+	if err != nil {
+		return adjustmentNotFound(ctx, err)
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		return repositories.NewAdjustmentRepository(tx).Approve(&header, userID)
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Adjustment " + header.AdjustmentNo + " approved and posted"})
+}
+
+func (c *AdjustmentController) RejectAdjustment(ctx *fiber.Ctx) error {
+	var input struct {
+		Remarks string `json:"remarks"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if input.Remarks == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Reject remarks is required"})
+	}
+
+	header, err := c.findAdjustment(ctx)
+	if err != nil {
+		return adjustmentNotFound(ctx, err)
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		return repositories.NewAdjustmentRepository(tx).Reject(&header, input.Remarks, userID)
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Adjustment " + header.AdjustmentNo + " rejected"})
+}
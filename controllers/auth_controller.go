@@ -3,13 +3,15 @@ package controllers
 import (
 	"errors"
 	"fiber-app/config"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/models"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
@@ -140,6 +142,14 @@ func NewAuthController(DB *gorm.DB) *AuthController {
 // }
 
 func (c *AuthController) Logout(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+	sessionID, _ := ctx.Locals("sessionID").(string)
+
+	// Cabut session yang sedang dipakai supaya access token tidak bisa dipakai lagi
+	if err := helpers.RevokeSessionTokens(c.DB, uint(userID), sessionID, helpers.RevokeLogout, userID); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Hapus token dari cookie
 	ctx.Cookie(config.GetTokenCookie(""))
 
@@ -149,6 +159,165 @@ func (c *AuthController) Logout(ctx *fiber.Ctx) error {
 	})
 }
 
+// LogoutAll mencabut semua session aktif milik user yang sedang login
+func (c *AuthController) LogoutAll(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var sessionIDs []string
+	if err := c.DB.Model(&models.LoginLog{}).
+		Where("user_id = ? AND login_status = ? AND logout_at IS NULL AND session_id <> ''", userID, "SUCCESS").
+		Pluck("session_id", &sessionIDs).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, sessionID := range sessionIDs {
+		if err := helpers.RevokeSessionTokens(tx, uint(userID), sessionID, helpers.RevokeLogoutAll, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx.Cookie(config.GetTokenCookie(""))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("Logged out from %d sessions", len(sessionIDs)),
+	})
+}
+
+// GetSessions menampilkan session login milik user yang sedang login
+func (c *AuthController) GetSessions(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+	currentSession, _ := ctx.Locals("sessionID").(string)
+
+	var logs []models.LoginLog
+	if err := c.DB.Where("user_id = ? AND login_status = ? AND session_id <> ''", userID, "SUCCESS").
+		Order("login_at DESC").
+		Limit(50).
+		Find(&logs).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	setting, err := helpers.GetTokenSetting(c.DB)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var sessions []fiber.Map
+	for _, l := range logs {
+		// session tanpa logout dianggap aktif selama refresh token-nya belum expired
+		active := l.LogoutAt == nil
+		if active {
+			var count int64
+			c.DB.Model(&models.RefreshToken{}).
+				Where("session_id = ? AND revoked_at IS NULL AND used_at IS NULL AND expires_at > ?", l.SessionID, time.Now()).
+				Count(&count)
+			active = count > 0 || (l.LoginAt != nil && time.Since(*l.LoginAt) < time.Duration(setting.AccessTokenTTL)*time.Minute)
+		}
+
+		sessions = append(sessions, fiber.Map{
+			"session_id":  l.SessionID,
+			"login_at":    l.LoginAt,
+			"logout_at":   l.LogoutAt,
+			"ip_address":  l.IPAddress,
+			"user_agent":  l.UserAgent,
+			"browser":     l.Browser,
+			"os":          l.OS,
+			"device_type": l.DeviceType,
+			"is_active":   active,
+			"is_current":  l.SessionID == currentSession,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    sessions,
+	})
+}
+
+// RevokeSession logout satu device tertentu milik user yang sedang login
+func (c *AuthController) RevokeSession(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+	sessionID := ctx.Params("session_id")
+
+	var loginLog models.LoginLog
+	if err := c.DB.Where("session_id = ? AND user_id = ?", sessionID, userID).First(&loginLog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := helpers.RevokeSessionTokens(c.DB, uint(userID), sessionID, helpers.RevokeSession, userID); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Session revoked successfully",
+	})
+}
+
+func (c *AuthController) GetTokenSetting(ctx *fiber.Ctx) error {
+	setting, err := helpers.GetTokenSetting(c.DB)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": setting})
+}
+
+func (c *AuthController) UpdateTokenSetting(ctx *fiber.Ctx) error {
+	var input struct {
+		AccessTokenTTL  int `json:"access_token_ttl" validate:"required,min=1,max=1440"`
+		RefreshTokenTTL int `json:"refresh_token_ttl" validate:"required,min=1,max=720"`
+	}
+
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var setting models.TokenSetting
+	if err := c.DB.Order("id ASC").First(&setting).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if setting.ID == 0 {
+		setting.CreatedBy = userID
+	}
+	setting.AccessTokenTTL = input.AccessTokenTTL
+	setting.RefreshTokenTTL = input.RefreshTokenTTL
+	setting.UpdatedBy = userID
+
+	if err := c.DB.Save(&setting).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Token setting updated successfully",
+		"data":    setting,
+	})
+}
+
 // func (c *AuthController) IsLoggedIn(ctx *fiber.Ctx) error {
 // 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 // 		"success": true,
@@ -208,6 +377,7 @@ func Login(ctx *fiber.Ctx) error {
 	// Periksa jika user tidak ditemukan
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			writeLoginLog(db, ctx, nil, input.Email, "FAILED", "USER_NOT_FOUND", "")
 			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"message": "Invalid username or password",
 			})
@@ -217,44 +387,62 @@ func Login(ctx *fiber.Ctx) error {
 		})
 	}
 
-	// Verifikasi password (contoh sederhana, sebaiknya gunakan bcrypt)
-	if mUser.Password != input.Password {
+	// Verifikasi password, user lama masih bisa login dengan password plaintext
+	if !helpers.CheckPassword(mUser.Password, input.Password) {
+		writeLoginLog(db, ctx, &mUser, mUser.Username, "FAILED", "INVALID_PASSWORD", "")
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"message": "Invalid password",
 		})
 	}
 
-	// Buat token JWT
-	access_token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": mUser.ID,
-		// "exp":    time.Now().Add(time.Hour * 24).Unix(), // Token berlaku 24 jam
-		"exp":  time.Now().Add(time.Hour * 24 * 365 * 100).Unix(), // 100 tahun
-		"unit": config.DBUnit,
-		// Setting 30 Detik untuk testing
-		// "exp": time.Now().Add(time.Second * 15).Unix(),
-	})
+	// Password plaintext langsung di-hash ulang setelah login berhasil
+	if !helpers.IsPasswordHashed(mUser.Password) {
+		hashed, err := helpers.HashPassword(input.Password)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Failed to hash password",
+			})
+		}
+		if err := helpers.SetUserPassword(db, mUser.ID, hashed, int(mUser.ID)); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
 
-	// Buat refresh token JWT
-	refresh_token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": mUser.ID,
-		"unit":   config.DBUnit,
-		"exp":    time.Now().Add(time.Hour * 24).Unix(), // Token berlaku 24 jam
-	})
+	// Setiap login membuat session baru, dicatat di login log
+	sessionID, err := helpers.RandomToken(16)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to generate session",
+		})
+	}
 
-	accesTokenString, err := access_token.SignedString([]byte(config.JWTSecret))
+	accesTokenString, expiresAt, err := helpers.IssueAccessToken(db, mUser.ID, config.DBUnit, sessionID)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to generate token",
 		})
 	}
 
-	refreshTokenString, err := refresh_token.SignedString([]byte(config.JWTSecret))
+	refreshTokenString, _, err := helpers.IssueRefreshToken(db, mUser.ID, sessionID)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to generate token",
 		})
 	}
 
+	if err := writeLoginLog(db, ctx, &mUser, mUser.Username, "SUCCESS", "", sessionID); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	// bersihkan revocation list yang sudah lewat masa berlakunya
+	if err := helpers.PurgeExpiredTokens(db); err != nil {
+		log.Println("Login: gagal purge expired tokens:", err)
+	}
+
 	// Simpan refresh token ke cookie
 	ctx.Cookie(config.GetTokenCookie(refreshTokenString))
 
@@ -291,9 +479,10 @@ func Login(ctx *fiber.Ctx) error {
 
 	// Return data user (opsional, jangan kirim password)
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Login successful",
-		"x_token": accesTokenString,
+		"success":    true,
+		"message":    "Login successful",
+		"x_token":    accesTokenString,
+		"expires_at": expiresAt,
 		"user": fiber.Map{
 			"id":       mUser.ID,
 			"email":    mUser.Email,
@@ -315,47 +504,73 @@ func RefreshToken(ctx *fiber.Ctx) error {
 		})
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.JWTSecret), nil
-	})
+	db, err := database.GetDBConnection(config.DBUnit)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to connect to database",
+		})
+	}
 
+	// Refresh token selalu dirotasi, token lama tidak bisa dipakai lagi
+	newRefreshToken, refresh, err := helpers.RotateRefreshToken(db, tokenString)
 	if err != nil {
+		if errors.Is(err, helpers.ErrRefreshTokenReused) {
+			ctx.Cookie(config.GetTokenCookie(""))
+		}
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"message": "Unauthorized",
+			"error":   err.Error(),
 		})
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Generate new token
-		newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"userID": claims["userID"],
-			"unit":   claims["unit"],
-			"exp":    time.Now().Add(time.Second * 15).Unix(),
-			// "exp":    time.Now().Add(time.Hour * 24).Unix(), // Token berlaku 24 jam
+	newTokenString, expiresAt, err := helpers.IssueAccessToken(db, refresh.UserID, config.DBUnit, refresh.SessionID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to generate token",
 		})
-		newTokenString, err := newToken.SignedString([]byte(config.JWTSecret))
-		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Failed to generate token",
-			})
-		}
+	}
 
-		// Simpan token ke cookie
-		// ctx.Cookie(config.GetTokenCookie(newTokenString))
+	ctx.Cookie(config.GetTokenCookie(newRefreshToken))
 
-		fmt.Println("newTokenString: ", newTokenString)
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success":      true,
+		"message":      "Token refreshed successfully",
+		"access_token": newTokenString,
+		"expires_at":   expiresAt,
+	})
+}
 
-		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-			"success":      true,
-			"message":      "Token refreshed successfully",
-			"access_token": newTokenString,
-		})
+// writeLoginLog mencatat percobaan login, user nil kalau username tidak ditemukan
+func writeLoginLog(db *gorm.DB, ctx *fiber.Ctx, user *models.User, username, status, reason, sessionID string) error {
+	now := time.Now()
+	userAgent := ctx.Get("User-Agent")
+	browser, os, deviceType := helpers.ParseUserAgent(userAgent)
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	if len(username) > 50 {
+		username = username[:50]
 	}
 
-	return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		"message": "Unauthorized",
-	})
+	loginLog := models.LoginLog{
+		Username:    username,
+		LoginAt:     &now,
+		IPAddress:   ctx.IP(),
+		UserAgent:   userAgent,
+		Browser:     browser,
+		OS:          os,
+		DeviceType:  deviceType,
+		LoginStatus: status,
+		SessionID:   sessionID,
+	}
+	if user != nil {
+		userID := uint64(user.ID)
+		loginLog.UserID = &userID
+	}
+	if reason != "" {
+		loginLog.FailureReason = &reason
+	}
+
+	return db.Create(&loginLog).Error
 }
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type CycleCountController struct {
+	DB *gorm.DB
+}
+
+func (c *CycleCountController) GetClasses(ctx *fiber.Ctx) error {
+	classes, err := repositories.NewCycleCountRepository(c.DB).GetClasses()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": classes})
+}
+
+func (c *CycleCountController) SaveClass(ctx *fiber.Ctx) error {
+	var input struct {
+		ClassCode     string  `json:"class_code"`
+		CumulativePct float64 `json:"cumulative_pct"`
+		FrequencyDays int     `json:"frequency_days"`
+		IsActive      *bool   `json:"is_active"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if input.ClassCode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Class code is required"})
+	}
+	if input.CumulativePct <= 0 || input.CumulativePct > 100 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cumulative percentage must be between 0 and 100"})
+	}
+	if input.FrequencyDays <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Frequency days must be greater than 0"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var class models.CycleCountClass
+	err := c.DB.Where("class_code = ?", input.ClassCode).First(&class).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	class.ClassCode = input.ClassCode
+	class.CumulativePct = input.CumulativePct
+	class.FrequencyDays = input.FrequencyDays
+	class.IsActive = input.IsActive == nil || *input.IsActive
+	class.UpdatedBy = userID
+
+	if class.ID == 0 {
+		class.CreatedBy = userID
+		err = c.DB.Create(&class).Error
+	} else {
+		err = c.DB.Save(&class).Error
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Class " + class.ClassCode + " saved successfully", "data": class})
+}
+
+func (c *CycleCountController) DeleteClass(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var class models.CycleCountClass
+	if err := c.DB.Where("id = ?", ctx.Params("id")).First(&class).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Class not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	class.DeletedBy = userID
+	if err := c.DB.Save(&class).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := c.DB.Delete(&class).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Class deleted successfully"})
+}
+
+// Classify hitung ulang class ABC semua item berdasarkan frekuensi pick atau nilai stock
+func (c *CycleCountController) Classify(ctx *fiber.Ctx) error {
+	var input struct {
+		Basis string `json:"basis"`
+		Days  int    `json:"days"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var summary map[string]int
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		summary, err = repositories.NewCycleCountRepository(tx).Classify(input.Basis, input.Days, userID)
+		return err
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Items classified successfully", "data": summary})
+}
+
+func (c *CycleCountController) GetClassifications(ctx *fiber.Ctx) error {
+	query := c.DB.Order("class_code, item_code")
+	if classCode := ctx.Query("class_code"); classCode != "" {
+		query = query.Where("class_code = ?", classCode)
+	}
+
+	var classifications []models.ItemClassification
+	if err := query.Find(&classifications).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": classifications})
+}
+
+func (c *CycleCountController) GetDue(ctx *fiber.Ctx) error {
+	due, err := repositories.NewCycleCountRepository(c.DB).GetDue(time.Now())
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": due})
+}
+
+// Generate membuat cycle count dari item jatuh tempo, hasilnya diposting lewat stock take
+func (c *CycleCountController) Generate(ctx *fiber.Ctx) error {
+	var input struct {
+		WhsCode      string `json:"whs_code"`
+		MaxLocations int    `json:"max_locations"`
+		IsBlind      bool   `json:"is_blind"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var stockTake models.StockTake
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		stockTake, err = repositories.NewCycleCountRepository(tx).Generate(input.WhsCode, input.MaxLocations, input.IsBlind, userID)
+		return err
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "message": "Cycle count " + stockTake.Code + " generated successfully", "data": blindStockTake(stockTake)})
+}
+
+// Recount count ulang blind untuk baris yang selisih
+func (c *CycleCountController) Recount(ctx *fiber.Ctx) error {
+	var stockTake models.StockTake
+	if err := c.DB.First(&stockTake, "code = ?", ctx.Params("code")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Stock take not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var recount models.StockTake
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		recount, err = repositories.NewCycleCountRepository(tx).Recount(stockTake, userID)
+		return err
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "message": "Recount " + recount.Code + " created for " + stockTake.Code, "data": blindStockTake(recount)})
+}
+
+// GetAccuracy IRA per bulan dan class, default 12 bulan terakhir (from / to format YYYY-MM)
+func (c *CycleCountController) GetAccuracy(ctx *fiber.Ctx) error {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -11, 0)
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1, 0)
+
+	if value := ctx.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, now.Location())
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid from period, use YYYY-MM"})
+		}
+		from = parsed
+	}
+	if value := ctx.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, now.Location())
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid to period, use YYYY-MM"})
+		}
+		to = parsed.AddDate(0, 1, 0)
+	}
+
+	accuracy, err := repositories.NewCycleCountRepository(c.DB).GetAccuracy(from, to)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": accuracy})
+}
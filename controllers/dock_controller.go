@@ -0,0 +1,471 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type DockController struct {
+	DB *gorm.DB
+}
+
+func (c *DockController) findAppointment(db *gorm.DB, appointmentNo string) (models.DockAppointment, error) {
+	var appointment models.DockAppointment
+	if err := db.Where("appointment_no = ?", appointmentNo).First(&appointment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appointment, fiber.NewError(fiber.StatusNotFound, "Appointment "+appointmentNo+" not found")
+		}
+		return appointment, err
+	}
+	return appointment, nil
+}
+
+func (c *DockController) GetDoors(ctx *fiber.Ctx) error {
+	query := c.DB.Order("door_code")
+	if whsCode := ctx.Query("whs_code"); whsCode != "" {
+		query = query.Where("whs_code = ?", whsCode)
+	}
+
+	var doors []models.DockDoor
+	if err := query.Find(&doors).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": doors})
+}
+
+// SaveDoor insert atau update pintu dock berdasarkan door code
+func (c *DockController) SaveDoor(ctx *fiber.Ctx) error {
+	var input struct {
+		DoorCode string `json:"door_code"`
+		Name     string `json:"name"`
+		WhsCode  string `json:"whs_code"`
+		DoorType string `json:"door_type"`
+		IsActive *bool  `json:"is_active"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if input.DoorCode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Door code is required"})
+	}
+	if input.DoorType == "" {
+		input.DoorType = repositories.DockBoth
+	}
+	if input.DoorType != repositories.DockInbound && input.DoorType != repositories.DockOutbound && input.DoorType != repositories.DockBoth {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Door type must be inbound, outbound or both"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var door models.DockDoor
+	err := c.DB.Where("door_code = ?", input.DoorCode).First(&door).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	door.DoorCode = input.DoorCode
+	door.Name = input.Name
+	door.WhsCode = input.WhsCode
+	door.DoorType = input.DoorType
+	door.IsActive = input.IsActive == nil || *input.IsActive
+	door.UpdatedBy = userID
+
+	if door.ID == 0 {
+		door.CreatedBy = userID
+		err = c.DB.Create(&door).Error
+	} else {
+		err = c.DB.Save(&door).Error
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Dock door saved successfully", "data": door})
+}
+
+func (c *DockController) GetAppointments(ctx *fiber.Ctx) error {
+	query := c.DB.Order("start_time")
+	if date := ctx.Query("date"); date != "" {
+		day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date, use YYYY-MM-DD"})
+		}
+		query = query.Where("start_time < ? AND end_time > ?", day.AddDate(0, 0, 1), day)
+	}
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if refNo := ctx.Query("ref_no"); refNo != "" {
+		query = query.Where("ref_no = ?", refNo)
+	}
+
+	var appointments []models.DockAppointment
+	if err := query.Find(&appointments).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": appointments})
+}
+
+// BookAppointment booking slot pintu untuk satu inbound atau outbound
+func (c *DockController) BookAppointment(ctx *fiber.Ctx) error {
+	var input struct {
+		Direction       string `json:"direction"`
+		RefNo           string `json:"ref_no"`
+		DoorCode        string `json:"door_code"`
+		StartTime       string `json:"start_time"`
+		EndTime         string `json:"end_time"`
+		TransporterCode string `json:"transporter_code"`
+		TruckNo         string `json:"truck_no"`
+		Driver          string `json:"driver"`
+		Remarks         string `json:"remarks"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	start, err := repositories.ParseDockTime(input.StartTime)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	end, err := repositories.ParseDockTime(input.EndTime)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	appointment := models.DockAppointment{
+		Direction:       input.Direction,
+		RefNo:           input.RefNo,
+		TransporterCode: input.TransporterCode,
+		TruckNo:         input.TruckNo,
+		Driver:          input.Driver,
+		StartTime:       start,
+		EndTime:         end,
+		Status:          repositories.AppointmentBooked,
+		Remarks:         input.Remarks,
+		CreatedBy:       userID,
+		UpdatedBy:       userID,
+	}
+
+	switch input.Direction {
+	case repositories.DockInbound:
+		var inbound models.InboundHeader
+		if err := tx.Where("inbound_no = ?", input.RefNo).First(&inbound).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Inbound " + input.RefNo + " not found"})
+		}
+		if inbound.Status == "complete" || inbound.Status == "cancel" {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Inbound " + input.RefNo + " is " + inbound.Status})
+		}
+		appointment.WhsCode = inbound.WhsCode
+		appointment.OwnerCode = inbound.OwnerCode
+		if appointment.TruckNo == "" {
+			appointment.TruckNo = inbound.NoTruck
+		}
+		if appointment.Driver == "" {
+			appointment.Driver = inbound.Driver
+		}
+		if appointment.TransporterCode == "" {
+			appointment.TransporterCode = inbound.Transporter
+		}
+	case repositories.DockOutbound:
+		var outbound models.OutboundHeader
+		if err := tx.Where("outbound_no = ?", input.RefNo).First(&outbound).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Outbound " + input.RefNo + " not found"})
+		}
+		if outbound.Status == "complete" || outbound.Status == "cancel" {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Outbound " + input.RefNo + " is " + outbound.Status})
+		}
+		appointment.WhsCode = outbound.WhsCode
+		appointment.OwnerCode = outbound.OwnerCode
+		if appointment.TruckNo == "" {
+			appointment.TruckNo = outbound.TruckNo
+		}
+		if appointment.Driver == "" {
+			appointment.Driver = outbound.Driver
+		}
+		if appointment.TransporterCode == "" {
+			appointment.TransporterCode = outbound.TransporterCode
+		}
+
+		// jadwal pickup outbound mengikuti slot dock
+		if err := tx.Model(&models.OutboundHeader{}).Where("id = ?", outbound.ID).Updates(map[string]interface{}{
+			"plan_pickup_date": start.Format("2006-01-02"),
+			"plan_pickup_time": start.Format("15:04"),
+			"updated_by":       userID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	default:
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Direction must be inbound or outbound"})
+	}
+
+	var active int64
+	if err := tx.Model(&models.DockAppointment{}).Where("ref_no = ? AND direction = ? AND status IN ?", input.RefNo, input.Direction,
+		[]string{repositories.AppointmentBooked, repositories.AppointmentArrived, repositories.AppointmentDocked}).Count(&active).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if active > 0 {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": input.RefNo + " already has an active appointment"})
+	}
+
+	dockRepo := repositories.NewDockRepository(tx)
+	door, err := dockRepo.ValidateSlot(input.DoorCode, appointment.WhsCode, input.Direction, start, end, 0)
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+	appointment.DoorID = door.ID
+	appointment.DoorCode = door.DoorCode
+
+	if appointment.AppointmentNo, err = dockRepo.GenerateAppointmentNo(appointment.OwnerCode, appointment.WhsCode); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Create(&appointment).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Appointment " + appointment.AppointmentNo + " booked", "data": appointment})
+}
+
+// RescheduleAppointment pindah pintu / jam, hanya selama truk belum datang
+func (c *DockController) RescheduleAppointment(ctx *fiber.Ctx) error {
+	var input struct {
+		DoorCode  string `json:"door_code"`
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	start, err := repositories.ParseDockTime(input.StartTime)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	end, err := repositories.ParseDockTime(input.EndTime)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	appointment, err := c.findAppointment(tx, ctx.Params("appointment_no"))
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+	if appointment.Status != repositories.AppointmentBooked {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Appointment " + appointment.AppointmentNo + " is " + appointment.Status})
+	}
+
+	if input.DoorCode == "" {
+		input.DoorCode = appointment.DoorCode
+	}
+	door, err := repositories.NewDockRepository(tx).ValidateSlot(input.DoorCode, appointment.WhsCode, appointment.Direction, start, end, appointment.ID)
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	if err := tx.Model(&appointment).Updates(map[string]interface{}{
+		"door_id":    door.ID,
+		"door_code":  door.DoorCode,
+		"start_time": start,
+		"end_time":   end,
+		"updated_by": userID,
+	}).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if appointment.Direction == repositories.DockOutbound {
+		if err := tx.Model(&models.OutboundHeader{}).Where("outbound_no = ?", appointment.RefNo).Updates(map[string]interface{}{
+			"plan_pickup_date": start.Format("2006-01-02"),
+			"plan_pickup_time": start.Format("15:04"),
+			"updated_by":       userID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Appointment " + appointment.AppointmentNo + " rescheduled", "data": appointment})
+}
+
+// moveAppointment mengubah status appointment dan mencatat jamnya ke header inbound
+func (c *DockController) moveAppointment(ctx *fiber.Ctx, from []string, to, timeColumn, inboundColumn string) error {
+	var input struct {
+		TruckNo string `json:"truck_no"`
+		Driver  string `json:"driver"`
+	}
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&input); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		}
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	appointment, err := c.findAppointment(tx, ctx.Params("appointment_no"))
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	allowed := false
+	for _, status := range from {
+		if appointment.Status == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Appointment " + appointment.AppointmentNo + " is " + appointment.Status})
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     to,
+		timeColumn:   now,
+		"updated_by": userID,
+	}
+	if input.TruckNo != "" {
+		updates["truck_no"] = input.TruckNo
+	}
+	if input.Driver != "" {
+		updates["driver"] = input.Driver
+	}
+	if err := tx.Model(&appointment).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	header := map[string]interface{}{"updated_by": userID}
+	if input.TruckNo != "" {
+		header["truck_no"] = input.TruckNo
+	}
+	if input.Driver != "" {
+		header["driver"] = input.Driver
+	}
+
+	if appointment.Direction == repositories.DockInbound {
+		if inboundColumn != "" {
+			header[inboundColumn] = now.Format(repositories.DockTimeLayout)
+		}
+		if truckNo, ok := header["truck_no"]; ok {
+			delete(header, "truck_no")
+			header["no_truck"] = truckNo
+		}
+		err = tx.Model(&models.InboundHeader{}).Where("inbound_no = ?", appointment.RefNo).Updates(header).Error
+	} else if len(header) > 1 {
+		err = tx.Model(&models.OutboundHeader{}).Where("outbound_no = ?", appointment.RefNo).Updates(header).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Appointment " + appointment.AppointmentNo + " " + to})
+}
+
+// CheckIn truk datang di gate, inbound mencatat arrival time
+func (c *DockController) CheckIn(ctx *fiber.Ctx) error {
+	return c.moveAppointment(ctx, []string{repositories.AppointmentBooked}, repositories.AppointmentArrived, "arrived_at", "arrival_time")
+}
+
+// Dock truk masuk pintu, inbound mencatat start unloading
+func (c *DockController) Dock(ctx *fiber.Ctx) error {
+	return c.moveAppointment(ctx, []string{repositories.AppointmentArrived}, repositories.AppointmentDocked, "docked_at", "start_unloading")
+}
+
+// CheckOut truk keluar, inbound mencatat end unloading
+func (c *DockController) CheckOut(ctx *fiber.Ctx) error {
+	return c.moveAppointment(ctx, []string{repositories.AppointmentArrived, repositories.AppointmentDocked}, repositories.AppointmentDeparted, "departed_at", "end_unloading")
+}
+
+func (c *DockController) CancelAppointment(ctx *fiber.Ctx) error {
+	appointment, err := c.findAppointment(c.DB, ctx.Params("appointment_no"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	if appointment.Status != repositories.AppointmentBooked && appointment.Status != repositories.AppointmentArrived {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Appointment " + appointment.AppointmentNo + " is " + appointment.Status})
+	}
+
+	if err := c.DB.Model(&appointment).Updates(map[string]interface{}{
+		"status":     repositories.AppointmentCancel,
+		"updated_by": int(ctx.Locals("userID").(float64)),
+	}).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Appointment " + appointment.AppointmentNo + " cancelled"})
+}
+
+// GetSchedule jadwal dock harian untuk tim yard, default hari ini
+func (c *DockController) GetSchedule(ctx *fiber.Ctx) error {
+	date := ctx.Query("date", time.Now().Format("2006-01-02"))
+
+	schedule, err := repositories.NewDockRepository(c.DB).GetSchedule(date, ctx.Query("whs_code"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": schedule})
+}
@@ -0,0 +1,12 @@
+package controllers
+
+import (
+	"fiber-app/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errorResponse balas error dari repository dengan status sesuai jenisnya, lihat repositories.ErrorStatus
+func errorResponse(ctx *fiber.Ctx, err error) error {
+	return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"success": false, "message": err.Error(), "error": err.Error()})
+}
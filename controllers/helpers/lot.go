@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"errors"
+	"time"
+)
+
+// ValidateLotDates checks mfg/expiry dates (YYYY-MM-DD). Empty dates are allowed.
+func ValidateLotDates(mfgDate, expDate string) error {
+	var mfg, exp time.Time
+	var err error
+
+	if mfgDate != "" {
+		if mfg, err = time.Parse("2006-01-02", mfgDate); err != nil {
+			return errors.New("invalid mfg date, expected format YYYY-MM-DD")
+		}
+	}
+
+	if expDate != "" {
+		if exp, err = time.Parse("2006-01-02", expDate); err != nil {
+			return errors.New("invalid expiry date, expected format YYYY-MM-DD")
+		}
+	}
+
+	if mfgDate != "" && expDate != "" && exp.Before(mfg) {
+		return errors.New("expiry date cannot be before mfg date")
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"fiber-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis movement di inventory ledger
+const (
+	MovementPutaway      = "PUTAWAY"
+	MovementAllocate     = "ALLOCATE"
+	MovementUnallocate   = "UNALLOCATE"
+	MovementShip         = "SHIP"
+	MovementUnpostOut    = "UNPOST_OUT"
+	MovementUnpostIn     = "UNPOST_IN"
+	MovementMoveOut      = "MOVE_OUT"
+	MovementMoveIn       = "MOVE_IN"
+	MovementStatusOut    = "CHANGE_STATUS_OUT"
+	MovementStatusIn     = "CHANGE_STATUS_IN"
+	MovementTransferOut  = "TRANSFER_OUT"
+	MovementTransferIn   = "TRANSFER_IN"
+	MovementOverrideFrom = "OVERRIDE_FROM"
+	MovementOverrideTo   = "OVERRIDE_TO"
+	MovementReturnIn     = "RETURN_IN"
+	MovementKitConsume   = "KIT_CONSUME"
+	MovementKitProduce   = "KIT_PRODUCE"
+	MovementHold         = "HOLD"
+	MovementHoldRelease  = "HOLD_RELEASE"
+	MovementAdjustIn     = "ADJUST_IN"
+	MovementAdjustOut    = "ADJUST_OUT"
+	MovementDummy        = "DUMMY"
+)
+
+// InsertInventoryMovement writes one ledger row for an inventory that was just changed.
+// before holds the row as it was prior to the change (only ID set for a newly created row);
+// the after state is read back through db, so call it with the same transaction.
+func InsertInventoryMovement(db *gorm.DB, before models.Inventory, movementType, refNo string, actor int) error {
+	var after models.Inventory
+	if err := db.Where("id = ?", before.ID).First(&after).Error; err != nil {
+		return err
+	}
+
+	movement := models.InventoryMovement{
+		InventoryID:        int(after.ID),
+		MovementType:       movementType,
+		RefNo:              refNo,
+		OwnerCode:          after.OwnerCode,
+		WhsCode:            after.WhsCode,
+		ItemID:             after.ItemId,
+		ItemCode:           after.ItemCode,
+		Location:           after.Location,
+		Pallet:             after.Pallet,
+		QaStatus:           after.QaStatus,
+		LotNo:              after.LotNo,
+		QtyOnhandBefore:    before.QtyOnhand,
+		QtyOnhandAfter:     after.QtyOnhand,
+		QtyAvailableBefore: before.QtyAvailable,
+		QtyAvailableAfter:  after.QtyAvailable,
+		QtyAllocatedBefore: before.QtyAllocated,
+		QtyAllocatedAfter:  after.QtyAllocated,
+		QtyShippedBefore:   before.QtyShipped,
+		QtyShippedAfter:    after.QtyShipped,
+		QtyChange:          after.QtyOnhand - before.QtyOnhand,
+		CreatedAt:          time.Now(),
+		CreatedBy:          actor,
+	}
+
+	return db.Create(&movement).Error
+}
@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OwnerScopeKey adalah key gorm setting yang berisi owner code yang boleh diakses user
+const OwnerScopeKey = "owner_scope:codes"
+
+var ErrOwnerNotAllowed = errors.New("owner is not assigned to this user")
+
+type cachedOwners struct {
+	codes     []string
+	expiresAt time.Time
+}
+
+var (
+	ownerCache   = map[string]cachedOwners{}
+	ownerCacheMu sync.RWMutex
+)
+
+// GetUserOwnerCodes mengambil owner yang di-assign ke user. Slice kosong berarti user tidak dibatasi.
+func GetUserOwnerCodes(db *gorm.DB, unit string, userID uint) ([]string, error) {
+	key := fmt.Sprintf("%s:%d", unit, userID)
+
+	ownerCacheMu.RLock()
+	cached, ok := ownerCache[key]
+	ownerCacheMu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.codes, nil
+	}
+
+	var codes []string
+	if err := db.Model(&models.UserOwner{}).Where("user_id = ?", userID).Pluck("owner_code", &codes).Error; err != nil {
+		return nil, err
+	}
+
+	ownerCacheMu.Lock()
+	ownerCache[key] = cachedOwners{codes: codes, expiresAt: time.Now().Add(permissionCacheTTL)}
+	ownerCacheMu.Unlock()
+
+	return codes, nil
+}
+
+func InvalidateOwnerCache() {
+	ownerCacheMu.Lock()
+	ownerCache = map[string]cachedOwners{}
+	ownerCacheMu.Unlock()
+}
+
+// WithOwnerScope menempelkan owner code ke db, semua query model ber-OwnerCode akan difilter
+func WithOwnerScope(db *gorm.DB, codes []string) *gorm.DB {
+	if len(codes) == 0 {
+		return db
+	}
+	return db.Set(OwnerScopeKey, codes).Session(&gorm.Session{})
+}
+
+// OwnerCodesFromDB mengembalikan owner yang boleh diakses, ok false kalau tidak dibatasi
+func OwnerCodesFromDB(db *gorm.DB) ([]string, bool) {
+	value, ok := db.Get(OwnerScopeKey)
+	if !ok {
+		return nil, false
+	}
+	codes, ok := value.([]string)
+	if !ok || len(codes) == 0 {
+		return nil, false
+	}
+	return codes, true
+}
+
+// IsOwnerAllowed cek satu owner code terhadap scope di db. Owner kosong dianggap data bersama.
+func IsOwnerAllowed(db *gorm.DB, ownerCode string) bool {
+	codes, ok := OwnerCodesFromDB(db)
+	if !ok || ownerCode == "" {
+		return true
+	}
+	for _, code := range codes {
+		if code == ownerCode {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnerScopeSQL dipakai untuk raw query: mengembalikan potongan " AND (...)" dan argumennya
+func OwnerScopeSQL(db *gorm.DB, column string) (string, []interface{}) {
+	codes, ok := OwnerCodesFromDB(db)
+	if !ok {
+		return "", nil
+	}
+	return fmt.Sprintf(" AND (%s IN ? OR COALESCE(%s, '') = '')", column, column), []interface{}{codes}
+}
+
+// RegisterOwnerScope memasang callback gorm supaya query, update dan delete model yang punya
+// OwnerCode otomatis difilter, dan create dengan owner di luar scope ditolak.
+func RegisterOwnerScope(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("owner_scope:query", ownerScopeWhere); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("owner_scope:update", ownerScopeWhere); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("owner_scope:delete", ownerScopeWhere); err != nil {
+		return err
+	}
+	return db.Callback().Create().Before("gorm:create").Register("owner_scope:create", ownerScopeCreate)
+}
+
+func ownerScopeField(db *gorm.DB) string {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return ""
+	}
+	// hanya kalau yang di-query memang tabel model itu sendiri (bukan Table() lain / alias)
+	if stmt.Table != "" && stmt.Table != stmt.Schema.Table {
+		return ""
+	}
+	field := stmt.Schema.LookUpField("OwnerCode")
+	if field == nil || field.DBName == "" {
+		return ""
+	}
+	return field.DBName
+}
+
+func ownerScopeWhere(db *gorm.DB) {
+	codes, ok := OwnerCodesFromDB(db)
+	if !ok {
+		return
+	}
+	column := ownerScopeField(db)
+	if column == "" {
+		return
+	}
+
+	values := make([]interface{}, len(codes))
+	for i, code := range codes {
+		values[i] = code
+	}
+
+	col := clause.Column{Table: clause.CurrentTable, Name: column}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Or(
+			clause.IN{Column: col, Values: values},
+			clause.Eq{Column: col, Value: ""},
+			clause.Eq{Column: col, Value: nil},
+		),
+	}})
+}
+
+func ownerScopeCreate(db *gorm.DB) {
+	if _, ok := OwnerCodesFromDB(db); !ok {
+		return
+	}
+	if ownerScopeField(db) == "" {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("OwnerCode")
+	rv := db.Statement.ReflectValue
+
+	check := func(v reflect.Value) {
+		value, _ := field.ValueOf(db.Statement.Context, v)
+		if code, ok := value.(string); ok && !IsOwnerAllowed(db, code) {
+			db.AddError(fmt.Errorf("%w: %s", ErrOwnerNotAllowed, code))
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			check(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		check(rv)
+	}
+}
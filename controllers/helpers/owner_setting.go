@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"errors"
+	"fiber-app/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultOwnerSetting dipakai untuk owner yang belum punya setting atau field yang dikosongkan
+var DefaultOwnerSetting = models.OwnerSetting{
+	QaStatus:        "A",
+	DivisionCode:    "REGULAR",
+	InboundPrefix:   "IN",
+	OutboundPrefix:  "OB",
+	BillingCurrency: "IDR",
+}
+
+// GetOwnerSetting mengambil setting owner, field yang kosong diisi dengan default
+func GetOwnerSetting(db *gorm.DB, ownerCode string) (models.OwnerSetting, error) {
+	var setting models.OwnerSetting
+	if err := db.Where("owner_code = ?", ownerCode).First(&setting).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return setting, err
+	}
+	setting.OwnerCode = ownerCode
+
+	if setting.QaStatus == "" {
+		setting.QaStatus = DefaultOwnerSetting.QaStatus
+	}
+	if setting.DivisionCode == "" {
+		setting.DivisionCode = DefaultOwnerSetting.DivisionCode
+	}
+	if setting.InboundPrefix == "" {
+		setting.InboundPrefix = DefaultOwnerSetting.InboundPrefix
+	}
+	if setting.OutboundPrefix == "" {
+		setting.OutboundPrefix = DefaultOwnerSetting.OutboundPrefix
+	}
+	if setting.BillingCurrency == "" {
+		setting.BillingCurrency = DefaultOwnerSetting.BillingCurrency
+	}
+
+	return setting, nil
+}
+
+// ResolveUom memakai uom dari payload, kalau kosong pakai default owner lalu uom product
+func ResolveUom(setting models.OwnerSetting, uom, productUom string) string {
+	if uom != "" {
+		return uom
+	}
+	if setting.DefaultUom != "" {
+		return setting.DefaultUom
+	}
+	return productUom
+}
+
+// ResolveSerial mengembalikan flag serial detail, owner dengan SerialMandatory wajib scan serial
+func ResolveSerial(setting models.OwnerSetting, hasSerial string) string {
+	if setting.SerialMandatory {
+		return "Y"
+	}
+	return hasSerial
+}
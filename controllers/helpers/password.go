@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// DefaultPasswordPolicy dipakai kalau belum ada policy di database
+var DefaultPasswordPolicy = models.PasswordPolicy{
+	MinLength:    8,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	HistoryCount: 3,
+}
+
+func HashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// IsPasswordHashed membedakan hash bcrypt dengan password lama yang masih plaintext
+func IsPasswordHashed(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// CheckPassword returns true when password matches the stored value.
+// Stored values that are not bcrypt hashes are legacy plaintext and compared directly.
+func CheckPassword(stored, password string) bool {
+	if !IsPasswordHashed(stored) {
+		return stored != "" && stored == password
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
+
+func GetPasswordPolicy(db *gorm.DB) (models.PasswordPolicy, error) {
+	var policy models.PasswordPolicy
+	if err := db.Order("id ASC").First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultPasswordPolicy, nil
+		}
+		return policy, err
+	}
+	return policy, nil
+}
+
+// ValidatePassword checks password against the policy and the user's last passwords.
+// userID 0 skips the history check (user baru).
+func ValidatePassword(db *gorm.DB, userID uint, password string) error {
+	policy, err := GetPasswordPolicy(db)
+	if err != nil {
+		return err
+	}
+
+	if len(password) < policy.MinLength {
+		return fmt.Errorf("password must be at least %d characters", policy.MinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireUpper && !hasUpper {
+		return errors.New("password must contain an uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		return errors.New("password must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return errors.New("password must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return errors.New("password must contain a symbol")
+	}
+
+	if userID == 0 || policy.HistoryCount <= 0 {
+		return nil
+	}
+
+	var histories []models.PasswordHistory
+	if err := db.Where("user_id = ?", userID).Order("id DESC").Limit(policy.HistoryCount).Find(&histories).Error; err != nil {
+		return err
+	}
+
+	for _, h := range histories {
+		if CheckPassword(h.Password, password) {
+			return fmt.Errorf("password cannot be the same as the last %d passwords", policy.HistoryCount)
+		}
+	}
+
+	return nil
+}
+
+// SetUserPassword menyimpan hash baru ke user dan mencatatnya di password history
+func SetUserPassword(db *gorm.DB, userID uint, hashed string, actor int) error {
+	if err := db.Model(&models.User{}).Where("id = ?", userID).Update("password", hashed).Error; err != nil {
+		return err
+	}
+
+	history := models.PasswordHistory{
+		UserID:    userID,
+		Password:  hashed,
+		CreatedAt: time.Now(),
+		CreatedBy: actor,
+	}
+
+	return db.Create(&history).Error
+}
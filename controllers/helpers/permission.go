@@ -0,0 +1,208 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PermissionMap memetakan "METHOD /path" (relatif terhadap prefix group) ke nama permission.
+// METHOD boleh "*", segment ":param" cocok dengan satu segment, "*" di akhir cocok dengan sisa path.
+// Nama permission kosong berarti cukup login tanpa permission khusus.
+type PermissionMap map[string]string
+
+type RoutePermissionGroup struct {
+	Prefix      string
+	Permissions PermissionMap
+}
+
+var (
+	permissionMaps   []PermissionMap
+	permissionGroups []RoutePermissionGroup
+	permissionMu     sync.RWMutex
+)
+
+// RegisterPermissions dipanggil dari deklarasi map di routes/*.go supaya permission ikut di-seed
+func RegisterPermissions(m PermissionMap) PermissionMap {
+	permissionMu.Lock()
+	defer permissionMu.Unlock()
+	permissionMaps = append(permissionMaps, m)
+	return m
+}
+
+// BindPermissionGroup mencatat prefix route yang dijaga oleh map, dipakai untuk listing endpoint
+func BindPermissionGroup(prefix string, m PermissionMap) {
+	permissionMu.Lock()
+	defer permissionMu.Unlock()
+	permissionGroups = append(permissionGroups, RoutePermissionGroup{Prefix: strings.TrimRight(prefix, "/"), Permissions: m})
+}
+
+// AllPermissionNames mengembalikan semua nama permission unik dari map yang terdaftar
+func AllPermissionNames() []string {
+	permissionMu.RLock()
+	defer permissionMu.RUnlock()
+
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range permissionMaps {
+		for _, name := range m {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FindPermissionGroup mencari group dengan prefix terpanjang yang menaungi path
+func FindPermissionGroup(path string) (RoutePermissionGroup, bool) {
+	permissionMu.RLock()
+	defer permissionMu.RUnlock()
+
+	var found RoutePermissionGroup
+	ok := false
+	for _, g := range permissionGroups {
+		if PathHasPrefix(path, g.Prefix) && len(g.Prefix) > len(found.Prefix) {
+			found = g
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+func PathHasPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// Resolve mencari permission untuk method dan path relatif, pattern paling spesifik yang menang
+func (m PermissionMap) Resolve(method, path string) (string, bool) {
+	path = "/" + strings.Trim(path, "/")
+	pathSegments := splitPath(path)
+
+	bestScore := -1
+	bestPermission := ""
+	for key, permission := range m {
+		parts := strings.SplitN(strings.TrimSpace(key), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		score := 0
+		switch {
+		case strings.EqualFold(parts[0], method):
+			score += 1000
+		case parts[0] == "*":
+		default:
+			continue
+		}
+
+		segScore, ok := matchSegments(splitPath(parts[1]), pathSegments)
+		if !ok {
+			continue
+		}
+		score += segScore
+
+		if score > bestScore {
+			bestScore = score
+			bestPermission = permission
+		}
+	}
+
+	return bestPermission, bestScore >= 0
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func matchSegments(pattern, path []string) (int, bool) {
+	score := 0
+	for i, seg := range pattern {
+		if seg == "*" && i == len(pattern)-1 {
+			return score, true
+		}
+		if i >= len(path) {
+			return 0, false
+		}
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			score += 1
+		case seg == path[i]:
+			score += 10
+		default:
+			return 0, false
+		}
+	}
+	if len(pattern) != len(path) {
+		return 0, false
+	}
+	// pattern tanpa wildcard lebih spesifik dari pattern dengan wildcard
+	return score + 5, true
+}
+
+type cachedPermissions struct {
+	names     map[string]bool
+	expiresAt time.Time
+}
+
+const permissionCacheTTL = 5 * time.Minute
+
+var (
+	permissionCache   = map[string]cachedPermissions{}
+	permissionCacheMu sync.RWMutex
+)
+
+// GetUserPermissions mengambil permission user (dari role dan permission langsung), di-cache per unit dan user
+func GetUserPermissions(db *gorm.DB, unit string, userID uint) (map[string]bool, error) {
+	key := fmt.Sprintf("%s:%d", unit, userID)
+
+	permissionCacheMu.RLock()
+	cached, ok := permissionCache[key]
+	permissionCacheMu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.names, nil
+	}
+
+	var names []string
+	err := db.Raw(`SELECT p.name FROM permissions p
+		INNER JOIN role_permissions rp ON rp.permission_id = p.id
+		INNER JOIN user_roles ur ON ur.role_id = rp.role_id
+		INNER JOIN roles r ON r.id = ur.role_id AND r.deleted_at IS NULL
+		WHERE ur.user_id = ? AND p.deleted_at IS NULL
+		UNION
+		SELECT p.name FROM permissions p
+		INNER JOIN user_permissions up ON up.permission_id = p.id
+		WHERE up.user_id = ? AND p.deleted_at IS NULL`, userID, userID).
+		Scan(&names).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(names))
+	for _, name := range names {
+		result[name] = true
+	}
+
+	permissionCacheMu.Lock()
+	permissionCache[key] = cachedPermissions{names: result, expiresAt: time.Now().Add(permissionCacheTTL)}
+	permissionCacheMu.Unlock()
+
+	return result, nil
+}
+
+// InvalidatePermissionCache dipanggil setiap kali role atau permission user berubah
+func InvalidatePermissionCache() {
+	permissionCacheMu.Lock()
+	permissionCache = map[string]cachedPermissions{}
+	permissionCacheMu.Unlock()
+}
@@ -0,0 +1,272 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fiber-app/config"
+	"fiber-app/models"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// Alasan revoke di revocation list
+const (
+	RevokeLogout     = "LOGOUT"
+	RevokeLogoutAll  = "LOGOUT_ALL"
+	RevokeSession    = "SESSION_REVOKED"
+	RevokeTokenReuse = "REFRESH_REUSE"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token already used, session revoked")
+
+// DefaultTokenSetting dipakai kalau belum ada setting di database
+var DefaultTokenSetting = models.TokenSetting{
+	AccessTokenTTL:  15,
+	RefreshTokenTTL: 24,
+}
+
+func GetTokenSetting(db *gorm.DB) (models.TokenSetting, error) {
+	var setting models.TokenSetting
+	if err := db.Order("id ASC").First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultTokenSetting, nil
+		}
+		return setting, err
+	}
+	return setting, nil
+}
+
+func RandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueAccessToken membuat JWT access token untuk satu session, return token dan waktu expired
+func IssueAccessToken(db *gorm.DB, userID uint, unit, sessionID string) (string, time.Time, error) {
+	setting, err := GetTokenSetting(db)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(setting.AccessTokenTTL) * time.Minute)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userID": userID,
+		"unit":   unit,
+		"sid":    sessionID,
+		"jti":    jti,
+		"exp":    expiresAt.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(config.JWTSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// IssueRefreshToken menyimpan refresh token baru (hash) dan mengembalikan token aslinya
+func IssueRefreshToken(db *gorm.DB, userID uint, sessionID string) (string, models.RefreshToken, error) {
+	setting, err := GetTokenSetting(db)
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	raw, err := RandomToken(32)
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	refresh := models.RefreshToken{
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenHash: HashToken(raw),
+		ExpiresAt: time.Now().Add(time.Duration(setting.RefreshTokenTTL) * time.Hour),
+		CreatedAt: time.Now(),
+	}
+
+	if err := db.Create(&refresh).Error; err != nil {
+		return "", refresh, err
+	}
+
+	return raw, refresh, nil
+}
+
+// RotateRefreshToken menukar refresh token lama dengan yang baru.
+// Token yang sudah pernah dipakai dianggap dicuri: seluruh session langsung di-revoke.
+func RotateRefreshToken(db *gorm.DB, raw string) (string, models.RefreshToken, error) {
+	var current models.RefreshToken
+	if err := db.Where("token_hash = ?", HashToken(raw)).First(&current).Error; err != nil {
+		return "", current, err
+	}
+
+	if current.UsedAt != nil {
+		if err := RevokeSessionTokens(db, current.UserID, current.SessionID, RevokeTokenReuse, 0); err != nil {
+			return "", current, err
+		}
+		return "", current, ErrRefreshTokenReused
+	}
+
+	if current.RevokedAt != nil {
+		return "", current, errors.New("refresh token revoked")
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return "", current, errors.New("refresh token expired")
+	}
+
+	// tandai terpakai dengan kondisi used_at IS NULL supaya dua refresh bersamaan tidak sama-sama lolos
+	result := db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", current.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return "", current, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := RevokeSessionTokens(db, current.UserID, current.SessionID, RevokeTokenReuse, 0); err != nil {
+			return "", current, err
+		}
+		return "", current, ErrRefreshTokenReused
+	}
+
+	newRaw, next, err := IssueRefreshToken(db, current.UserID, current.SessionID)
+	if err != nil {
+		return "", next, err
+	}
+
+	if err := db.Model(&models.RefreshToken{}).Where("id = ?", current.ID).Update("replaced_by", next.ID).Error; err != nil {
+		return "", next, err
+	}
+
+	return newRaw, next, nil
+}
+
+// RevokeSessionTokens mencabut semua token di satu session dan menutup login log-nya
+func RevokeSessionTokens(db *gorm.DB, userID uint, sessionID, reason string, actor int) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	setting, err := GetTokenSetting(db)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if err := db.Model(&models.RefreshToken{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	// access token yang masih beredar paling lama hidup selama AccessTokenTTL
+	revoked := models.RevokedToken{
+		SessionID: sessionID,
+		UserID:    userID,
+		Reason:    reason,
+		ExpiresAt: now.Add(time.Duration(setting.AccessTokenTTL) * time.Minute),
+		CreatedAt: now,
+		CreatedBy: actor,
+	}
+	if err := db.Create(&revoked).Error; err != nil {
+		return err
+	}
+
+	return db.Model(&models.LoginLog{}).
+		Where("session_id = ? AND logout_at IS NULL", sessionID).
+		Update("logout_at", now).Error
+}
+
+// IsTokenRevoked dicek oleh AuthMiddleware untuk setiap request
+func IsTokenRevoked(db *gorm.DB, jti, sessionID string) (bool, error) {
+	if jti == "" && sessionID == "" {
+		return false, nil
+	}
+
+	var count int64
+	err := db.Model(&models.RevokedToken{}).
+		Where("expires_at > ?", time.Now()).
+		Where("(jti <> '' AND jti = ?) OR (session_id <> '' AND session_id = ?)", jti, sessionID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// PurgeExpiredTokens membersihkan revocation list dan refresh token yang sudah tidak berlaku
+func PurgeExpiredTokens(db *gorm.DB) error {
+	now := time.Now()
+	if err := db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return db.Where("expires_at < ?", now.Add(-24*time.Hour)).Delete(&models.RefreshToken{}).Error
+}
+
+// ParseUserAgent mengambil browser, OS dan tipe device secara sederhana untuk login log
+func ParseUserAgent(ua string) (browser, os, deviceType string) {
+	lower := strings.ToLower(ua)
+
+	switch {
+	case strings.Contains(lower, "edg/"):
+		browser = "Edge"
+	case strings.Contains(lower, "opr/") || strings.Contains(lower, "opera"):
+		browser = "Opera"
+	case strings.Contains(lower, "chrome/"):
+		browser = "Chrome"
+	case strings.Contains(lower, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(lower, "safari/"):
+		browser = "Safari"
+	case strings.Contains(lower, "dart") || strings.Contains(lower, "okhttp"):
+		browser = "App"
+	default:
+		browser = "Other"
+	}
+
+	switch {
+	case strings.Contains(lower, "android"):
+		os = "Android"
+	case strings.Contains(lower, "iphone") || strings.Contains(lower, "ipad"):
+		os = "iOS"
+	case strings.Contains(lower, "windows"):
+		os = "Windows"
+	case strings.Contains(lower, "mac os"):
+		os = "macOS"
+	case strings.Contains(lower, "linux"):
+		os = "Linux"
+	default:
+		os = "Other"
+	}
+
+	switch {
+	case strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet"):
+		deviceType = "TABLET"
+	case strings.Contains(lower, "mobile") || strings.Contains(lower, "android") || strings.Contains(lower, "iphone"):
+		deviceType = "MOBILE"
+	default:
+		deviceType = "DESKTOP"
+	}
+
+	return browser, os, deviceType
+}
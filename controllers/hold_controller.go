@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type HoldController struct {
+	DB *gorm.DB
+}
+
+func (c *HoldController) GetReasons(ctx *fiber.Ctx) error {
+	var reasons []models.HoldReason
+	if err := c.DB.Order("reason_code").Find(&reasons).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": reasons})
+}
+
+func (c *HoldController) SaveReason(ctx *fiber.Ctx) error {
+	var input struct {
+		ReasonCode       string `json:"reason_code"`
+		Description      string `json:"description"`
+		RequiresApproval bool   `json:"requires_approval"`
+		IsActive         *bool  `json:"is_active"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if input.ReasonCode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Reason code is required"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var reason models.HoldReason
+	err := c.DB.Where("reason_code = ?", input.ReasonCode).First(&reason).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reason.ReasonCode = input.ReasonCode
+	reason.Description = input.Description
+	reason.RequiresApproval = input.RequiresApproval
+	reason.IsActive = input.IsActive == nil || *input.IsActive
+	reason.UpdatedBy = userID
+
+	if reason.ID == 0 {
+		reason.CreatedBy = userID
+		err = c.DB.Create(&reason).Error
+	} else {
+		err = c.DB.Save(&reason).Error
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Reason " + reason.ReasonCode + " saved successfully", "data": reason})
+}
+
+func (c *HoldController) DeleteReason(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var reason models.HoldReason
+	if err := c.DB.Where("id = ?", ctx.Params("id")).First(&reason).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Reason not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reason.DeletedBy = userID
+	if err := c.DB.Save(&reason).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := c.DB.Delete(&reason).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Reason deleted successfully"})
+}
+
+func (c *HoldController) GetHolds(ctx *fiber.Ctx) error {
+	list, err := repositories.NewHoldRepository(c.DB).GetHoldList(ctx.Query("status"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": list})
+}
+
+func (c *HoldController) GetHoldByNo(ctx *fiber.Ctx) error {
+	var hold models.InventoryHold
+	if err := c.DB.Preload("Details").Where("hold_no = ?", ctx.Params("hold_no")).First(&hold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Hold not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": hold})
+}
+
+// CreateHold blokir stock sesuai kriteria, qty available yang cocok langsung dipindah ke suspend
+func (c *HoldController) CreateHold(ctx *fiber.Ctx) error {
+	var input struct {
+		HoldType   string     `json:"hold_type"`
+		OwnerCode  string     `json:"owner_code"`
+		WhsCode    string     `json:"whs_code"`
+		ItemCode   string     `json:"item_code"`
+		LotNo      string     `json:"lot_no"`
+		Location   string     `json:"location"`
+		InboundNo  string     `json:"inbound_no"`
+		ReasonCode string     `json:"reason_code"`
+		Remarks    string     `json:"remarks"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	hold := models.InventoryHold{
+		HoldType:   input.HoldType,
+		OwnerCode:  input.OwnerCode,
+		WhsCode:    input.WhsCode,
+		ItemCode:   input.ItemCode,
+		LotNo:      input.LotNo,
+		Location:   input.Location,
+		InboundNo:  input.InboundNo,
+		ReasonCode: input.ReasonCode,
+		Remarks:    input.Remarks,
+		ExpiresAt:  input.ExpiresAt,
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		return repositories.NewHoldRepository(tx).Place(&hold, userID)
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "message": "Hold " + hold.HoldNo + " created successfully", "data": hold})
+}
+
+func (c *HoldController) ApproveHold(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var hold models.InventoryHold
+	if err := c.DB.Where("hold_no = ?", ctx.Params("hold_no")).First(&hold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Hold not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := repositories.NewHoldRepository(c.DB).Approve(hold, userID); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Hold " + hold.HoldNo + " approved successfully"})
+}
+
+// ReleaseHold mengembalikan stock yang disuspend, juga dipakai untuk membatalkan hold pending
+func (c *HoldController) ReleaseHold(ctx *fiber.Ctx) error {
+	var input struct {
+		Remarks string `json:"remarks"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if input.Remarks == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Release remarks is required"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var hold models.InventoryHold
+	if err := c.DB.Where("hold_no = ?", ctx.Params("hold_no")).First(&hold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Hold not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		return repositories.NewHoldRepository(tx).Release(hold, input.Remarks, userID)
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Hold " + hold.HoldNo + " released successfully"})
+}
@@ -66,6 +66,9 @@ type InboundItem struct {
 	WhsCode     string            `json:"whs_code"`
 	UOM         string            `json:"uom"`
 	RecDate     string            `json:"rec_date"`
+	LotNo       string            `json:"lot_no"`
+	MfgDate     string            `json:"mfg_date"`
+	ExpDate     string            `json:"exp_date"`
 	Remarks     string            `json:"remarks"`
 	IsSerial    string            `json:"is_serial"`
 	Mode        string            `json:"mode"`
@@ -127,6 +130,14 @@ func (c *InboundController) CreateInbound(ctx *fiber.Ctx) error {
 			})
 		}
 
+		if err := helpers.ValidateLotDates(item.MfgDate, item.ExpDate); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "Item " + item.ItemCode + ": " + err.Error(),
+				"error":   err.Error(),
+			})
+		}
+
 		itemCodes[item.ItemCode] = true // tandai sebagai sudah ditemukan
 	}
 
@@ -141,8 +152,15 @@ func (c *InboundController) CreateInbound(ctx *fiber.Ctx) error {
 
 	repositories := repositories.NewInboundRepository(tx)
 
-	inbound_no, err := repositories.GenerateInboundNo()
+	ownerSetting, err := helpers.GetOwnerSetting(tx, payload.OwnerCode)
 	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	inbound_no, err := repositories.GenerateInboundNo(payload.OwnerCode, payload.WhsCode)
+	if err != nil {
+		tx.Rollback()
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to generate inbound no",
@@ -273,19 +291,25 @@ func (c *InboundController) CreateInbound(ctx *fiber.Ctx) error {
 		InboundDetail.ItemCode = item.ItemCode
 		InboundDetail.ItemId = types.SnowflakeID(int64(product.ID))
 		InboundDetail.Barcode = product.Barcode
-		InboundDetail.Uom = item.UOM
+		InboundDetail.Uom = helpers.ResolveUom(ownerSetting, item.UOM, product.Uom)
 		InboundDetail.Quantity = item.Quantity
 		InboundDetail.RcvLocation = item.RcvLocation
-		InboundDetail.QaStatus = "A"
+		InboundDetail.QaStatus = ownerSetting.QaStatus
 		InboundDetail.WhsCode = item.WhsCode
 		InboundDetail.RecDate = item.RecDate
+		InboundDetail.LotNo = item.LotNo
+		InboundDetail.MfgDate = item.MfgDate
+		InboundDetail.ExpDate = item.ExpDate
 		InboundDetail.Remarks = item.Remarks
-		InboundDetail.IsSerial = product.HasSerial
+		InboundDetail.IsSerial = helpers.ResolveSerial(ownerSetting, product.HasSerial)
 		InboundDetail.RefId = int(InboundReference.ID)
 		InboundDetail.RefNo = item.RefNo
 		InboundDetail.OwnerCode = payload.OwnerCode
 		InboundDetail.WhsCode = payload.WhsCode
 		InboundDetail.DivisionCode = item.Division
+		if InboundDetail.DivisionCode == "" {
+			InboundDetail.DivisionCode = ownerSetting.DivisionCode
+		}
 		InboundDetail.CreatedBy = userID
 		InboundDetail.UpdatedBy = userID
 
@@ -361,6 +385,14 @@ func (c *InboundController) UpdateInboundByID(ctx *fiber.Ctx) error {
 			})
 		}
 
+		if err := helpers.ValidateLotDates(item.MfgDate, item.ExpDate); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "Item " + item.ItemCode + ": " + err.Error(),
+				"error":   err.Error(),
+			})
+		}
+
 		itemCodes[item.ItemCode] = true
 	}
 
@@ -438,9 +470,19 @@ func (c *InboundController) UpdateInboundByID(ctx *fiber.Ctx) error {
 			}
 		}
 
+		ownerSetting, err := helpers.GetOwnerSetting(c.DB, InboundHeader.OwnerCode)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		for _, item := range payloadItem {
 			var inboundDetail models.InboundDetail
 
+			division := item.Division
+			if division == "" {
+				division = ownerSetting.DivisionCode
+			}
+
 			var product models.Product
 			if err := c.DB.Debug().First(&product, "item_code = ?", item.ItemCode).Error; err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -463,13 +505,16 @@ func (c *InboundController) UpdateInboundByID(ctx *fiber.Ctx) error {
 					RcvLocation:  item.RcvLocation,
 					WhsCode:      InboundHeader.WhsCode,
 					RecDate:      item.RecDate,
-					Uom:          item.UOM,
-					IsSerial:     product.HasSerial,
+					LotNo:        item.LotNo,
+					MfgDate:      item.MfgDate,
+					ExpDate:      item.ExpDate,
+					Uom:          helpers.ResolveUom(ownerSetting, item.UOM, product.Uom),
+					IsSerial:     helpers.ResolveSerial(ownerSetting, product.HasSerial),
 					RefNo:        item.RefNo,
 					RefId:        item.RefId,
 					OwnerCode:    InboundHeader.OwnerCode,
-					DivisionCode: item.Division,
-					QaStatus:     "A",
+					DivisionCode: division,
+					QaStatus:     ownerSetting.QaStatus,
 					CreatedBy:    int(ctx.Locals("userID").(float64)),
 				}
 				if err := c.DB.Create(&newDetail).Error; err != nil {
@@ -484,13 +529,16 @@ func (c *InboundController) UpdateInboundByID(ctx *fiber.Ctx) error {
 				inboundDetail.RcvLocation = item.RcvLocation
 				inboundDetail.WhsCode = InboundHeader.WhsCode
 				inboundDetail.RecDate = item.RecDate
-				inboundDetail.Uom = item.UOM
-				inboundDetail.IsSerial = product.HasSerial
+				inboundDetail.LotNo = item.LotNo
+				inboundDetail.MfgDate = item.MfgDate
+				inboundDetail.ExpDate = item.ExpDate
+				inboundDetail.Uom = helpers.ResolveUom(ownerSetting, item.UOM, product.Uom)
+				inboundDetail.IsSerial = helpers.ResolveSerial(ownerSetting, product.HasSerial)
 				inboundDetail.RefNo = item.RefNo
 				inboundDetail.RefId = item.RefId
 				inboundDetail.OwnerCode = InboundHeader.OwnerCode
-				inboundDetail.DivisionCode = item.Division
-				inboundDetail.QaStatus = "A"
+				inboundDetail.DivisionCode = division
+				inboundDetail.QaStatus = ownerSetting.QaStatus
 				inboundDetail.UpdatedBy = int(ctx.Locals("userID").(float64))
 
 				if err := c.DB.Save(&inboundDetail).Error; err != nil {
@@ -676,6 +724,103 @@ func (c *InboundController) GetPutawaySheet(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Putaway Sheet Found", "data": putawaySheet})
 }
 
+func (c *InboundController) SuggestPutaway(ctx *fiber.Ctx) error {
+	inbound_no := ctx.Params("inbound_no")
+
+	var inboundHeader models.InboundHeader
+	if err := c.DB.Where("inbound_no = ?", inbound_no).First(&inboundHeader).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Inbound not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	suggestions, err := repositories.NewInboundRepository(c.DB).SuggestPutaway(int(inboundHeader.ID))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	crossDock, err := repositories.NewCrossDockRepository(c.DB).FindMatches(int(inboundHeader.ID))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Putaway suggestion", "data": suggestions, "cross_dock": crossDock})
+}
+
+// GetCrossDock barcode inbound pending yang bisa langsung dialokasikan ke outbound open
+func (c *InboundController) GetCrossDock(ctx *fiber.Ctx) error {
+	inbound_no := ctx.Params("inbound_no")
+
+	var inboundHeader models.InboundHeader
+	if err := c.DB.Where("inbound_no = ?", inbound_no).First(&inboundHeader).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Inbound not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	matches, err := repositories.NewCrossDockRepository(c.DB).FindMatches(int(inboundHeader.ID))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Cross-dock matches", "data": matches})
+}
+
+// ConfirmCrossDock putaway barcode yang dipilih ke staging dan langsung membuat picking sheet outbound
+func (c *InboundController) ConfirmCrossDock(ctx *fiber.Ctx) error {
+	inbound_no := ctx.Params("inbound_no")
+
+	var payload struct {
+		StagingLocation   string `json:"staging_location"`
+		InboundBarcodeIDs []int  `json:"inbound_barcode_ids"`
+	}
+	if err := ctx.BodyParser(&payload); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid payload"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var inboundHeader models.InboundHeader
+	if err := tx.Where("inbound_no = ?", inbound_no).First(&inboundHeader).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Inbound not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if inboundHeader.Status == "complete" || inboundHeader.Status == "cancel" {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Inbound " + inbound_no + " is " + inboundHeader.Status})
+	}
+
+	applied, err := repositories.NewCrossDockRepository(tx).Execute(ctx, inboundHeader, payload.StagingLocation, payload.InboundBarcodeIDs, userID)
+	if err != nil {
+		tx.Rollback()
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if len(applied) == 0 {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No outbound demand matches the received items"})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": fmt.Sprintf("%d line cross-docked to %s", len(applied), payload.StagingLocation), "data": applied})
+}
+
 func (c *InboundController) PutawayByInboundNo(ctx *fiber.Ctx) error {
 	var payload struct {
 		InboundNo string `json:"inbound_no"`
@@ -757,7 +902,7 @@ func (c *InboundController) PutawayByInboundNo(ctx *fiber.Ctx) error {
 
 		if errx := c.putawayPerItem(ctx, barcodeIDStr); errx != nil {
 			fmt.Println("ERROR PUTAWAY ITEM ID", barcodeIDStr, ":", errx.Error())
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed putaway item ID " + barcodeIDStr + ": " + errx.Error()})
+			return ctx.Status(repositories.ErrorStatus(errx)).JSON(fiber.Map{"error": "Failed putaway item ID " + barcodeIDStr + ": " + errx.Error()})
 		}
 	}
 
@@ -792,6 +937,11 @@ func (c *InboundController) putawayPerItem(ctx *fiber.Ctx, idStr string) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
 	}
 
+	// putaway ikut antrian task: diambil user ini, ditolak kalau sedang dikerjakan operator lain
+	if err := repositories.NewTaskRepository(c.DB).EnsureClaim(repositories.TaskPutaway, inboundHeader.InboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+		return err
+	}
+
 	inboundRepo := repositories.NewInboundRepository(c.DB)
 
 	_, errs := inboundRepo.PutawayItem(ctx, id, "")
@@ -882,7 +1032,7 @@ func (c *InboundController) PutawayBulk(ctx *fiber.Ctx) error {
 
 	for _, id := range req.ItemIDs {
 		if err := c.putawayPerItem(ctx, id); err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{
 				"error": fmt.Sprintf("Failed putaway ID %s: %v", id, err),
 			})
 		}
@@ -944,7 +1094,13 @@ func (r *InboundController) HandleChecking(ctx *fiber.Ctx) error {
 		log.Println("Gagal insert history:", errHistory)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Change status inbound " + payload.InboundNo + " to checking successfully"})
+	// tawarkan cross-dock kalau barang yang diterima sudah ditunggu outbound open
+	crossDock, err := repositories.NewCrossDockRepository(r.DB).FindMatches(int(InboundHeader.ID))
+	if err != nil {
+		log.Println("Gagal cek cross-dock:", err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Change status inbound " + payload.InboundNo + " to checking successfully", "cross_dock": crossDock})
 }
 
 func (r *InboundController) HandleChecked(ctx *fiber.Ctx) error {
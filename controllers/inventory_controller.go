@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
 	"fiber-app/repositories"
 	"fmt"
@@ -32,6 +33,15 @@ func (c *InventoryController) GetInventory(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": fiber.Map{"inventories": inventories}})
 }
 
+func (c *InventoryController) GetInvalidLocationStock(ctx *fiber.Ctx) error {
+	inventories, err := repositories.NewInventoryRepository(c.DB).GetInventoryInvalidLocation()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": inventories})
+}
+
 type ReqPallet struct {
 	Pallet   string `json:"pallet"`
 	Location string `json:"location"`
@@ -76,27 +86,31 @@ type MovePayload struct {
 }
 
 // 🔹 Helper untuk update inventory existing
-func (c *InventoryController) updateInventoryQuantity(ctx *fiber.Ctx, inv *models.Inventory, qty int) error {
+func (c *InventoryController) updateInventoryQuantity(ctx *fiber.Ctx, tx *gorm.DB, inv *models.Inventory, qty int, refNo string) error {
+	before := *inv
 	inv.QtyAvailable -= qty
 	inv.QtyOnhand -= qty
 	// inv.QtyOrigin -= qty
 	inv.UpdatedBy = int(ctx.Locals("userID").(float64))
 	inv.UpdatedAt = time.Now()
 
-	if err := c.DB.Model(inv).
+	if err := tx.Model(inv).
 		Select("qty_available", "qty_onhand", "qty_origin", "updated_by", "updated_at").
 		Where("id = ?", inv.ID).
 		Updates(inv).Error; err != nil {
 		return err
 	}
-	return nil
+	return helpers.InsertInventoryMovement(tx, before, helpers.MovementMoveOut, refNo, inv.UpdatedBy)
 }
 
 // 🔹 Helper untuk create inventory baru (target pallet)
-func (c *InventoryController) createNewInventory(ctx *fiber.Ctx, oldInv *models.Inventory, targetPallet, targetLocation string, itemID, qty int) error {
+func (c *InventoryController) createNewInventory(ctx *fiber.Ctx, tx *gorm.DB, oldInv *models.Inventory, targetPallet, targetLocation string, itemID, qty int, refNo string) (models.Inventory, error) {
 	newInventory := models.Inventory{
 		InboundDetailId: oldInv.InboundDetailId,
 		RecDate:         oldInv.RecDate,
+		LotNo:           oldInv.LotNo,
+		MfgDate:         oldInv.MfgDate,
+		ExpDate:         oldInv.ExpDate,
 		ItemId:          itemID,
 		ItemCode:        oldInv.ItemCode,
 		WhsCode:         oldInv.WhsCode,
@@ -114,10 +128,10 @@ func (c *InventoryController) createNewInventory(ctx *fiber.Ctx, oldInv *models.
 		CreatedBy:    int(ctx.Locals("userID").(float64)),
 	}
 
-	if err := c.DB.Create(&newInventory).Error; err != nil {
-		return err
+	if err := tx.Create(&newInventory).Error; err != nil {
+		return newInventory, err
 	}
-	return nil
+	return newInventory, helpers.InsertInventoryMovement(tx, models.Inventory{ID: newInventory.ID}, helpers.MovementMoveIn, refNo, newInventory.CreatedBy)
 }
 
 // 🔹 Function utama untuk move item
@@ -139,20 +153,42 @@ func (c *InventoryController) MoveItem(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Source and target locations cannot be the same"})
 	}
 
+	refNo := movePayload.SourceLocation + " > " + movePayload.TargetLocation
+
+	tx := c.DB.Begin()
+	if tx.Error != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to start transaction"})
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
 	for _, item := range movePayload.Items {
 		// cari inventory lama
 		var oldInventory models.Inventory
-		if err := c.DB.Where("id = ?", item.InventoryID).First(&oldInventory).Error; err != nil {
+		if err := tx.Where("id = ?", item.InventoryID).First(&oldInventory).Error; err != nil {
+			tx.Rollback()
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to find source inventory: " + err.Error()})
 		}
 
 		// validasi stock cukup
 		if oldInventory.QtyAvailable < item.Quantity {
+			tx.Rollback()
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "message": "Insufficient quantity in source pallet"})
 		}
 
+		if _, err := repositories.NewLocationRepository(tx).ValidateLocation(movePayload.TargetLocation, oldInventory.WhsCode, oldInventory.OwnerCode); err != nil {
+			tx.Rollback()
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"success": false, "message": err.Error()})
+		}
+
 		// update inventory lama
-		if err := c.updateInventoryQuantity(ctx, &oldInventory, item.Quantity); err != nil {
+		before := oldInventory
+		if err := c.updateInventoryQuantity(ctx, tx, &oldInventory, item.Quantity, refNo); err != nil {
+			tx.Rollback()
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
 				"message": "Failed to update source inventory: " + err.Error(),
@@ -160,12 +196,28 @@ func (c *InventoryController) MoveItem(ctx *fiber.Ctx) error {
 		}
 
 		// create inventory baru di target
-		if err := c.createNewInventory(ctx, &oldInventory, movePayload.TargetPallet, movePayload.TargetLocation, item.ItemID, item.Quantity); err != nil {
+		newInventory, err := c.createNewInventory(ctx, tx, &oldInventory, movePayload.TargetPallet, movePayload.TargetLocation, item.ItemID, item.Quantity, refNo)
+		if err != nil {
+			tx.Rollback()
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
 				"message": "Failed to create target inventory: " + err.Error(),
 			})
 		}
+
+		if err := repositories.NewSerialRepository(tx).Move(before, newInventory, item.Quantity, refNo, newInventory.CreatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": err.Error()})
+		}
+
+		if err := repositories.NewHoldRepository(tx).Apply(newInventory, newInventory.CreatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": err.Error()})
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Item moved successfully"})
@@ -191,6 +243,9 @@ func (c *InventoryController) ExportExcel(ctx *fiber.Ctx) error {
 	f.SetCellValue(sheet, "D1", "Location")
 	f.SetCellValue(sheet, "E1", "Qa Status")
 	f.SetCellValue(sheet, "F1", "Qty Onhand")
+	f.SetCellValue(sheet, "G1", "Lot No")
+	f.SetCellValue(sheet, "H1", "Mfg Date")
+	f.SetCellValue(sheet, "I1", "Exp Date")
 
 	// Isi data ke dalam sheet
 	for i, item := range inventories {
@@ -200,6 +255,9 @@ func (c *InventoryController) ExportExcel(ctx *fiber.Ctx) error {
 		f.SetCellValue(sheet, fmt.Sprintf("D%d", i+2), item.Location)
 		f.SetCellValue(sheet, fmt.Sprintf("E%d", i+2), item.QaStatus)
 		f.SetCellValue(sheet, fmt.Sprintf("F%d", i+2), item.QtyOnhand)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", i+2), item.LotNo)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", i+2), item.MfgDate)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", i+2), item.ExpDate)
 	}
 
 	// Simpan file ke dalam response
@@ -301,6 +359,9 @@ func (c *InventoryController) ChangeStatusInventory(ctx *fiber.Ctx) error {
 		newInventory.InboundID = inv.InboundID
 		newInventory.InboundDetailId = inv.InboundDetailId
 		newInventory.RecDate = inv.RecDate
+		newInventory.LotNo = inv.LotNo
+		newInventory.MfgDate = inv.MfgDate
+		newInventory.ExpDate = inv.ExpDate
 		newInventory.ItemId = inv.ItemId
 		newInventory.ItemCode = inv.ItemCode
 		newInventory.Barcode = inv.Barcode
@@ -311,6 +372,7 @@ func (c *InventoryController) ChangeStatusInventory(ctx *fiber.Ctx) error {
 		newInventory.QtyOrigin = inv.QtyAvailable
 		newInventory.QtyOnhand = inv.QtyAvailable
 		newInventory.QtyAvailable = inv.QtyAvailable
+		refNo := fmt.Sprintf("%s-%s > %s-%s", inv.WhsCode, inv.QaStatus, req.NewWhsCode, req.NewQaStatus)
 		newInventory.Trans = fmt.Sprintf("change from inventory_id : %d", inv.ID)
 		newInventory.IsTransfer = true
 		newInventory.TransferFrom = inv.ID
@@ -322,6 +384,11 @@ func (c *InventoryController) ChangeStatusInventory(ctx *fiber.Ctx) error {
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
 
+		if err := helpers.InsertInventoryMovement(tx, models.Inventory{ID: newInventory.ID}, helpers.MovementStatusIn, refNo, newInventory.CreatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		var oldInventory models.Inventory
 		if err := tx.Where("id = ?", inv.ID).First(&oldInventory).Error; err != nil {
 			tx.Rollback()
@@ -339,6 +406,21 @@ func (c *InventoryController) ChangeStatusInventory(ctx *fiber.Ctx) error {
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
 
+		if err := helpers.InsertInventoryMovement(tx, inv, helpers.MovementStatusOut, refNo, oldInventory.UpdatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := repositories.NewSerialRepository(tx).Move(inv, newInventory, inv.QtyAvailable, refNo, oldInventory.UpdatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := repositories.NewHoldRepository(tx).Apply(newInventory, oldInventory.UpdatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		updatedCount++
 	}
 
@@ -351,3 +433,127 @@ func (c *InventoryController) ChangeStatusInventory(ctx *fiber.Ctx) error {
 		"updated_count":  updatedCount,
 	})
 }
+
+func (c *InventoryController) GetStockCard(ctx *fiber.Ctx) error {
+	itemCode := ctx.Query("item_code")
+	if itemCode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "item_code is required"})
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", ctx.Query("from"), time.Local)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid from date, expected format YYYY-MM-DD"})
+	}
+
+	to, err := time.ParseInLocation("2006-01-02", ctx.Query("to"), time.Local)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid to date, expected format YYYY-MM-DD"})
+	}
+
+	if to.Before(from) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to date cannot be before from date"})
+	}
+
+	inventoryRepo := repositories.NewInventoryRepository(c.DB)
+	card, err := inventoryRepo.GetStockCard(repositories.StockCardFilter{
+		ItemCode:  itemCode,
+		Location:  ctx.Query("location"),
+		WhsCode:   ctx.Query("whs_code"),
+		OwnerCode: ctx.Query("owner_code"),
+		From:      from,
+		To:        to,
+	})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": card})
+}
+
+func (c *InventoryController) TakeStockSnapshot(ctx *fiber.Ctx) error {
+	snapshotDate := time.Now().Format("2006-01-02")
+
+	snapshotRepo := repositories.NewStockSnapshotRepository(c.DB)
+	count, err := snapshotRepo.TakeSnapshot(snapshotDate, int(ctx.Locals("userID").(float64)))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Stock snapshot " + snapshotDate + " created successfully",
+		"data":    fiber.Map{"snapshot_date": snapshotDate, "rows": count},
+	})
+}
+
+func (c *InventoryController) getStockAsOf(ctx *fiber.Ctx) (repositories.StockAsOfResult, error) {
+	asOf, err := time.ParseInLocation("2006-01-02", ctx.Query("date"), time.Local)
+	if err != nil {
+		return repositories.StockAsOfResult{}, fiber.NewError(fiber.StatusBadRequest, "Invalid date, expected format YYYY-MM-DD")
+	}
+
+	snapshotRepo := repositories.NewStockSnapshotRepository(c.DB)
+	return snapshotRepo.GetStockAsOf(asOf, repositories.StockAsOfFilter{
+		OwnerCode: ctx.Query("owner_code"),
+		WhsCode:   ctx.Query("whs_code"),
+		ItemCode:  ctx.Query("item_code"),
+	})
+}
+
+func (c *InventoryController) GetStockAsOf(ctx *fiber.Ctx) error {
+	result, err := c.getStockAsOf(ctx)
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return ctx.Status(fiberErr.Code).JSON(fiber.Map{"error": fiberErr.Message})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": result})
+}
+
+func (c *InventoryController) ExportStockAsOf(ctx *fiber.Ctx) error {
+	result, err := c.getStockAsOf(ctx)
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			return ctx.Status(fiberErr.Code).JSON(fiber.Map{"error": fiberErr.Message})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+
+	f.SetCellValue(sheet, "A1", "Stock As Of")
+	f.SetCellValue(sheet, "B1", result.AsOfDate)
+	f.SetCellValue(sheet, "C1", "Snapshot")
+	f.SetCellValue(sheet, "D1", result.SnapshotDate)
+
+	headers := []string{"Owner", "Whs Code", "Item Code", "Item Name", "Location", "Qa Status", "Qty Onhand", "Qty Available", "Qty Allocated"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 3)
+		f.SetCellValue(sheet, cell, h)
+	}
+
+	for i, item := range result.Items {
+		row := i + 4
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), item.OwnerCode)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), item.WhsCode)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), item.ItemCode)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), item.ItemName)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), item.Location)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), item.QaStatus)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), item.QtyOnhand)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), item.QtyAvailable)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), item.QtyAllocated)
+	}
+
+	ctx.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Set("Content-Disposition", `attachment; filename="stock_as_of_`+result.AsOfDate+`.xlsx"`)
+
+	if err := f.Write(ctx.Response().BodyWriter()); err != nil {
+		return ctx.Status(http.StatusInternalServerError).SendString("Gagal generate Excel")
+	}
+
+	return nil
+}
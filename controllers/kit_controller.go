@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type KitController struct {
+	DB *gorm.DB
+}
+
+func (c *KitController) GetBoms(ctx *fiber.Ctx) error {
+	var boms []models.BomHeader
+	if err := c.DB.Preload("Components").Order("kit_item_code").Find(&boms).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": boms})
+}
+
+func (c *KitController) GetBomByItem(ctx *fiber.Ctx) error {
+	var bom models.BomHeader
+	if err := c.DB.Preload("Components").Where("kit_item_code = ?", ctx.Params("kit_item_code")).First(&bom).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "BOM not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": bom})
+}
+
+// SaveBom insert atau update bom berdasarkan kit item, komponen lama diganti seluruhnya
+func (c *KitController) SaveBom(ctx *fiber.Ctx) error {
+	var input struct {
+		KitItemCode      string `json:"kit_item_code"`
+		Description      string `json:"description"`
+		AssemblyVasID    int    `json:"assembly_vas_id"`
+		DisassemblyVasID int    `json:"disassembly_vas_id"`
+		IsActive         *bool  `json:"is_active"`
+		Components       []struct {
+			ItemCode string `json:"item_code"`
+			Quantity int    `json:"quantity"`
+		} `json:"components"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if input.KitItemCode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Kit item code is required"})
+	}
+	if len(input.Components) == 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "BOM needs at least one component"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var kitItem models.Product
+	if err := c.DB.Where("item_code = ?", input.KitItemCode).First(&kitItem).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Kit item " + input.KitItemCode + " not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	for _, vasID := range []int{input.AssemblyVasID, input.DisassemblyVasID} {
+		if vasID == 0 {
+			continue
+		}
+		var count int64
+		if err := c.DB.Model(&models.MainVas{}).Where("id = ?", vasID).Count(&count).Error; err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		if count == 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "VAS not found"})
+		}
+	}
+
+	var components []models.BomComponent
+	seen := map[string]bool{}
+	for _, item := range input.Components {
+		if item.Quantity <= 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Quantity for " + item.ItemCode + " must be greater than 0"})
+		}
+		if item.ItemCode == input.KitItemCode {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Kit item cannot be its own component"})
+		}
+		if seen[item.ItemCode] {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Duplicate component " + item.ItemCode})
+		}
+		seen[item.ItemCode] = true
+
+		var product models.Product
+		if err := c.DB.Where("item_code = ?", item.ItemCode).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Component " + item.ItemCode + " not found"})
+			}
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		components = append(components, models.BomComponent{
+			ItemID:    int(product.ID),
+			ItemCode:  product.ItemCode,
+			Quantity:  item.Quantity,
+			CreatedBy: userID,
+		})
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var bom models.BomHeader
+		err := tx.Where("kit_item_code = ?", input.KitItemCode).First(&bom).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		bom.KitItemID = int(kitItem.ID)
+		bom.KitItemCode = kitItem.ItemCode
+		bom.OwnerCode = kitItem.OwnerCode
+		bom.Description = input.Description
+		bom.AssemblyVasID = input.AssemblyVasID
+		bom.DisassemblyVasID = input.DisassemblyVasID
+		bom.IsActive = input.IsActive == nil || *input.IsActive
+		bom.UpdatedBy = userID
+
+		if bom.ID == 0 {
+			bom.CreatedBy = userID
+			err = tx.Omit("Components").Create(&bom).Error
+		} else {
+			err = tx.Omit("Components").Save(&bom).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("bom_id = ?", bom.ID).Delete(&models.BomComponent{}).Error; err != nil {
+			return err
+		}
+		for i := range components {
+			components[i].BomID = bom.ID
+		}
+		return tx.Create(&components).Error
+	})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "BOM " + input.KitItemCode + " saved successfully"})
+}
+
+func (c *KitController) DeleteBom(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var bom models.BomHeader
+	if err := c.DB.Where("id = ?", ctx.Params("id")).First(&bom).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "BOM not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var open int64
+	if err := c.DB.Model(&models.KitOrder{}).Where("bom_id = ? AND status = ?", bom.ID, repositories.KitOrderOpen).Count(&open).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if open > 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "BOM still used by open kit orders"})
+	}
+
+	bom.DeletedBy = userID
+	if err := c.DB.Omit("Components").Save(&bom).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := c.DB.Delete(&bom).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "BOM deleted successfully"})
+}
+
+func (c *KitController) GetKitOrders(ctx *fiber.Ctx) error {
+	list, err := repositories.NewKitRepository(c.DB).GetKitOrderList(ctx.Query("status"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": list})
+}
+
+func (c *KitController) GetKitOrderByNo(ctx *fiber.Ctx) error {
+	var order models.KitOrder
+	if err := c.DB.Preload("Details").Preload("Vas").Where("kit_order_no = ?", ctx.Params("kit_order_no")).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Kit order not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": order})
+}
+
+// CreateKitOrder membuat work order, stock baru berubah saat order di-complete
+func (c *KitController) CreateKitOrder(ctx *fiber.Ctx) error {
+	var input struct {
+		OrderType      string `json:"order_type"`
+		KitItemCode    string `json:"kit_item_code"`
+		WhsCode        string `json:"whs_code"`
+		Quantity       int    `json:"quantity"`
+		Location       string `json:"location"`
+		SourceLocation string `json:"source_location"`
+		Remarks        string `json:"remarks"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if input.OrderType == "" {
+		input.OrderType = repositories.KitAssembly
+	}
+	if input.OrderType != repositories.KitAssembly && input.OrderType != repositories.KitDisassembly {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Order type must be assembly or disassembly"})
+	}
+	if input.Quantity <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Quantity must be greater than 0"})
+	}
+	if input.WhsCode == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Warehouse code is required"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	kitRepo := repositories.NewKitRepository(tx)
+	bom, err := kitRepo.GetBom(input.KitItemCode)
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	if _, err := repositories.NewLocationRepository(tx).ValidateLocation(input.Location, input.WhsCode, bom.OwnerCode); err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	order := models.KitOrder{
+		OrderType:      input.OrderType,
+		BomID:          bom.ID,
+		KitItemID:      bom.KitItemID,
+		KitItemCode:    bom.KitItemCode,
+		OwnerCode:      bom.OwnerCode,
+		WhsCode:        input.WhsCode,
+		Quantity:       input.Quantity,
+		Location:       input.Location,
+		SourceLocation: input.SourceLocation,
+		Status:         repositories.KitOrderOpen,
+		Remarks:        input.Remarks,
+		CreatedBy:      userID,
+		UpdatedBy:      userID,
+	}
+
+	if order.KitOrderNo, err = kitRepo.GenerateKitOrderNo(order.OwnerCode, order.WhsCode); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Kit order " + order.KitOrderNo + " created", "data": order})
+}
+
+// CompleteKitOrder memposting perubahan stock kit order dan membuat billing VAS-nya
+func (c *KitController) CompleteKitOrder(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var order models.KitOrder
+	if err := tx.Where("kit_order_no = ?", ctx.Params("kit_order_no")).First(&order).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Kit order not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := repositories.NewKitRepository(tx).CompleteOrder(order, userID); err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Kit order " + order.KitOrderNo + " completed successfully"})
+}
+
+func (c *KitController) CancelKitOrder(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var order models.KitOrder
+	if err := c.DB.Where("kit_order_no = ?", ctx.Params("kit_order_no")).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Kit order not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if order.Status != repositories.KitOrderOpen {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Only open kit order can be cancelled"})
+	}
+
+	// syarat masih open supaya tidak membatalkan order yang sedang di-complete
+	result := c.DB.Model(&models.KitOrder{}).Where("id = ? AND status = ?", order.ID, repositories.KitOrderOpen).Updates(map[string]interface{}{
+		"status":     repositories.KitOrderCancel,
+		"cancel_at":  time.Now(),
+		"cancel_by":  userID,
+		"updated_by": userID,
+	})
+	if result.Error != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected != 1 {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Kit order " + order.KitOrderNo + " is no longer open"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Kit order " + order.KitOrderNo + " cancelled"})
+}
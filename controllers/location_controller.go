@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"fiber-app/models"
+	"fiber-app/repositories"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,6 +17,15 @@ func NewLocationController(DB *gorm.DB) *LocationController {
 	return &LocationController{DB: DB}
 }
 
+func validLocationType(locationType string) bool {
+	for _, t := range repositories.LocationTypes {
+		if t == locationType {
+			return true
+		}
+	}
+	return false
+}
+
 // CREATE
 func (lc *LocationController) CreateLocation(ctx *fiber.Ctx) error {
 	userID := int(ctx.Locals("userID").(float64))
@@ -26,6 +36,12 @@ func (lc *LocationController) CreateLocation(ctx *fiber.Ctx) error {
 	}
 
 	location.LocationCode = location.Row + location.Bay + location.Level + location.Bin
+	if location.LocationType == "" {
+		location.LocationType = repositories.LocationTypeRack
+	}
+	if !validLocationType(location.LocationType) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid location type"})
+	}
 	// if location.LocationCode == "" {
 	// 	return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Location code is required"})
 	// }
@@ -102,6 +118,12 @@ func (lc *LocationController) UpdateLocation(ctx *fiber.Ctx) error {
 	if err := ctx.BodyParser(&input); err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
 	}
+	if input.LocationType == "" {
+		input.LocationType = location.LocationType
+	}
+	if !validLocationType(input.LocationType) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid location type"})
+	}
 
 	bayInt, err := strconv.Atoi(location.Bay)
 	if err != nil {
@@ -122,6 +144,15 @@ func (lc *LocationController) UpdateLocation(ctx *fiber.Ctx) error {
 	location.Bin = input.Bin
 	// location.Area = input.Area
 	location.IsActive = input.IsActive
+	location.PickSequence = input.PickSequence
+	location.LocationType = input.LocationType
+	location.WhsCode = input.WhsCode
+	location.OwnerCode = input.OwnerCode
+	location.MaxPallet = input.MaxPallet
+	location.MaxCbm = input.MaxCbm
+	location.MaxWeight = input.MaxWeight
+	location.SingleItem = input.SingleItem
+	location.SingleLot = input.SingleLot
 	location.UpdatedBy = userID
 
 	if err := lc.DB.Save(&location).Error; err != nil {
@@ -159,3 +190,66 @@ func (lc *LocationController) DeleteLocation(ctx *fiber.Ctx) error {
 		"message": "Location deleted successfully",
 	})
 }
+
+// SetPickSequence mengatur urutan jalur picking: serpentine otomatis per row, atau custom per lokasi
+func (lc *LocationController) SetPickSequence(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var input struct {
+		Mode      string                          `json:"mode"`
+		Area      string                          `json:"area"`
+		Start     int                             `json:"start"`
+		Step      int                             `json:"step"`
+		Sequences []repositories.LocationSequence `json:"sequences"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if input.Start <= 0 {
+		input.Start = 10
+	}
+	if input.Step <= 0 {
+		input.Step = 10
+	}
+
+	tx := lc.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	locationRepo := repositories.NewLocationRepository(tx)
+	sequences := input.Sequences
+
+	switch input.Mode {
+	case repositories.PickPathSerpentine:
+		var err error
+		if sequences, err = locationRepo.SequenceSerpentine(input.Area, input.Start, input.Step, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	case repositories.PickPathCustom:
+		if len(sequences) == 0 {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Sequences are required"})
+		}
+		if err := locationRepo.UpdateSequences(sequences, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	default:
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Mode must be serpentine or custom"})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Pick sequence updated successfully",
+		"data":    sequences,
+	})
+}
@@ -2,6 +2,7 @@ package mobiles
 
 import (
 	"errors"
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
 	"fiber-app/repositories"
 	"fmt"
@@ -85,7 +86,17 @@ func (c *MobileInboundController) CheckItem(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found", "message": "Product not found"})
 	}
 
-	if product.HasSerial == "Y" {
+	var inboundHeader models.InboundHeader
+	if err := c.DB.Select("id, owner_code").Where("inbound_no = ?", scanInbound.InboundNo).First(&inboundHeader).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ownerSetting, err := helpers.GetOwnerSetting(c.DB, inboundHeader.OwnerCode)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if helpers.ResolveSerial(ownerSetting, product.HasSerial) == "Y" {
 		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Item checked successfully", "data": product, "is_serial": true})
 	}
 
@@ -104,6 +115,9 @@ func (c *MobileInboundController) ScanInbound(ctx *fiber.Ctx) error {
 		QaStatus  string `json:"qaStatus"`
 		Serial    string `json:"serial"`
 		QtyScan   int    `json:"qtyScan"`
+		LotNo     string `json:"lotNo"`
+		MfgDate   string `json:"mfgDate"`
+		ExpDate   string `json:"expDate"`
 		Uploaded  bool   `json:"uploaded"`
 	}
 
@@ -163,6 +177,27 @@ func (c *MobileInboundController) ScanInbound(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Quantity exceeds planned receipt", "message": "Quantity exceeds planned receipt"})
 	}
 
+	// lot kosong → ikut lot di inbound detail
+	if scanInbound.LotNo == "" {
+		scanInbound.LotNo = inboundDetail.LotNo
+	}
+	if scanInbound.MfgDate == "" {
+		scanInbound.MfgDate = inboundDetail.MfgDate
+	}
+	if scanInbound.ExpDate == "" {
+		scanInbound.ExpDate = inboundDetail.ExpDate
+	}
+
+	if err := helpers.ValidateLotDates(scanInbound.MfgDate, scanInbound.ExpDate); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error(), "message": err.Error()})
+	}
+
+	if _, err := repositories.NewLocationRepository(tx).ValidateLocation(scanInbound.Location, inboundDetail.WhsCode, inboundDetail.OwnerCode); err != nil {
+		tx.Rollback()
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error(), "message": err.Error()})
+	}
+
 	inboundDetail.UpdatedBy = int(ctx.Locals("userID").(float64))
 	inboundDetail.UpdatedAt = time.Now()
 
@@ -176,7 +211,8 @@ func (c *MobileInboundController) ScanInbound(ctx *fiber.Ctx) error {
 
 	var scanType = "SERIAL"
 
-	if product.HasSerial == "N" {
+	// detail owner dengan serial wajib tetap discan per serial
+	if product.HasSerial == "N" && inboundDetail.IsSerial != "Y" {
 		scanType = "BARCODE"
 		scanInbound.Serial = scanInbound.Barcode
 	}
@@ -202,6 +238,9 @@ func (c *MobileInboundController) ScanInbound(ctx *fiber.Ctx) error {
 		ScanData:        scanInbound.Serial,
 		SerialNumber:    scanInbound.Serial,
 		Quantity:        scanInbound.QtyScan,
+		LotNo:           scanInbound.LotNo,
+		MfgDate:         scanInbound.MfgDate,
+		ExpDate:         scanInbound.ExpDate,
 		Status:          "pending",
 		CreatedBy:       int(ctx.Locals("userID").(float64)),
 	}
@@ -211,6 +250,26 @@ func (c *MobileInboundController) ScanInbound(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if scanType == "SERIAL" {
+		if err := repositories.NewSerialRepository(tx).Receive(inboundBarcode, inboundHeader.InboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+			tx.Rollback()
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error(), "message": err.Error()})
+		}
+	}
+
+	// barang yang sudah discan menunggu putaway, masuk antrian task
+	if _, err := repositories.NewTaskRepository(tx).CreateTask(models.WarehouseTask{
+		TaskType:  repositories.TaskPutaway,
+		RefNo:     inboundHeader.InboundNo,
+		WhsCode:   inboundDetail.WhsCode,
+		OwnerCode: inboundDetail.OwnerCode,
+		Location:  scanInbound.Location,
+		CreatedBy: int(ctx.Locals("userID").(float64)),
+	}); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// fmt.Println("ID yang dihasilkan:", inboundBarcode.ID)
 
 	if err := tx.Commit().Error; err != nil {
@@ -340,6 +399,13 @@ func (c *MobileInboundController) DeleteScannedInbound(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if inboundBarcode.ScanType == "SERIAL" {
+		if err := repositories.NewSerialRepository(tx).CancelReceive(inboundBarcode, inboundDetail.InboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -405,7 +471,7 @@ func (c *MobileInboundController) ConfirmPutaway(ctx *fiber.Ctx) error {
 		_, err := inboundRepo.PutawayItem(ctx, int(inboundBarcode.ID), scanInbound.Location)
 		if err != nil {
 			tx.Rollback()
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 		}
 
 		// if inboundBarcode.Status != "pending" {
@@ -543,7 +609,7 @@ func (c *MobileInboundController) ConfirmPutawayByLocation(ctx *fiber.Ctx) error
 		_, err := inboundRepo.PutawayItem(ctx, scanned.ID, input.ToLocation)
 		if err != nil {
 			tx.Rollback()
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 		}
 
 		// var inboundBarcode models.InboundBarcode
@@ -711,3 +777,25 @@ func (c *MobileInboundController) GetSequenceLocation(ctx *fiber.Ctx) error {
 		"data":    sequenceLocation,
 	})
 }
+
+func (c *MobileInboundController) SuggestPutaway(ctx *fiber.Ctx) error {
+	inbound_no := ctx.Params("inbound_no")
+
+	var inboundHeader models.InboundHeader
+	if err := c.DB.Where("inbound_no = ?", inbound_no).First(&inboundHeader).Error; err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Inbound not found"})
+	}
+
+	suggestions, err := repositories.NewInboundRepository(c.DB).SuggestPutaway(int(inboundHeader.ID))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// barang yang ditunggu outbound open sebaiknya ke staging, bukan ke rak
+	crossDock, err := repositories.NewCrossDockRepository(c.DB).FindMatches(int(inboundHeader.ID))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": suggestions, "cross_dock": crossDock})
+}
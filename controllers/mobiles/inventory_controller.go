@@ -2,7 +2,9 @@ package mobiles
 
 import (
 	"errors"
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
+	"fiber-app/repositories"
 	"fmt"
 	"strconv"
 	"time"
@@ -72,8 +74,19 @@ func (c *MobileInventoryController) CreateDummyInventory(ctx *fiber.Ctx) error {
 		inventories = append(inventories, inventory)
 	}
 
-	// Batch Insert
-	if err := c.DB.Create(&inventories).Error; err != nil {
+	// Batch Insert, tiap inventory dummy tetap dicatat di ledger
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&inventories).Error; err != nil {
+			return err
+		}
+		for _, inventory := range inventories {
+			if err := helpers.InsertInventoryMovement(tx, models.Inventory{ID: inventory.ID}, helpers.MovementDummy, inventory.Trans, inventory.CreatedBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to insert dummy data to database, error: " + err.Error(),
 		})
@@ -157,12 +170,6 @@ func (c *MobileInventoryController) ConfirmTransferByLocationAndBarcode(ctx *fib
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "List Inventory is required"})
 	}
 
-	// check ToLocation is registered
-	var location models.Location
-	if err := c.DB.Where("location_code = ?", input.ToLocation).First(&location).Error; err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "To Location is not registered"})
-	}
-
 	// start db transaction
 	tx := c.DB.Begin()
 	if tx.Error != nil {
@@ -193,6 +200,11 @@ func (c *MobileInventoryController) ConfirmTransferByLocationAndBarcode(ctx *fib
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Inventory not found or not available"})
 		}
 
+		if _, err := repositories.NewLocationRepository(tx).ValidateLocation(input.ToLocation, inventory.WhsCode, inventory.OwnerCode); err != nil {
+			tx.Rollback()
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		var newInventory models.Inventory
 		newInventory.OwnerCode = inventory.OwnerCode
 		newInventory.DivisionCode = inventory.DivisionCode
@@ -200,6 +212,9 @@ func (c *MobileInventoryController) ConfirmTransferByLocationAndBarcode(ctx *fib
 		newInventory.InboundID = inventory.InboundID
 		newInventory.InboundDetailId = inventory.InboundDetailId
 		newInventory.RecDate = inventory.RecDate
+		newInventory.LotNo = inventory.LotNo
+		newInventory.MfgDate = inventory.MfgDate
+		newInventory.ExpDate = inventory.ExpDate
 		newInventory.ItemId = inventory.ItemId
 		newInventory.ItemCode = inventory.ItemCode
 		newInventory.Barcode = inventory.Barcode
@@ -221,6 +236,11 @@ func (c *MobileInventoryController) ConfirmTransferByLocationAndBarcode(ctx *fib
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
 
+		if err := helpers.InsertInventoryMovement(tx, models.Inventory{ID: newInventory.ID}, helpers.MovementTransferIn, input.FromLocation+" > "+input.ToLocation, newInventory.CreatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		var oldInventory models.Inventory
 		if err := tx.Where("id = ?", inv.ID).First(&oldInventory).Error; err != nil {
 			tx.Rollback()
@@ -238,6 +258,21 @@ func (c *MobileInventoryController) ConfirmTransferByLocationAndBarcode(ctx *fib
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
 
+		if err := helpers.InsertInventoryMovement(tx, inventory, helpers.MovementTransferOut, input.FromLocation+" > "+input.ToLocation, oldInventory.UpdatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := repositories.NewSerialRepository(tx).Move(inventory, newInventory, inventory.QtyAvailable, input.FromLocation+" > "+input.ToLocation, oldInventory.UpdatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := repositories.NewHoldRepository(tx).Apply(newInventory, oldInventory.UpdatedBy); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -290,16 +325,6 @@ func (c *MobileInventoryController) ConfirmTransferByInventoryID(ctx *fiber.Ctx)
 		}
 	}()
 
-	// validate to location already exists on master locations
-	var toLocation models.Location
-	if err := tx.Where("location_code = ?", input.ToLocation).First(&toLocation).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "To Location is not registered"})
-		}
-		tx.Rollback()
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-
 	var inventory models.Inventory
 	if err := tx.Where("id = ? AND location = ? AND qty_available > 0", inventoryID, input.FromLocation).First(&inventory).Error; err != nil {
 		tx.Rollback()
@@ -320,47 +345,12 @@ func (c *MobileInventoryController) ConfirmTransferByInventoryID(ctx *fiber.Ctx)
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Qty Transfer is greater than available quantity"})
 	}
 
-	var newInventory models.Inventory
-	newInventory.OwnerCode = inventory.OwnerCode
-	newInventory.DivisionCode = inventory.DivisionCode
-	newInventory.Uom = inventory.Uom
-	newInventory.InboundID = inventory.InboundID
-	newInventory.InboundDetailId = inventory.InboundDetailId
-	newInventory.RecDate = inventory.RecDate
-	newInventory.ItemId = inventory.ItemId
-	newInventory.ItemCode = inventory.ItemCode
-	newInventory.Barcode = inventory.Barcode
-	newInventory.WhsCode = inventory.WhsCode
-	newInventory.Pallet = input.ToLocation
-	newInventory.Location = input.ToLocation
-	newInventory.QaStatus = inventory.QaStatus
-	newInventory.QtyOrigin = input.QtyTransfer
-	newInventory.QtyOnhand = input.QtyTransfer
-	newInventory.QtyAvailable = input.QtyTransfer
-	newInventory.Trans = fmt.Sprintf("transfer from inventory_id : %d", inventory.ID)
-	newInventory.IsTransfer = true
-	newInventory.TransferFrom = inventory.ID
-	newInventory.CreatedAt = time.Now()
-	newInventory.CreatedBy = int(ctx.Locals("userID").(float64))
-
-	if err := tx.Create(&newInventory).Error; err != nil {
+	if _, err := repositories.NewLocationRepository(tx).ValidateLocation(input.ToLocation, inventory.WhsCode, inventory.OwnerCode); err != nil {
 		tx.Rollback()
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	var oldInventory models.Inventory
-	if err := tx.Where("id = ?", inventoryID).First(&oldInventory).Error; err != nil {
-		tx.Rollback()
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	oldInventory.QtyOrigin = oldInventory.QtyOrigin - input.QtyTransfer
-	oldInventory.QtyOnhand = oldInventory.QtyOnhand - input.QtyTransfer
-	oldInventory.QtyAvailable = oldInventory.QtyAvailable - input.QtyTransfer
-	oldInventory.UpdatedAt = time.Now()
-	oldInventory.UpdatedBy = int(ctx.Locals("userID").(float64))
-
-	if err := tx.Select("qty_origin", "qty_onhand", "qty_available", "updated_at", "updated_by").Updates(&oldInventory).Error; err != nil {
+	if _, err := repositories.NewInventoryRepository(tx).TransferQty(inventory, input.ToLocation, input.QtyTransfer, input.FromLocation+" > "+input.ToLocation, int(ctx.Locals("userID").(float64))); err != nil {
 		tx.Rollback()
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -508,3 +498,122 @@ func (c *MobileInventoryController) GetItemsByBarcode(ctx *fiber.Ctx) error {
 		"data":    results,
 	})
 }
+
+func (c *MobileInventoryController) GetReplenishmentTasks(ctx *fiber.Ctx) error {
+	tasks, err := repositories.NewReplenishmentRepository(c.DB).GetTaskList("open")
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": tasks})
+}
+
+// ConfirmReplenishment eksekusi task replenishment dari RF: scan lokasi asal, lokasi tujuan dan qty.
+// Task bisa dikerjakan sebagian, selesai kalau qty yang dipindah sudah sama dengan qty task.
+func (c *MobileInventoryController) ConfirmReplenishment(ctx *fiber.Ctx) error {
+	taskNo := ctx.Params("task_no")
+
+	var input struct {
+		FromLocation string `json:"from_location"`
+		ToLocation   string `json:"to_location"`
+		Qty          int    `json:"qty"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	if tx.Error != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": tx.Error.Error()})
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var task models.ReplenishmentTask
+	if err := tx.Where("task_no = ?", taskNo).First(&task).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Replenishment task not found"})
+	}
+
+	if task.Status != "open" {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Replenishment task " + taskNo + " is " + task.Status})
+	}
+
+	taskRepo := repositories.NewTaskRepository(tx)
+	if err := taskRepo.EnsureClaim(repositories.TaskReplenish, task.TaskNo, userID); err != nil {
+		tx.Rollback()
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if input.FromLocation != task.FromLocation || input.ToLocation != task.ToLocation {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Scanned location does not match the task"})
+	}
+
+	if input.Qty <= 0 || task.QtyMoved+input.Qty > task.Quantity {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Qty exceeds remaining task quantity"})
+	}
+
+	var inventory models.Inventory
+	if err := tx.Where("id = ? AND location = ?", task.FromInventoryID, task.FromLocation).First(&inventory).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Inventory not found or not available"})
+	}
+
+	if inventory.QtyAvailable < input.Qty {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Qty is greater than available quantity"})
+	}
+
+	if _, err := repositories.NewLocationRepository(tx).ValidateLocation(task.ToLocation, inventory.WhsCode, inventory.OwnerCode); err != nil {
+		tx.Rollback()
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if _, err := repositories.NewInventoryRepository(tx).TransferQty(inventory, task.ToLocation, input.Qty, task.TaskNo, userID); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updates := map[string]interface{}{
+		"qty_moved":  task.QtyMoved + input.Qty,
+		"updated_by": userID,
+	}
+	if task.QtyMoved+input.Qty >= task.Quantity {
+		updates["status"] = "done"
+		updates["complete_at"] = time.Now()
+		updates["complete_by"] = userID
+	}
+
+	// syarat task masih open dan belum dikonfirmasi request lain, kalau sudah dicancel stock batal dipindah
+	result := tx.Model(&task).Where("status = ? AND qty_moved = ?", "open", task.QtyMoved).Updates(updates)
+	if result.Error != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Replenishment task " + taskNo + " was changed by another request"})
+	}
+
+	if updates["status"] == "done" {
+		if err := taskRepo.CloseTask(repositories.TaskReplenish, task.TaskNo, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Replenishment " + taskNo + " confirmed", "data": task})
+}
@@ -2,7 +2,9 @@ package mobiles
 
 import (
 	"errors"
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
+	"fiber-app/repositories"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -182,6 +184,7 @@ func (c *MobileOutboundController) ScanPicking(ctx *fiber.Ctx) error {
 		OutboundNo string `json:"outbound_no"`
 		Barcode    string `json:"barcode"`
 		SerialNo   string `json:"serial_no"`
+		Location   string `json:"location"`
 		Qty        int    `json:"qty"`
 	}
 
@@ -203,6 +206,10 @@ func (c *MobileOutboundController) ScanPicking(ctx *fiber.Ctx) error {
 	}
 
 	if product.HasSerial == "Y" {
+		if err := repositories.NewSerialRepository(c.DB).ValidatePick(product.ItemCode, scanOutbound.SerialNo); err != nil {
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error(), "message": err.Error(), "is_serial": true})
+		}
+
 		var outboundBarcodes []models.OutboundBarcode
 
 		if err := c.DB.Where("outbound_id = ? AND barcode = ? AND serial_number = ?", outboundHeader.ID, scanOutbound.Barcode, scanOutbound.SerialNo).Find(&outboundBarcodes).Error; err != nil {
@@ -262,6 +269,11 @@ func (c *MobileOutboundController) ScanPicking(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Quantity exceeds the limit"})
 	}
 
+	taskRepo := repositories.NewTaskRepository(c.DB)
+	if err := taskRepo.EnsureClaim(repositories.TaskPick, outboundHeader.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error(), "message": err.Error()})
+	}
+
 	outboundBarcode := models.OutboundBarcode{
 		OutboundId:       outboundHeader.ID,
 		OutboundNo:       outboundHeader.OutboundNo,
@@ -277,11 +289,32 @@ func (c *MobileOutboundController) ScanPicking(ctx *fiber.Ctx) error {
 		CreatedBy:        int(ctx.Locals("userID").(float64)),
 	}
 
-	if err := c.DB.Create(&outboundBarcode).Error; err != nil {
+	if err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&outboundBarcode).Error; err != nil {
+			return err
+		}
+		if product.HasSerial == "Y" {
+			return repositories.NewSerialRepository(tx).Pick(outboundHeader, product, serialNumber, int(ctx.Locals("userID").(float64)))
+		}
+		return nil
+	}); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// arahkan picker ke lokasi berikutnya di jalur
+	nextPick, err := repositories.NewOutboundRepository(c.DB).GetNextPick(int(outboundHeader.ID))
+	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Item scanned successfully"})
+	// semua lokasi sudah dipick, task picking operator selesai
+	if nextPick == nil {
+		if err := taskRepo.CloseTask(repositories.TaskPick, outboundHeader.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Item scanned successfully", "next_pick": nextPick})
 }
 
 func (c *MobileOutboundController) GetListOutboundBarcode(ctx *fiber.Ctx) error {
@@ -301,13 +334,20 @@ func (c *MobileOutboundController) GetPickingList(ctx *fiber.Ctx) error {
 
 	outbound_no := ctx.Params("outbound_no")
 
-	var pickingList []models.OutboundPicking
-
-	if err := c.DB.Where("outbound_no = ?", outbound_no).Find(&pickingList).Error; err != nil {
+	outboundRepo := repositories.NewOutboundRepository(c.DB)
+	pickingList, err := outboundRepo.GetPickingList(outbound_no)
+	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": pickingList})
+	var nextPick *repositories.NextPick
+	if len(pickingList) > 0 {
+		if nextPick, err = outboundRepo.GetNextPick(int(pickingList[0].OutboundId)); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": pickingList, "next_pick": nextPick})
 }
 func (c *MobileOutboundController) OverridePicking(ctx *fiber.Ctx) error {
 
@@ -338,6 +378,11 @@ func (c *MobileOutboundController) OverridePicking(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if _, err := repositories.NewLocationRepository(tx).ValidateLocation(newPicking.NewLocation, oldPickingList.WhsCode, oldPickingList.OwnerCode); err != nil {
+		tx.Rollback()
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error(), "message": err.Error()})
+	}
+
 	var findInventory models.Inventory
 
 	if err := tx.Where("location = ? AND barcode = ? AND whs_code = ? AND qa_status = ? AND qty_available > 0", newPicking.NewLocation, newPicking.NewBarcode, oldPickingList.WhsCode, oldPickingList.QaStatus).First(&findInventory).Error; err != nil {
@@ -372,6 +417,11 @@ func (c *MobileOutboundController) OverridePicking(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if err := helpers.InsertInventoryMovement(tx, oldInventory, helpers.MovementOverrideFrom, oldPickingList.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// ambil stok baru
 	if err := tx.Debug().
 		Model(&models.Inventory{}).
@@ -388,6 +438,11 @@ func (c *MobileOutboundController) OverridePicking(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if err := helpers.InsertInventoryMovement(tx, findInventory, helpers.MovementOverrideTo, oldPickingList.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// update old picking list
 	if err := tx.Debug().
 		Model(&models.OutboundPicking{}).
@@ -419,6 +474,9 @@ func (c *MobileOutboundController) OverridePicking(ctx *fiber.Ctx) error {
 		Quantity:         newPicking.NewQty,
 		WhsCode:          findInventory.WhsCode,
 		QaStatus:         findInventory.QaStatus,
+		LotNo:            findInventory.LotNo,
+		ExpDate:          findInventory.ExpDate,
+		AllocationRule:   oldPickingList.AllocationRule,
 		Reason:           newPicking.Reason + " [new]",
 		CreatedBy:        int(ctx.Locals("userID").(float64)),
 	}
@@ -453,8 +511,13 @@ func (c *MobileOutboundController) DeleteOutboundBarcode(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Item cannot be deleted"})
 	}
 
-	// Hard Delete
-	if err := c.DB.Where("id = ?", idBarcode).Unscoped().Delete(&models.OutboundBarcode{}).Error; err != nil {
+	// Hard Delete, serial yang sudah dipick kembali ke stock
+	if err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", idBarcode).Unscoped().Delete(&models.OutboundBarcode{}).Error; err != nil {
+			return err
+		}
+		return repositories.NewSerialRepository(tx).CancelPick(outboundBarcodes.ItemCode, outboundBarcodes.SerialNumber, outboundBarcodes.OutboundNo, int(ctx.Locals("userID").(float64)))
+	}); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
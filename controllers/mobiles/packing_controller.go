@@ -3,8 +3,8 @@ package mobiles
 import (
 	"errors"
 	"fiber-app/models"
+	"fiber-app/repositories"
 	"fmt"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -44,36 +44,14 @@ func (c *MobilePackingController) GenerateKoli(ctx *fiber.Ctx) error {
 		})
 	}
 
-	// Ambil max no_koli yang sudah ada
-	var maxKoliNo string
-	err := c.DB.Table("outbound_scans").
-		Select("COALESCE(MAX(no_koli), '') as max_koli_no").
-		Where("outbound_id = ?", outboundHeader.ID).
-		Scan(&maxKoliNo).Error
-
+	// nomor koli = nomor outbound + urutan, lewat numbering service
+	newKoliNo, err := repositories.NewNumberingRepository(c.DB).Next(repositories.DocKoli, outboundHeader.OwnerCode, outboundHeader.WhsCode, outboundHeader.OutboundNo)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	// Generate new koli number
-	var newKoliNo string
-	if maxKoliNo == "" {
-		// Belum ada koli
-		newKoliNo = fmt.Sprintf("%s%04d", outboundHeader.OutboundNo, 1)
-	} else {
-		// Ambil 4 digit terakhir dari maxKoliNo
-		lastFour := maxKoliNo[len(maxKoliNo)-4:]
-		lastNumber, err := strconv.Atoi(lastFour)
-		if err != nil {
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Invalid existing KoliNo format",
-			})
-		}
-		newKoliNo = fmt.Sprintf("%s%04d", outboundHeader.OutboundNo, lastNumber+1)
-	}
-
 	// Simpan ke database
 	koliHeader := models.OutboundScan{
 		NoKoli:     newKoliNo,
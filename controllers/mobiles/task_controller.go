@@ -0,0 +1,101 @@
+package mobiles
+
+import (
+	"fiber-app/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type MobileTaskController struct {
+	DB *gorm.DB
+}
+
+func NewMobileTaskController(DB *gorm.DB) *MobileTaskController {
+	return &MobileTaskController{DB: DB}
+}
+
+// GetNextTask mengembalikan task yang sedang dipegang operator, kalau tidak ada ambil task
+// dengan prioritas tertinggi yang di-assign ke user / role-nya atau ke siapa saja
+func (c *MobileTaskController) GetNextTask(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+	taskRepo := repositories.NewTaskRepository(c.DB)
+
+	roles, err := taskRepo.UserRoles(userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	task, err := taskRepo.NextTask(userID, roles)
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if task == nil {
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "No task available", "data": nil})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Next task", "data": task})
+}
+
+func (c *MobileTaskController) GetMyTasks(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	tasks, err := repositories.NewTaskRepository(c.DB).GetTaskList(repositories.TaskFilter{
+		Status:    repositories.TaskStatusClaimed,
+		ClaimedBy: userID,
+	})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": tasks})
+}
+
+func (c *MobileTaskController) ClaimTask(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+	taskRepo := repositories.NewTaskRepository(c.DB)
+
+	roles, err := taskRepo.UserRoles(userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	task, err := taskRepo.Claim(uint(id), userID, roles)
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Task claimed", "data": task})
+}
+
+func (c *MobileTaskController) ReleaseTask(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+	}
+
+	if err := repositories.NewTaskRepository(c.DB).Release(uint(id), int(ctx.Locals("userID").(float64))); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Task released"})
+}
+
+// CompleteTask untuk task yang tidak ditutup otomatis oleh flow scan (count, move manual)
+func (c *MobileTaskController) CompleteTask(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+	}
+
+	if err := repositories.NewTaskRepository(c.DB).Complete(uint(id), int(ctx.Locals("userID").(float64))); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Task completed"})
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type NumberingController struct {
+	DB *gorm.DB
+}
+
+func (c *NumberingController) GetFormats(ctx *fiber.Ctx) error {
+	query := c.DB.Order("doc_type, owner_code, whs_code")
+	if docType := ctx.Query("doc_type"); docType != "" {
+		query = query.Where("doc_type = ?", docType)
+	}
+
+	var formats []models.NumberingFormat
+	if err := query.Find(&formats).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": formats})
+}
+
+// SaveFormat insert atau update format berdasarkan doc type, owner dan warehouse
+func (c *NumberingController) SaveFormat(ctx *fiber.Ctx) error {
+	var input struct {
+		DocType     string `json:"doc_type"`
+		OwnerCode   string `json:"owner_code"`
+		WhsCode     string `json:"whs_code"`
+		Prefix      string `json:"prefix"`
+		DatePattern string `json:"date_pattern"`
+		Padding     int    `json:"padding"`
+		ResetPeriod string `json:"reset_period"`
+		IsActive    *bool  `json:"is_active"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if input.Padding == 0 {
+		input.Padding = 4
+	}
+	if input.ResetPeriod == "" {
+		input.ResetPeriod = repositories.ResetDaily
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var format models.NumberingFormat
+	err := c.DB.Where("doc_type = ? AND owner_code = ? AND whs_code = ?", input.DocType, input.OwnerCode, input.WhsCode).First(&format).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	format.DocType = input.DocType
+	format.OwnerCode = input.OwnerCode
+	format.WhsCode = input.WhsCode
+	format.Prefix = strings.ToUpper(strings.TrimSpace(input.Prefix))
+	format.DatePattern = strings.ToUpper(strings.TrimSpace(input.DatePattern))
+	format.Padding = input.Padding
+	format.ResetPeriod = input.ResetPeriod
+	format.IsActive = input.IsActive == nil || *input.IsActive
+	format.UpdatedBy = userID
+
+	if err := repositories.ValidateFormat(format); err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	if format.ID == 0 {
+		format.CreatedBy = userID
+		err = c.DB.Create(&format).Error
+	} else {
+		err = c.DB.Save(&format).Error
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Numbering format saved successfully", "data": format})
+}
+
+func (c *NumberingController) DeleteFormat(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var format models.NumberingFormat
+	if err := c.DB.Where("id = ?", ctx.Params("id")).First(&format).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Numbering format not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// dihapus permanen supaya kombinasi doc type / owner / warehouse bisa dibuat lagi
+	format.DeletedBy = userID
+	if err := c.DB.Save(&format).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := c.DB.Unscoped().Delete(&format).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Numbering format deleted successfully"})
+}
+
+// Preview nomor berikutnya dengan format yang berlaku, sequence tidak berubah
+func (c *NumberingController) Preview(ctx *fiber.Ctx) error {
+	number, err := repositories.NewNumberingRepository(c.DB).Preview(ctx.Query("doc_type"), ctx.Query("owner_code"), ctx.Query("whs_code"), ctx.Query("ref"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": fiber.Map{"next_no": number}})
+}
+
+func (c *NumberingController) GetSequences(ctx *fiber.Ctx) error {
+	query := c.DB.Order("doc_type, seq_key")
+	if docType := ctx.Query("doc_type"); docType != "" {
+		query = query.Where("doc_type = ?", docType)
+	}
+
+	var sequences []models.NumberingSequence
+	if err := query.Find(&sequences).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": sequences})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"errors"
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
 	"fiber-app/repositories"
 	"fiber-app/types"
@@ -63,6 +64,7 @@ type OutboundItem struct {
 	Remarks    string            `json:"remarks"`
 	Mode       string            `json:"mode"`
 	VasID      int               `json:"vas_id"`
+	LotNo      string            `json:"lot_no"`
 }
 
 func (c *OutboundController) CreateOutbound(ctx *fiber.Ctx) error {
@@ -93,8 +95,15 @@ func (c *OutboundController) CreateOutbound(ctx *fiber.Ctx) error {
 
 	repositories := repositories.NewOutboundRepository(tx)
 
-	outbound_no, err := repositories.GenerateOutboundNumber()
+	ownerSetting, err := helpers.GetOwnerSetting(tx, payload.OwnerCode)
 	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	outbound_no, err := repositories.GenerateOutboundNumber(payload.OwnerCode, payload.WhsCode)
+	if err != nil {
+		tx.Rollback()
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"message": "Failed to generate inbound no",
@@ -217,12 +226,13 @@ func (c *OutboundController) CreateOutbound(ctx *fiber.Ctx) error {
 		OutboundDetail.ItemID = int(product.ID)
 		OutboundDetail.Barcode = product.Barcode
 		OutboundDetail.CustomerCode = OutboundHeader.CustomerCode
-		OutboundDetail.Uom = item.UOM
+		OutboundDetail.Uom = helpers.ResolveUom(ownerSetting, item.UOM, product.Uom)
 		OutboundDetail.Quantity = item.Quantity
 		OutboundDetail.WhsCode = OutboundHeader.WhsCode
-		OutboundDetail.DivisionCode = "REGULAR"
+		OutboundDetail.DivisionCode = ownerSetting.DivisionCode
 		OutboundDetail.Location = item.Location
-		OutboundDetail.QaStatus = "A"
+		OutboundDetail.QaStatus = ownerSetting.QaStatus
+		OutboundDetail.LotNo = item.LotNo
 		OutboundDetail.SN = item.SN
 		OutboundDetail.SNCheck = "N"
 		OutboundDetail.OwnerCode = OutboundHeader.OwnerCode
@@ -428,6 +438,12 @@ func (c *OutboundController) UpdateOutboundByID(ctx *fiber.Ctx) error {
 		})
 	}
 
+	ownerSetting, err := helpers.GetOwnerSetting(tx, OutboundHeader.OwnerCode)
+	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// update outbound detail
 	for _, item := range payload.Items {
 		var outboundDetail models.OutboundDetail
@@ -471,9 +487,10 @@ func (c *OutboundController) UpdateOutboundByID(ctx *fiber.Ctx) error {
 					WhsCode:      OutboundHeader.WhsCode,
 					OwnerCode:    OutboundHeader.OwnerCode,
 					CustomerCode: customer.CustomerCode,
-					Uom:          item.UOM,
-					DivisionCode: "REGULAR",
-					QaStatus:     "A",
+					Uom:          helpers.ResolveUom(ownerSetting, item.UOM, product.Uom),
+					DivisionCode: ownerSetting.DivisionCode,
+					QaStatus:     ownerSetting.QaStatus,
+					LotNo:        item.LotNo,
 					Remarks:      item.Remarks,
 					SN:           item.SN,
 					SNCheck:      "N",
@@ -496,12 +513,13 @@ func (c *OutboundController) UpdateOutboundByID(ctx *fiber.Ctx) error {
 				outboundDetail.ItemID = int(product.ID)
 				outboundDetail.ItemCode = item.ItemCode
 				outboundDetail.Barcode = product.Barcode
-				outboundDetail.Uom = item.UOM
+				outboundDetail.Uom = helpers.ResolveUom(ownerSetting, item.UOM, product.Uom)
 				outboundDetail.WhsCode = OutboundHeader.WhsCode
 				outboundDetail.OwnerCode = OutboundHeader.OwnerCode
-				outboundDetail.DivisionCode = "REGULAR"
+				outboundDetail.DivisionCode = ownerSetting.DivisionCode
 				outboundDetail.CustomerCode = customer.CustomerCode
-				outboundDetail.QaStatus = "A"
+				outboundDetail.QaStatus = ownerSetting.QaStatus
+				outboundDetail.LotNo = item.LotNo
 				outboundDetail.Quantity = item.Quantity
 				outboundDetail.Location = item.Location
 				outboundDetail.Remarks = item.Remarks
@@ -603,6 +621,15 @@ func (c *OutboundController) PickingOutbound(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
 	}
 
+	// outbound yang sudah masuk wave dialokasikan lewat release wave
+	waveNo, err := repositories.NewWaveRepository(c.DB).GetActiveWaveNo(id)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if waveNo != "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Outbound is planned in wave " + waveNo})
+	}
+
 	tx := c.DB.Begin()
 
 	if tx.Error != nil {
@@ -614,101 +641,23 @@ func (c *OutboundController) PickingOutbound(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	var itemCodes []string
+	allocationRepo := repositories.NewAllocationRepository(tx)
 	for _, outboundDetail := range outboundDetails {
-
-		qtyReq := outboundDetail.Quantity
-
-		var inventories []models.Inventory
-
-		fmt.Println("Picking Query")
-		if err := tx.Debug().
-			Where("item_id = ? AND whs_code = ? AND qty_available > 0", outboundDetail.ItemID, outboundDetail.WhsCode).
-			Order("rec_date, qty_available, pallet, location ASC").
-			Find(&inventories).Error; err != nil {
+		if err := allocationRepo.AllocateDetail(outboundDetail, 0, int(ctx.Locals("userID").(float64))); err != nil {
 			tx.Rollback()
-			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-		}
-
-		if len(inventories) == 0 {
-			tx.Rollback()
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Item " + outboundDetail.ItemCode + " not found",
-			})
-		}
-
-		for _, inventory := range inventories {
-
-			if qtyReq < 1 {
-				break
-			}
-
-			qtyPick := 0
-
-			if inventory.QtyAvailable >= qtyReq {
-				qtyPick = qtyReq
-			} else {
-				qtyPick = inventory.QtyAvailable
-			}
-
-			var product models.Product
-			if err := tx.Debug().Where("id = ?", outboundDetail.ItemID).First(&product).Error; err != nil {
-				tx.Rollback()
-				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Product not found",
-				})
-			}
-
-			// Insert picking sheet
-			pickingSheet := models.OutboundPicking{
-				InventoryID:      int(inventory.ID),
-				OutboundId:       outboundDetail.OutboundID,
-				OutboundNo:       outboundDetail.OutboundNo,
-				OutboundDetailId: int(outboundDetail.ID),
-				OwnerCode:        outboundDetail.OwnerCode,
-				ItemID:           outboundDetail.ItemID,
-				Barcode:          product.Barcode,
-				ItemCode:         product.ItemCode,
-				Pallet:           inventory.Pallet,
-				Location:         inventory.Location,
-				Quantity:         qtyPick,
-				WhsCode:          inventory.WhsCode,
-				QaStatus:         inventory.QaStatus,
-				CreatedBy:        int(ctx.Locals("userID").(float64)),
-			}
-
-			if err := tx.Create(&pickingSheet).Error; err != nil {
-				tx.Rollback()
-				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Failed to create picking sheet",
-				})
-			}
-
-			// Update Inventory
-			if err := tx.Debug().
-				Model(&models.Inventory{}).
-				Where("id = ?", inventory.ID).
-				Updates(map[string]interface{}{
-					"qty_available": gorm.Expr("qty_available - ?", qtyPick),
-					"qty_allocated": gorm.Expr("qty_allocated + ?", qtyPick),
-					"updated_by":    int(ctx.Locals("userID").(float64)),
-					"updated_at":    time.Now(),
-				}).Error; err != nil {
-				tx.Rollback()
-				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Failed to update inventory",
-				})
+			var businessErr *repositories.BusinessError
+			if errors.As(err, &businessErr) {
+				// stok kurang, coba isi ulang pick face item ini
+				response := fiber.Map{"error": err.Error()}
+				if err := generateReplenishment(c.DB, []string{outboundDetail.ItemCode}, outboundDetail.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+					response["warning"] = "Replenishment not generated: " + err.Error()
+				}
+				return ctx.Status(fiber.StatusBadRequest).JSON(response)
 			}
-
-			qtyReq -= qtyPick
-
-		}
-
-		if qtyReq > 0 {
-			tx.Rollback()
-			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Insufficient stock for item " + outboundDetail.ItemCode,
-			})
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
+		itemCodes = append(itemCodes, outboundDetail.ItemCode)
 	}
 
 	// update outbound status
@@ -726,11 +675,27 @@ func (c *OutboundController) PickingOutbound(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update outbound header: " + err.Error()})
 	}
 
+	if _, err := repositories.NewTaskRepository(tx).CreateTask(models.WarehouseTask{
+		TaskType:  repositories.TaskPick,
+		RefNo:     outboundHeader.OutboundNo,
+		WhsCode:   outboundHeader.WhsCode,
+		OwnerCode: outboundHeader.OwnerCode,
+		CreatedBy: int(ctx.Locals("userID").(float64)),
+	}); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Picking Outbound Success"})
+	response := fiber.Map{"success": true, "message": "Picking Outbound Success"}
+	if err := generateReplenishment(c.DB, itemCodes, outboundHeader.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+		response["warning"] = "Replenishment not generated: " + err.Error()
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
 }
 
 func (c *OutboundController) GetPickingSheet(ctx *fiber.Ctx) error {
@@ -808,6 +773,12 @@ func (c *OutboundController) PickingComplete(ctx *fiber.Ctx) error {
 
 	for _, pickingSheet := range pickingSheets {
 
+		var inventory models.Inventory
+		if err := tx.Where("id = ?", pickingSheet.InventoryID).First(&inventory).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		// update inventory
 		if err := tx.Debug().
 			Model(&models.Inventory{}).
@@ -820,6 +791,11 @@ func (c *OutboundController) PickingComplete(ctx *fiber.Ctx) error {
 			tx.Rollback()
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
+
+		if err := helpers.InsertInventoryMovement(tx, inventory, helpers.MovementShip, outboundHeader.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
 	}
 
 	// UPDATE OUTBOUND STATUS
@@ -847,6 +823,16 @@ func (c *OutboundController) PickingComplete(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if err := repositories.NewTaskRepository(tx).CloseTask(repositories.TaskPick, outboundHeader.OutboundNo, int(ctx.Locals("userID").(float64))); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := repositories.NewSerialRepository(tx).Ship(outboundHeader, int(ctx.Locals("userID").(float64))); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Commit transaction
 
 	if err := tx.Commit().Error; err != nil {
@@ -1171,6 +1157,12 @@ func (r *OutboundController) ProccesHandleOpen(ctx *fiber.Ctx) error {
 				tx.Rollback()
 				return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Inventory not found"})
 			}
+
+			if _, err := repositories.NewLocationRepository(tx).ValidateLocation(payload.TempLocationName, inventory.WhsCode, inventory.OwnerCode); err != nil {
+				tx.Rollback()
+				return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+			}
+
 			var newInventory models.Inventory
 			newInventory.OwnerCode = inventory.OwnerCode
 			newInventory.WhsCode = inventory.WhsCode
@@ -1178,6 +1170,9 @@ func (r *OutboundController) ProccesHandleOpen(ctx *fiber.Ctx) error {
 			newInventory.InboundID = inventory.InboundID
 			newInventory.InboundDetailId = inventory.InboundDetailId
 			newInventory.RecDate = inventory.RecDate
+			newInventory.LotNo = inventory.LotNo
+			newInventory.MfgDate = inventory.MfgDate
+			newInventory.ExpDate = inventory.ExpDate
 			newInventory.Pallet = payload.TempLocationName
 			newInventory.Location = payload.TempLocationName
 			newInventory.ItemId = inventory.ItemId
@@ -1199,6 +1194,11 @@ func (r *OutboundController) ProccesHandleOpen(ctx *fiber.Ctx) error {
 				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 			}
 
+			if err := helpers.InsertInventoryMovement(tx, models.Inventory{ID: newInventory.ID}, helpers.MovementUnpostIn, payload.OutboundNo, int(userID)); err != nil {
+				tx.Rollback()
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+
 			// Kurangi inventory lama
 			if err := tx.Model(&models.Inventory{}).Where("id = ?", picking.InventoryID).
 				Updates(map[string]interface{}{
@@ -1211,10 +1211,21 @@ func (r *OutboundController) ProccesHandleOpen(ctx *fiber.Ctx) error {
 				tx.Rollback()
 				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 			}
+
+			if err := helpers.InsertInventoryMovement(tx, inventory, helpers.MovementUnpostOut, payload.OutboundNo, int(userID)); err != nil {
+				tx.Rollback()
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
 		}
 	} else {
 		// Kalau action return to origin location
 		for _, picking := range outboundPickings {
+			var inventory models.Inventory
+			if err := tx.Where("id = ?", picking.InventoryID).First(&inventory).Error; err != nil {
+				tx.Rollback()
+				return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Inventory not found"})
+			}
+
 			if err := tx.Debug().Model(&models.Inventory{}).Where("id = ?", picking.InventoryID).
 				Updates(map[string]interface{}{
 					"qty_available": gorm.Expr("qty_available + ?", picking.Quantity),
@@ -1225,6 +1236,11 @@ func (r *OutboundController) ProccesHandleOpen(ctx *fiber.Ctx) error {
 				tx.Rollback()
 				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 			}
+
+			if err := helpers.InsertInventoryMovement(tx, inventory, helpers.MovementUnallocate, payload.OutboundNo, int(userID)); err != nil {
+				tx.Rollback()
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
 		}
 	}
 
@@ -1240,6 +1256,16 @@ func (r *OutboundController) ProccesHandleOpen(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	// serial yang sudah dipick ikut kembali ke stock
+	serialLocation := ""
+	if payload.Action == "temp_location" {
+		serialLocation = payload.TempLocationName
+	}
+	if err := repositories.NewSerialRepository(tx).ReleaseOutbound(outboundHeader.OutboundNo, serialLocation, int(userID)); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Delete scan vas outbound
 	if err := tx.Unscoped().Where("outbound_id = ?", outboundHeader.ID).Delete(&models.OutboundVas{}).Error; err != nil {
 		tx.Rollback()
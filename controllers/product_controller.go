@@ -36,6 +36,9 @@ var productInput struct {
 	ManualBook string  `json:"manual_book" validate:"required,min=1"`
 	Uom        string  `json:"uom" validate:"required,min=3"`
 	OwnerCode  string  `json:"owner_code" validate:"required,min=3"`
+	// kosong = ikut setting owner
+	AllocationRule string  `json:"allocation_rule" validate:"omitempty,oneof=FIFO FEFO LIFO SMALLEST_PALLET FEWEST_LOCATION"`
+	UnitCost       float64 `json:"unit_cost" validate:"gte=0"`
 }
 
 func (c *ProductController) CreateProduct(ctx *fiber.Ctx) error {
@@ -59,23 +62,25 @@ func (c *ProductController) CreateProduct(ctx *fiber.Ctx) error {
 
 	// Membuat user dengan memasukkan data ke struct models.Product
 	product := models.Product{
-		ItemCode:   productInput.ItemCode,
-		ItemName:   productInput.ItemName,
-		CBM:        productInput.CBM,
-		Barcode:    productInput.GMC,
-		GMC:        productInput.GMC,
-		Width:      productInput.Width,
-		Length:     productInput.Length,
-		Height:     productInput.Height,
-		Group:      productInput.Group,
-		Category:   productInput.Category,
-		HasSerial:  productInput.Serial,
-		HasWaranty: productInput.Waranty,
-		HasAdaptor: productInput.Adaptor,
-		ManualBook: productInput.ManualBook,
-		Uom:        productInput.Uom,
-		OwnerCode:  productInput.OwnerCode,
-		CreatedBy:  int(ctx.Locals("userID").(float64)),
+		ItemCode:       productInput.ItemCode,
+		ItemName:       productInput.ItemName,
+		CBM:            productInput.CBM,
+		Barcode:        productInput.GMC,
+		GMC:            productInput.GMC,
+		Width:          productInput.Width,
+		Length:         productInput.Length,
+		Height:         productInput.Height,
+		Group:          productInput.Group,
+		Category:       productInput.Category,
+		HasSerial:      productInput.Serial,
+		HasWaranty:     productInput.Waranty,
+		HasAdaptor:     productInput.Adaptor,
+		ManualBook:     productInput.ManualBook,
+		Uom:            productInput.Uom,
+		OwnerCode:      productInput.OwnerCode,
+		AllocationRule: productInput.AllocationRule,
+		UnitCost:       productInput.UnitCost,
+		CreatedBy:      int(ctx.Locals("userID").(float64)),
 	}
 
 	if err := c.DB.Create(&product).Error; err != nil {
@@ -176,24 +181,26 @@ func (c *ProductController) UpdateProduct(ctx *fiber.Ctx) error {
 		Model(&models.Product{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"item_code":   productInput.ItemCode,
-			"item_name":   productInput.ItemName,
-			"cbm":         productInput.CBM,
-			"gmc":         productInput.GMC,
-			"barcode":     productInput.GMC,
-			"group":       productInput.Group,
-			"category":    productInput.Category,
-			"width":       productInput.Width,
-			"length":      productInput.Length,
-			"height":      productInput.Height,
-			"has_serial":  productInput.Serial,
-			"has_waranty": productInput.Waranty,
-			"has_adaptor": productInput.Adaptor,
-			"manual_book": productInput.ManualBook,
-			"uom":         productInput.Uom,
-			"owner_code":  productInput.OwnerCode,
-			"updated_at":  time.Now(),
-			"updated_by":  int(ctx.Locals("userID").(float64)),
+			"item_code":       productInput.ItemCode,
+			"item_name":       productInput.ItemName,
+			"cbm":             productInput.CBM,
+			"gmc":             productInput.GMC,
+			"barcode":         productInput.GMC,
+			"group":           productInput.Group,
+			"category":        productInput.Category,
+			"width":           productInput.Width,
+			"length":          productInput.Length,
+			"height":          productInput.Height,
+			"has_serial":      productInput.Serial,
+			"has_waranty":     productInput.Waranty,
+			"has_adaptor":     productInput.Adaptor,
+			"manual_book":     productInput.ManualBook,
+			"uom":             productInput.Uom,
+			"owner_code":      productInput.OwnerCode,
+			"allocation_rule": productInput.AllocationRule,
+			"unit_cost":       productInput.UnitCost,
+			"updated_at":      time.Now(),
+			"updated_by":      int(ctx.Locals("userID").(float64)),
 		}).Error; err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+type ProductivityController struct {
+	DB *gorm.DB
+}
+
+func (c *ProductivityController) GetShifts(ctx *fiber.Ctx) error {
+	var shifts []models.Shift
+	if err := c.DB.Order("start_time").Find(&shifts).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": shifts})
+}
+
+// SaveShift insert atau update shift berdasarkan shift code
+func (c *ProductivityController) SaveShift(ctx *fiber.Ctx) error {
+	var input struct {
+		ShiftCode string `json:"shift_code"`
+		Name      string `json:"name"`
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+		IsActive  *bool  `json:"is_active"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var shift models.Shift
+	err := c.DB.Where("shift_code = ?", input.ShiftCode).First(&shift).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	shift.ShiftCode = input.ShiftCode
+	shift.Name = input.Name
+	shift.StartTime = input.StartTime
+	shift.EndTime = input.EndTime
+	shift.IsActive = input.IsActive == nil || *input.IsActive
+	shift.UpdatedBy = userID
+
+	if err := repositories.ValidateShift(shift); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if shift.ID == 0 {
+		shift.CreatedBy = userID
+		err = c.DB.Create(&shift).Error
+	} else {
+		err = c.DB.Save(&shift).Error
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Shift saved successfully", "data": shift})
+}
+
+func (c *ProductivityController) DeleteShift(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	var shift models.Shift
+	if err := c.DB.First(&shift, id).Error; err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Shift not found"})
+	}
+
+	shift.DeletedBy = int(ctx.Locals("userID").(float64))
+	if err := c.DB.Save(&shift).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := c.DB.Delete(&shift).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Shift deleted successfully"})
+}
+
+// GetProductivity lines / units / scans per jam per user, per shift dan aktivitas
+func (c *ProductivityController) GetProductivity(ctx *fiber.Ctx) error {
+	var filter repositories.ProductivityFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rows, err := repositories.NewProductivityRepository(c.DB).GetProductivity(filter)
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": rows})
+}
+
+func (c *ProductivityController) GetLeaderboard(ctx *fiber.Ctx) error {
+	var filter repositories.ProductivityFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rows, err := repositories.NewProductivityRepository(c.DB).GetLeaderboard(filter)
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": rows})
+}
+
+func (c *ProductivityController) ExportProductivity(ctx *fiber.Ctx) error {
+	var filter repositories.ProductivityFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rows, err := repositories.NewProductivityRepository(c.DB).GetProductivity(filter)
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	f := excelize.NewFile()
+	sheet := "Sheet1"
+
+	headers := []string{"Work Date", "Shift", "Username", "Name", "Activity", "Scans", "Lines", "Units",
+		"Active Hours", "Scans/Hour", "Lines/Hour", "Units/Hour", "First Scan", "Last Scan"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+
+	for i, row := range rows {
+		line := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", line), row.WorkDate)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", line), row.ShiftCode)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", line), row.Username)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", line), row.Name)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", line), row.Activity)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", line), row.Scans)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", line), row.Lines)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", line), row.Units)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", line), row.ActiveHours)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", line), row.ScansPerHour)
+		f.SetCellValue(sheet, fmt.Sprintf("K%d", line), row.LinesPerHour)
+		f.SetCellValue(sheet, fmt.Sprintf("L%d", line), row.UnitsPerHour)
+		f.SetCellValue(sheet, fmt.Sprintf("M%d", line), row.FirstScan.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheet, fmt.Sprintf("N%d", line), row.LastScan.Format("2006-01-02 15:04:05"))
+	}
+
+	ctx.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Set("Content-Disposition", `attachment; filename="productivity.xlsx"`)
+
+	if err := f.Write(ctx.Response().BodyWriter()); err != nil {
+		return ctx.Status(http.StatusInternalServerError).SendString("Gagal generate Excel")
+	}
+
+	return nil
+}
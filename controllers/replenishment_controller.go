@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ReplenishmentController struct {
+	DB *gorm.DB
+}
+
+// generateReplenishment dipanggil setelah alokasi, error dicatat dan dikembalikan
+// sebagai warning supaya alokasi yang sudah commit tidak ikut gagal
+func generateReplenishment(db *gorm.DB, itemCodes []string, refNo string, userID int) error {
+	if len(itemCodes) == 0 {
+		return nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := repositories.NewReplenishmentRepository(tx).GenerateTasks(itemCodes, repositories.ReplenishSourceAllocation, refNo, userID)
+		return err
+	})
+	if err != nil {
+		log.Println("Generate replenishment", refNo, "gagal:", err)
+	}
+	return err
+}
+
+func (c *ReplenishmentController) GetSettings(ctx *fiber.Ctx) error {
+	var settings []models.ReplenishmentSetting
+	query := c.DB.Order("item_code, location")
+	if itemCode := ctx.Query("item_code"); itemCode != "" {
+		query = query.Where("item_code = ?", itemCode)
+	}
+	if err := query.Find(&settings).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": settings})
+}
+
+// SaveSetting create atau update setting min/max per item per lokasi pick face
+func (c *ReplenishmentController) SaveSetting(ctx *fiber.Ctx) error {
+	var input struct {
+		ItemCode    string `json:"item_code"`
+		Location    string `json:"location"`
+		OwnerCode   string `json:"owner_code"`
+		WhsCode     string `json:"whs_code"`
+		MinQty      int    `json:"min_qty"`
+		MaxQty      int    `json:"max_qty"`
+		ReplenishTo int    `json:"replenish_to"`
+		IsActive    *bool  `json:"is_active"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if input.MinQty < 0 || input.MaxQty <= 0 || input.MinQty >= input.MaxQty {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Max qty must be greater than min qty"})
+	}
+	if input.ReplenishTo != 0 && (input.ReplenishTo <= input.MinQty || input.ReplenishTo > input.MaxQty) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Replenish to must be between min and max qty"})
+	}
+
+	var product models.Product
+	if err := c.DB.Where("item_code = ?", input.ItemCode).First(&product).Error; err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Product not found"})
+	}
+
+	if _, err := repositories.NewLocationRepository(c.DB).ValidateLocation(input.Location, input.WhsCode, input.OwnerCode); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var setting models.ReplenishmentSetting
+	err := c.DB.Where("item_code = ? AND location = ?", input.ItemCode, input.Location).First(&setting).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	setting.ItemID = int(product.ID)
+	setting.ItemCode = product.ItemCode
+	setting.Location = input.Location
+	setting.OwnerCode = input.OwnerCode
+	setting.WhsCode = input.WhsCode
+	setting.MinQty = input.MinQty
+	setting.MaxQty = input.MaxQty
+	setting.ReplenishTo = input.ReplenishTo
+	setting.IsActive = input.IsActive == nil || *input.IsActive
+	setting.UpdatedBy = userID
+
+	if setting.ID == 0 {
+		setting.CreatedBy = userID
+		err = c.DB.Create(&setting).Error
+	} else {
+		err = c.DB.Save(&setting).Error
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Replenishment setting saved successfully", "data": setting})
+}
+
+func (c *ReplenishmentController) DeleteSetting(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	var setting models.ReplenishmentSetting
+	if err := c.DB.First(&setting, id).Error; err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Replenishment setting not found"})
+	}
+
+	setting.DeletedBy = int(ctx.Locals("userID").(float64))
+	if err := c.DB.Save(&setting).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := c.DB.Delete(&setting).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Replenishment setting deleted successfully"})
+}
+
+// GenerateTasks menjalankan generator replenishment on demand
+func (c *ReplenishmentController) GenerateTasks(ctx *fiber.Ctx) error {
+	var input struct {
+		ItemCodes []string `json:"item_codes"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var tasks []models.ReplenishmentTask
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tasks, err = repositories.NewReplenishmentRepository(tx).GenerateTasks(input.ItemCodes, repositories.ReplenishSourceManual, "", userID)
+		return err
+	})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": fmt.Sprintf("%d replenishment task generated", len(tasks)), "data": tasks})
+}
+
+// GetTasks laporan task replenishment, default yang masih open
+func (c *ReplenishmentController) GetTasks(ctx *fiber.Ctx) error {
+	status := ctx.Query("status", "open")
+	if status == "all" {
+		status = ""
+	}
+
+	tasks, err := repositories.NewReplenishmentRepository(c.DB).GetTaskList(status)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": tasks})
+}
+
+func (c *ReplenishmentController) CancelTask(ctx *fiber.Ctx) error {
+	taskNo := ctx.Params("task_no")
+
+	var task models.ReplenishmentTask
+	if err := c.DB.Where("task_no = ?", taskNo).First(&task).Error; err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Replenishment task not found"})
+	}
+
+	if task.Status != "open" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Replenishment task " + taskNo + " is " + task.Status})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		// syarat masih open dan belum ada qty yang dipindah RF sejak dibaca
+		result := tx.Model(&task).Where("status = ? AND qty_moved = ?", "open", task.QtyMoved).Updates(map[string]interface{}{
+			"status":     "cancel",
+			"updated_by": userID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return &repositories.BusinessError{Message: "Replenishment task " + taskNo + " was changed by another request", Status: fiber.StatusConflict}
+		}
+		return repositories.NewTaskRepository(tx).CancelTask(repositories.TaskReplenish, task.TaskNo, userID)
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Replenishment task " + taskNo + " cancelled"})
+}
@@ -0,0 +1,482 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Disposition barang retur
+const (
+	DispositionRestock    = "RESTOCK"
+	DispositionQuarantine = "QUARANTINE"
+	DispositionScrap      = "SCRAP"
+	DispositionRTV        = "RTV"
+)
+
+// qa status default per disposition, RESTOCK memakai qa status default owner
+var dispositionQaStatus = map[string]string{
+	DispositionQuarantine: "Q",
+	DispositionScrap:      "S",
+	DispositionRTV:        "R",
+}
+
+type ReturnController struct {
+	DB *gorm.DB
+}
+
+func NewReturnController(DB *gorm.DB) *ReturnController {
+	return &ReturnController{DB: DB}
+}
+
+type ReturnItemInput struct {
+	OutboundDetailID int    `json:"outbound_detail_id"`
+	ItemCode         string `json:"item_code" validate:"required"`
+	SerialNumber     string `json:"serial_number"`
+	Quantity         int    `json:"quantity" validate:"required,min=1"`
+	Reason           string `json:"reason" validate:"required"`
+	Disposition      string `json:"disposition" validate:"required,oneof=RESTOCK QUARANTINE SCRAP RTV"`
+	QaStatus         string `json:"qa_status"`
+	Location         string `json:"location" validate:"required"`
+}
+
+type ReturnInput struct {
+	OutboundNo string            `json:"outbound_no" validate:"required"`
+	ReturnDate string            `json:"return_date"`
+	Reason     string            `json:"reason"`
+	Remarks    string            `json:"remarks"`
+	Items      []ReturnItemInput `json:"items" validate:"required,min=1,dive"`
+}
+
+func (c *ReturnController) getShippedOutbound(db *gorm.DB, outboundNo string) (models.OutboundHeader, error) {
+	var outbound models.OutboundHeader
+	if err := db.Where("outbound_no = ?", outboundNo).First(&outbound).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return outbound, fiber.NewError(fiber.StatusNotFound, "Outbound "+outboundNo+" not found")
+		}
+		return outbound, err
+	}
+	if outbound.Status != "complete" {
+		return outbound, fiber.NewError(fiber.StatusBadRequest, "Outbound "+outboundNo+" has not been shipped")
+	}
+	return outbound, nil
+}
+
+// GetShippedItems menampilkan item outbound yang bisa diretur beserta sisa qty-nya
+func (c *ReturnController) GetShippedItems(ctx *fiber.Ctx) error {
+	outbound, err := c.getShippedOutbound(c.DB, ctx.Params("outbound_no"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	items, err := repositories.NewReturnRepository(c.DB).GetShippedItems(int(outbound.ID))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"outbound": outbound, "items": items},
+	})
+}
+
+// validateReturnItems mencocokkan item retur dengan yang benar-benar dikirim di outbound
+func (c *ReturnController) validateReturnItems(db *gorm.DB, outbound models.OutboundHeader, items []ReturnItemInput) ([]models.ReturnDetail, error) {
+	repo := repositories.NewReturnRepository(db)
+
+	shippedItems, err := repo.GetShippedItems(int(outbound.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	ownerSetting, err := helpers.GetOwnerSetting(db, outbound.OwnerCode)
+	if err != nil {
+		return nil, err
+	}
+
+	shippedByID := map[int]*repositories.ShippedItem{}
+	for i := range shippedItems {
+		shippedByID[shippedItems[i].OutboundDetailID] = &shippedItems[i]
+	}
+
+	serials := map[string]bool{}
+	var details []models.ReturnDetail
+
+	for _, item := range items {
+		// cari baris outbound detail, kalau id tidak dikirim cari berdasarkan item code
+		var shipped *repositories.ShippedItem
+		if item.OutboundDetailID > 0 {
+			shipped = shippedByID[item.OutboundDetailID]
+			if shipped != nil && shipped.ItemCode != item.ItemCode {
+				shipped = nil
+			}
+		} else {
+			for i := range shippedItems {
+				if shippedItems[i].ItemCode != item.ItemCode {
+					continue
+				}
+				if shipped != nil {
+					return nil, fiber.NewError(fiber.StatusBadRequest, "Item "+item.ItemCode+" shipped in more than one line, outbound_detail_id is required")
+				}
+				shipped = &shippedItems[i]
+			}
+		}
+		if shipped == nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Item "+item.ItemCode+" was not shipped in outbound "+outbound.OutboundNo)
+		}
+
+		if shipped.QtyReturned+item.Quantity > shipped.QtyShipped {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Item %s return qty exceeds shipped qty (shipped %d, returned %d)",
+				item.ItemCode, shipped.QtyShipped, shipped.QtyReturned))
+		}
+		shipped.QtyReturned += item.Quantity
+
+		detail := models.ReturnDetail{
+			OutboundDetailID: shipped.OutboundDetailID,
+			ItemID:           shipped.ItemID,
+			ItemCode:         shipped.ItemCode,
+			Barcode:          shipped.Barcode,
+			Quantity:         item.Quantity,
+			Uom:              shipped.Uom,
+			Reason:           item.Reason,
+			Disposition:      item.Disposition,
+			Location:         item.Location,
+			OwnerCode:        outbound.OwnerCode,
+			WhsCode:          outbound.WhsCode,
+		}
+
+		// item serial wajib retur per serial dan serial harus yang dikirim di outbound ini
+		if helpers.ResolveSerial(ownerSetting, shipped.HasSerial) == "Y" {
+			if item.SerialNumber == "" {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "Serial number is required for item "+item.ItemCode)
+			}
+			if item.Quantity != 1 {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "Serial item "+item.ItemCode+" must be returned one by one")
+			}
+			if serials[item.ItemCode+"|"+item.SerialNumber] {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "Serial "+item.SerialNumber+" is duplicated")
+			}
+			serials[item.ItemCode+"|"+item.SerialNumber] = true
+
+			barcode, err := repo.GetShippedSerial(int(outbound.ID), item.ItemCode, item.SerialNumber)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, fiber.NewError(fiber.StatusBadRequest, "Serial "+item.SerialNumber+" was not shipped in outbound "+outbound.OutboundNo)
+				}
+				return nil, err
+			}
+
+			returned, err := repo.IsSerialReturned(int(outbound.ID), item.ItemCode, item.SerialNumber)
+			if err != nil {
+				return nil, err
+			}
+			if returned {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "Serial "+item.SerialNumber+" already returned")
+			}
+
+			detail.SerialNumber = item.SerialNumber
+			detail.OutboundBarcodeID = barcode.ID
+		}
+
+		detail.QaStatus = item.QaStatus
+		if detail.QaStatus == "" {
+			detail.QaStatus = dispositionQaStatus[item.Disposition]
+		}
+		if detail.QaStatus == "" {
+			detail.QaStatus = ownerSetting.QaStatus
+		}
+
+		var locationCount int64
+		if err := db.Model(&models.Location{}).Where("location_code = ? AND is_active = ?", item.Location, true).Count(&locationCount).Error; err != nil {
+			return nil, err
+		}
+		if locationCount == 0 {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Location "+item.Location+" not found or inactive")
+		}
+
+		details = append(details, detail)
+	}
+
+	return details, nil
+}
+
+func (c *ReturnController) CreateReturn(ctx *fiber.Ctx) error {
+	var payload ReturnInput
+	if err := ctx.BodyParser(&payload); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "message": "Invalid payload", "error": err.Error()})
+	}
+
+	for i := range payload.Items {
+		payload.Items[i].Disposition = strings.ToUpper(payload.Items[i].Disposition)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(payload); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "message": "Invalid payload", "error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	outbound, err := c.getShippedOutbound(tx, payload.OutboundNo)
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	details, err := c.validateReturnItems(tx, outbound, payload.Items)
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	returnNo, err := repositories.NewReturnRepository(tx).GenerateReturnNo(outbound.OwnerCode, outbound.WhsCode)
+	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to generate return no", "error": err.Error()})
+	}
+
+	if payload.ReturnDate == "" {
+		payload.ReturnDate = time.Now().Format("2006-01-02")
+	}
+
+	header := models.ReturnHeader{
+		ReturnNo:     returnNo,
+		ReturnDate:   payload.ReturnDate,
+		OutboundID:   outbound.ID,
+		OutboundNo:   outbound.OutboundNo,
+		ShipmentID:   outbound.ShipmentID,
+		CustomerCode: outbound.CustomerCode,
+		OwnerCode:    outbound.OwnerCode,
+		WhsCode:      outbound.WhsCode,
+		Reason:       payload.Reason,
+		Status:       "open",
+		Remarks:      payload.Remarks,
+		CreatedBy:    userID,
+		UpdatedBy:    userID,
+	}
+	if err := tx.Create(&header).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to insert return header", "error": err.Error()})
+	}
+
+	for i := range details {
+		details[i].ReturnID = header.ID
+		details[i].ReturnNo = header.ReturnNo
+		details[i].CreatedBy = userID
+		details[i].UpdatedBy = userID
+	}
+	if err := tx.Create(&details).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to insert return details", "error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to commit transaction", "error": err.Error()})
+	}
+
+	header.Details = details
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "message": "Return created successfully", "data": header})
+}
+
+func (c *ReturnController) GetAllReturns(ctx *fiber.Ctx) error {
+	list, err := repositories.NewReturnRepository(c.DB).GetReturnList()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": list})
+}
+
+func (c *ReturnController) GetReturnByNo(ctx *fiber.Ctx) error {
+	var header models.ReturnHeader
+	if err := c.DB.Preload("Details").Where("return_no = ?", ctx.Params("return_no")).First(&header).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Return not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": header})
+}
+
+// CompleteReturn memposting barang retur ke inventory sesuai disposition dan qa status-nya.
+// Selain RESTOCK, qty masuk ke qty_suspend sehingga tidak ikut dialokasikan.
+func (c *ReturnController) CompleteReturn(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var header models.ReturnHeader
+	if err := tx.Preload("Details").Where("return_no = ?", ctx.Params("return_no")).First(&header).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Return not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if header.Status != "open" {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Return " + header.ReturnNo + " is " + header.Status})
+	}
+
+	now := time.Now()
+
+	// status dipindah duluan dengan syarat masih open, request complete lain yang bersamaan
+	// tidak ikut membuat inventory dua kali
+	result := tx.Model(&models.ReturnHeader{}).Where("id = ? AND status = ?", header.ID, "open").Updates(map[string]interface{}{
+		"status":      "complete",
+		"complete_at": now,
+		"complete_by": userID,
+		"updated_by":  userID,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Return " + header.ReturnNo + " is already being completed"})
+	}
+
+	for _, detail := range header.Details {
+		// lot dan expiry diambil dari inventory asal barang saat dikirim
+		var source models.OutboundBarcode
+		query := tx.Where("outbound_detail_id = ?", detail.OutboundDetailID)
+		if detail.OutboundBarcodeID > 0 {
+			query = tx.Where("id = ?", detail.OutboundBarcodeID)
+		}
+		if err := query.First(&source).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var origin models.Inventory
+		if source.InventoryID > 0 {
+			if err := tx.Unscoped().Where("id = ?", source.InventoryID).First(&origin).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				tx.Rollback()
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		qtyAvailable, qtySuspend := detail.Quantity, 0
+		if detail.Disposition != DispositionRestock {
+			qtyAvailable, qtySuspend = 0, detail.Quantity
+		}
+
+		uom := detail.Uom
+		if origin.Uom != "" {
+			uom = origin.Uom
+		}
+
+		newInventory := models.Inventory{
+			OwnerCode:    detail.OwnerCode,
+			WhsCode:      detail.WhsCode,
+			DivisionCode: origin.DivisionCode,
+			RecDate:      header.ReturnDate,
+			LotNo:        origin.LotNo,
+			MfgDate:      origin.MfgDate,
+			ExpDate:      origin.ExpDate,
+			Pallet:       header.ReturnNo,
+			Location:     detail.Location,
+			ItemId:       detail.ItemID,
+			ItemCode:     detail.ItemCode,
+			Barcode:      detail.Barcode,
+			QaStatus:     detail.QaStatus,
+			Uom:          uom,
+			QtyOrigin:    detail.Quantity,
+			QtyOnhand:    detail.Quantity,
+			QtyAvailable: qtyAvailable,
+			QtySuspend:   qtySuspend,
+			Trans:        "return",
+			CreatedBy:    userID,
+		}
+		if err := tx.Create(&newInventory).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := helpers.InsertInventoryMovement(tx, models.Inventory{ID: newInventory.ID}, helpers.MovementReturnIn, header.ReturnNo, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if err := repositories.NewHoldRepository(tx).Apply(newInventory, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if detail.SerialNumber != "" && detail.SerialNumber != detail.Barcode {
+			if err := repositories.NewSerialRepository(tx).Return(detail, newInventory, userID); err != nil {
+				tx.Rollback()
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		if err := tx.Model(&models.ReturnDetail{}).Where("id = ?", detail.ID).Updates(map[string]interface{}{
+			"inventory_id": int(newInventory.ID),
+			"updated_by":   userID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Return " + header.ReturnNo + " completed successfully"})
+}
+
+func (c *ReturnController) CancelReturn(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var header models.ReturnHeader
+	if err := c.DB.Where("return_no = ?", ctx.Params("return_no")).First(&header).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Return not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if header.Status != "open" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Only open return can be cancelled"})
+	}
+
+	// syarat masih open supaya tidak membatalkan return yang sedang di-complete
+	result := c.DB.Model(&models.ReturnHeader{}).Where("id = ? AND status = ?", header.ID, "open").Updates(map[string]interface{}{
+		"status":     "cancel",
+		"cancel_at":  time.Now(),
+		"cancel_by":  userID,
+		"updated_by": userID,
+	})
+	if result.Error != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected != 1 {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Return " + header.ReturnNo + " is no longer open"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Return " + header.ReturnNo + " cancelled successfully"})
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"fiber-app/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type SerialController struct {
+	DB *gorm.DB
+}
+
+// SearchSerial posisi terakhir serial dan riwayatnya, termasuk outbound dan customer tujuan kirim
+func (c *SerialController) SearchSerial(ctx *fiber.Ctx) error {
+	serialNo := ctx.Params("serial_no")
+	if serialNo == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Serial number is required"})
+	}
+
+	result, err := repositories.NewSerialRepository(c.DB).Search(serialNo, ctx.Query("item_code"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(result) == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Serial " + serialNo + " not found"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": result})
+}
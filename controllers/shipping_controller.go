@@ -203,47 +203,9 @@ func NewShippingController(DB *gorm.DB) *ShippingController {
 // 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Order created successfully"})
 // }
 
+// GenerateOrderNo nomor SPK lewat numbering service, default SPKYM<YYMM><seq> reset tiap bulan
 func GenerateOrderNo(db *gorm.DB) (string, error) {
-	prefix := "SPK"
-	companyCode := "YM"
-	now := time.Now()
-
-	year := now.Format("06")  // 2 digit tahun
-	month := now.Format("01") // 2 digit bulan
-
-	// Hitung range awal dan akhir bulan ini
-	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
-	firstOfNextMonth := firstOfMonth.AddDate(0, 1, 0)
-
-	// Cari order terakhir yang dibuat di bulan ini
-	var lastOrder models.OrderHeader
-	err := db.Debug().
-		Where("created_at >= ? AND created_at < ?", firstOfMonth, firstOfNextMonth).
-		Order("order_no DESC").
-		First(&lastOrder).Error
-
-	fmt.Println("lastOrder:", lastOrder)
-
-	var sequence int
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			sequence = 1
-		} else {
-			return "", err
-		}
-	} else {
-		lastOrderNo := lastOrder.OrderNo
-		if len(lastOrderNo) >= 4 {
-			var lastSequence int
-			fmt.Sscanf(lastOrderNo[len(lastOrderNo)-4:], "%d", &lastSequence)
-			sequence = lastSequence + 1
-		} else {
-			sequence = 1
-		}
-	}
-
-	orderNo := fmt.Sprintf("%s%s%s%s%04d", prefix, companyCode, year, month, sequence)
-	return orderNo, nil
+	return repositories.NewNumberingRepository(db).Next(repositories.DocOrder, "", "", "")
 }
 
 type OrderItem struct {
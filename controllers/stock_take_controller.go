@@ -1,12 +1,9 @@
 package controllers
 
 import (
-	"errors"
 	"fiber-app/models"
 	"fiber-app/repositories"
 	"fmt"
-	"strconv"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -21,33 +18,7 @@ func NewStockTakeController(DB *gorm.DB) *StockTakeController {
 }
 
 func (c *StockTakeController) GenerateStockTakeCode() (string, error) {
-	var lastCode models.StockTake
-
-	// Ambil inbound terakhir
-	if err := c.DB.Last(&lastCode).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return "", err
-	}
-
-	// Ambil bulan dan tahun saat ini
-	currentYear := time.Now().Format("2006")
-	currentMonth := time.Now().Format("01")
-	currentDay := time.Now().Format("02")
-
-	// Generate nomor inbound baru
-	var stoNo string
-	if lastCode.Code != "" {
-		lastStoNo := lastCode.Code[len(lastCode.Code)-4:]
-		if currentDay != lastCode.Code[8:10] {
-			stoNo = fmt.Sprintf("ST%s%s%s%04d", currentYear, currentMonth, currentDay, 1)
-		} else {
-			lastStoNoInt, _ := strconv.Atoi(lastStoNo)
-			stoNo = fmt.Sprintf("ST%s%s%s%04d", currentYear, currentMonth, currentDay, lastStoNoInt+1)
-		}
-	} else {
-		stoNo = fmt.Sprintf("ST%s%s%s%04d", currentYear, currentMonth, currentDay, 1)
-	}
-
-	return stoNo, nil
+	return repositories.NewNumberingRepository(c.DB).Next(repositories.DocStockTake, "", "", "")
 }
 
 func (c *StockTakeController) GenerateDataStockTake(ctx *fiber.Ctx) error {
@@ -109,7 +80,7 @@ func (c *StockTakeController) GenerateDataStockTake(ctx *fiber.Ctx) error {
 	var inventories []models.Inventory
 	if err := c.DB.
 		Where("location IN ?", locationCodes).
-		Where("qty_available > ?", 0).
+		Where("qty_onhand > ?", 0).
 		Find(&inventories).Error; err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -161,7 +132,7 @@ func (c *StockTakeController) GenerateDataStockTake(ctx *fiber.Ctx) error {
 			Pallet:      inv.Pallet,
 			Barcode:     inv.Barcode,
 			// SerialNumber: inv.SerialNumber,
-			SystemQty:  inv.QtyAvailable,
+			SystemQty:  inv.QtyOnhand,
 			CountedQty: 0,
 			Difference: 0,
 			CreatedBy:  int(ctx.Locals("userID").(float64)),
@@ -179,6 +150,22 @@ func (c *StockTakeController) GenerateDataStockTake(ctx *fiber.Ctx) error {
 		}
 	}
 
+	// task count diselesaikan manual dari RF karena satu stock take bisa dihitung beberapa counter
+	if _, err := repositories.NewTaskRepository(c.DB).CreateTask(models.WarehouseTask{
+		TaskType:  repositories.TaskCount,
+		RefNo:     stockTake.Code,
+		WhsCode:   inventories[0].WhsCode,
+		Quantity:  len(items),
+		Remarks:   fmt.Sprintf("%d locations", len(locationCodes)),
+		CreatedBy: int(ctx.Locals("userID").(float64)),
+	}); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Failed to create count task",
+			"error":   err.Error(),
+		})
+	}
+
 	// 6. Return response
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
@@ -279,6 +266,22 @@ func (c *StockTakeController) GetAllStockTake(ctx *fiber.Ctx) error {
 	})
 }
 
+// blindStockTake system qty dan selisih tidak dikirim ke counter selama blind count masih berjalan
+func blindStockTake(stockTake models.StockTake) models.StockTake {
+	if !stockTake.IsBlind || stockTake.Status != repositories.StockTakeOpen {
+		return stockTake
+	}
+
+	items := make([]models.StockTakeItem, len(stockTake.Items))
+	for i, item := range stockTake.Items {
+		item.SystemQty = 0
+		item.Difference = 0
+		items[i] = item
+	}
+	stockTake.Items = items
+	return stockTake
+}
+
 func (c *StockTakeController) GetStockTakeDetail(ctx *fiber.Ctx) error {
 	code := ctx.Params("code")
 	var stockTake models.StockTake
@@ -287,7 +290,7 @@ func (c *StockTakeController) GetStockTakeDetail(ctx *fiber.Ctx) error {
 		return ctx.Status(404).JSON(fiber.Map{"success": false, "message": "Not found"})
 	}
 
-	return ctx.JSON(fiber.Map{"success": true, "data": stockTake.Items})
+	return ctx.JSON(fiber.Map{"success": true, "data": blindStockTake(stockTake).Items})
 }
 
 func (c *StockTakeController) ScanStockTake(ctx *fiber.Ctx) error {
@@ -308,6 +311,13 @@ func (c *StockTakeController) ScanStockTake(ctx *fiber.Ctx) error {
 	if err := c.DB.Preload("Items").First(&stockTake, "code = ?", input.StockTakeCode).Error; err != nil {
 		return ctx.Status(404).JSON(fiber.Map{"success": false, "message": "Not found"})
 	}
+	if stockTake.Status != repositories.StockTakeOpen {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "message": "Stock take " + stockTake.Code + " is " + stockTake.Status})
+	}
+
+	if _, err := repositories.NewLocationRepository(c.DB).ValidateLocation(input.Location, "", ""); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"success": false, "message": err.Error()})
+	}
 
 	// insert to StockTakeBarcodes
 
@@ -321,7 +331,36 @@ func (c *StockTakeController) ScanStockTake(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Internal Server Error", "error": err.Error()})
 	}
 
-	return ctx.JSON(fiber.Map{"success": true, "message": "Success", "data": stockTake.Items})
+	return ctx.JSON(fiber.Map{"success": true, "message": "Success", "data": blindStockTake(stockTake).Items})
+}
+
+// PostStockTake membuat adjustment dari selisih count, adjustment di atas limit owner menunggu approval
+func (c *StockTakeController) PostStockTake(ctx *fiber.Ctx) error {
+	var input struct {
+		ReasonCode string `json:"reason_code"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "message": "Bad request"})
+	}
+
+	var stockTake models.StockTake
+	if err := c.DB.First(&stockTake, "code = ?", ctx.Params("code")).Error; err != nil {
+		return ctx.Status(404).JSON(fiber.Map{"success": false, "message": "Not found"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var adjustments []models.AdjustmentHeader
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		adjustments, err = repositories.NewAdjustmentRepository(tx).FromStockTake(stockTake, input.ReasonCode, userID)
+		return err
+	})
+	if err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"success": false, "message": err.Error()})
+	}
+
+	return ctx.JSON(fiber.Map{"success": true, "message": fmt.Sprintf("Stock take %s posted, %d adjustments created", stockTake.Code, len(adjustments)), "data": adjustments})
 }
 
 func (c *StockTakeController) GetStockTakeBarcodeByCode(ctx *fiber.Ctx) error {
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"fiber-app/models"
+	"fiber-app/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type TaskController struct {
+	DB *gorm.DB
+}
+
+// GetTasks monitoring antrian task, default yang masih aktif (open / claimed)
+func (c *TaskController) GetTasks(ctx *fiber.Ctx) error {
+	var filter repositories.TaskFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.Status == "" {
+		filter.Status = "active"
+	} else if filter.Status == "all" {
+		filter.Status = ""
+	}
+
+	tasks, err := repositories.NewTaskRepository(c.DB).GetTaskList(filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": tasks})
+}
+
+// CreateTask task manual dari supervisor, tipe lain dibuat otomatis oleh flow masing-masing
+func (c *TaskController) CreateTask(ctx *fiber.Ctx) error {
+	var input struct {
+		TaskType       string `json:"task_type"`
+		RefNo          string `json:"ref_no"`
+		WhsCode        string `json:"whs_code"`
+		OwnerCode      string `json:"owner_code"`
+		Location       string `json:"location"`
+		ToLocation     string `json:"to_location"`
+		ItemCode       string `json:"item_code"`
+		Quantity       int    `json:"quantity"`
+		Priority       int    `json:"priority"`
+		AssignedUserID int    `json:"assigned_user_id"`
+		AssignedRole   string `json:"assigned_role"`
+		Remarks        string `json:"remarks"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if input.TaskType != repositories.TaskMove && input.TaskType != repositories.TaskCount {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Only move and count task can be created manually"})
+	}
+	if input.RefNo == "" || input.Location == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ref no and location are required"})
+	}
+	if input.TaskType == repositories.TaskMove && input.ToLocation == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "To location is required for move task"})
+	}
+
+	locationRepo := repositories.NewLocationRepository(c.DB)
+	if _, err := locationRepo.ValidateLocation(input.Location, input.WhsCode, input.OwnerCode); err != nil {
+		return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if input.ToLocation != "" {
+		if _, err := locationRepo.ValidateLocation(input.ToLocation, input.WhsCode, input.OwnerCode); err != nil {
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	task, err := repositories.NewTaskRepository(c.DB).CreateTask(models.WarehouseTask{
+		TaskType:       input.TaskType,
+		RefNo:          input.RefNo,
+		WhsCode:        input.WhsCode,
+		OwnerCode:      input.OwnerCode,
+		Location:       input.Location,
+		ToLocation:     input.ToLocation,
+		ItemCode:       input.ItemCode,
+		Quantity:       input.Quantity,
+		Priority:       input.Priority,
+		AssignedUserID: input.AssignedUserID,
+		AssignedRole:   input.AssignedRole,
+		Remarks:        input.Remarks,
+		CreatedBy:      int(ctx.Locals("userID").(float64)),
+	})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Task created successfully", "data": task})
+}
+
+// UpdateTask mengubah prioritas dan assignment task yang masih aktif
+func (c *TaskController) UpdateTask(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	var input struct {
+		Priority       *int    `json:"priority"`
+		AssignedUserID *int    `json:"assigned_user_id"`
+		AssignedRole   *string `json:"assigned_role"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	var task models.WarehouseTask
+	if err := c.DB.First(&task, id).Error; err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	if task.Status != repositories.TaskStatusOpen && task.Status != repositories.TaskStatusClaimed {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Task is " + task.Status})
+	}
+
+	updates := map[string]interface{}{
+		"updated_by": int(ctx.Locals("userID").(float64)),
+	}
+	if input.Priority != nil {
+		updates["priority"] = *input.Priority
+	}
+	if input.AssignedUserID != nil {
+		updates["assigned_user_id"] = *input.AssignedUserID
+	}
+	if input.AssignedRole != nil {
+		updates["assigned_role"] = *input.AssignedRole
+	}
+
+	if err := c.DB.Model(&task).Updates(updates).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.DB.First(&task, task.ID)
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Task updated successfully", "data": task})
+}
+
+// CancelTask hanya task manual, task dari dokumen ikut batal lewat dokumennya
+func (c *TaskController) CancelTask(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	var task models.WarehouseTask
+	if err := c.DB.First(&task, id).Error; err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	}
+
+	if task.TaskType != repositories.TaskMove && task.TaskType != repositories.TaskCount {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cancel " + task.TaskType + " task from its document " + task.RefNo})
+	}
+	if task.Status != repositories.TaskStatusOpen && task.Status != repositories.TaskStatusClaimed {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Task is " + task.Status})
+	}
+
+	if err := repositories.NewTaskRepository(c.DB).CancelTask(task.TaskType, task.RefNo, int(ctx.Locals("userID").(float64))); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Task cancelled successfully"})
+}
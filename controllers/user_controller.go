@@ -2,7 +2,11 @@ package controllers
 
 import (
 	"errors"
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
@@ -22,7 +26,7 @@ func (c *UserController) CreateUser(ctx *fiber.Ctx) error {
 		Username    string `json:"username" validate:"required,min=3"`
 		Name        string `json:"name" validate:"required,min=3"`
 		Email       string `json:"email" validate:"required,email"`
-		Password    string `json:"password" validate:"required,min=6"`
+		Password    string `json:"password" validate:"required"`
 		BaseRoute   string `json:"base_route"`
 		Roles       []uint `json:"roles"`
 		Permissions []uint `json:"permissions"`
@@ -39,13 +43,15 @@ func (c *UserController) CreateUser(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Hash password
-	// hashedPassword, err := HashPassword(userInput.Password) // pastikan kamu punya fungsi ini
-	// if err != nil {
-	// 	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
-	// }
+	if err := helpers.ValidatePassword(c.DB, 0, userInput.Password); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	hashedPassword := userInput.Password
+	// Hash password
+	hashedPassword, err := helpers.HashPassword(userInput.Password)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
+	}
 
 	user := models.User{
 		Username:  userInput.Username,
@@ -61,6 +67,15 @@ func (c *UserController) CreateUser(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if err := c.DB.Create(&models.PasswordHistory{
+		UserID:    user.ID,
+		Password:  hashedPassword,
+		CreatedAt: time.Now(),
+		CreatedBy: user.CreatedBy,
+	}).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Assign Roles
 	if len(userInput.Roles) > 0 {
 		var roles []models.Role
@@ -98,6 +113,8 @@ func (c *UserController) GetUserByID(ctx *fiber.Ctx) error {
 		})
 	}
 
+	user.Password = ""
+
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"data":    user,
 		"success": true,
@@ -157,12 +174,17 @@ func (c *UserController) UpdateUser(ctx *fiber.Ctx) error {
 	user.UpdatedBy = int(ctx.Locals("userID").(float64))
 
 	// Jika password tidak kosong, update
+	hashedPassword := ""
 	if userInput.Password != "" {
-		// hashedPassword, err := HashPassword(userInput.Password)
-		// if err != nil {
-		//     return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
-		// }
-		user.Password = userInput.Password
+		if err := helpers.ValidatePassword(c.DB, user.ID, userInput.Password); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		hashed, err := helpers.HashPassword(userInput.Password)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
+		}
+		hashedPassword = hashed
 	}
 
 	// Simpan perubahan user
@@ -170,6 +192,12 @@ func (c *UserController) UpdateUser(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if hashedPassword != "" {
+		if err := helpers.SetUserPassword(c.DB, user.ID, hashedPassword, user.UpdatedBy); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
 	// Update Roles
 	if userInput.Roles != nil {
 		var roles []models.Role
@@ -186,6 +214,8 @@ func (c *UserController) UpdateUser(ctx *fiber.Ctx) error {
 		}
 	}
 
+	helpers.InvalidatePermissionCache()
+
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "User updated successfully",
@@ -223,6 +253,13 @@ func (c *UserController) DeleteUser(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
 	}
 
+	if err := c.DB.Where("user_id = ?", user.ID).Delete(&models.UserOwner{}).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	helpers.InvalidatePermissionCache()
+	helpers.InvalidateOwnerCache()
+
 	// Respons sukses
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "User deleted successfully"})
 }
@@ -419,8 +456,250 @@ func (c *UserController) UpdatePermissionsForRole(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update permissions"})
 	}
 
+	helpers.InvalidatePermissionCache()
+
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Permissions updated successfully for role",
 	})
 }
+
+// GetRoutePermissions menampilkan permission yang menjaga setiap endpoint
+func (c *UserController) GetRoutePermissions(ctx *fiber.Ctx) error {
+	type routePermission struct {
+		Method     string `json:"method"`
+		Path       string `json:"path"`
+		Permission string `json:"permission"`
+		Guarded    bool   `json:"guarded"`
+		Mapped     bool   `json:"mapped"`
+	}
+
+	var result []routePermission
+	for _, route := range ctx.App().GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		item := routePermission{Method: route.Method, Path: route.Path}
+		if group, ok := helpers.FindPermissionGroup(strings.TrimRight(route.Path, "/")); ok {
+			item.Guarded = true
+			item.Permission, item.Mapped = group.Permissions.Resolve(route.Method, strings.TrimPrefix(route.Path, group.Prefix))
+		}
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path == result[j].Path {
+			return result[i].Method < result[j].Method
+		}
+		return result[i].Path < result[j].Path
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ChangePassword untuk user yang sedang login
+func (c *UserController) ChangePassword(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	var input struct {
+		OldPassword string `json:"old_password" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required"`
+	}
+
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var user models.User
+	if err := c.DB.First(&user, userID).Error; err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	if !helpers.CheckPassword(user.Password, input.OldPassword) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Old password is incorrect"})
+	}
+
+	if err := helpers.ValidatePassword(c.DB, user.ID, input.NewPassword); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	hashed, err := helpers.HashPassword(input.NewPassword)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to hash password"})
+	}
+
+	if err := helpers.SetUserPassword(c.DB, user.ID, hashed, userID); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Password changed successfully",
+	})
+}
+
+func (c *UserController) GetPasswordPolicy(ctx *fiber.Ctx) error {
+	policy, err := helpers.GetPasswordPolicy(c.DB)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"data": policy, "success": true})
+}
+
+func (c *UserController) UpdatePasswordPolicy(ctx *fiber.Ctx) error {
+	var input struct {
+		MinLength     int  `json:"min_length" validate:"min=6,max=128"`
+		RequireUpper  bool `json:"require_upper"`
+		RequireLower  bool `json:"require_lower"`
+		RequireDigit  bool `json:"require_digit"`
+		RequireSymbol bool `json:"require_symbol"`
+		HistoryCount  int  `json:"history_count" validate:"min=0,max=24"`
+	}
+
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	var policy models.PasswordPolicy
+	if err := c.DB.Order("id ASC").First(&policy).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if policy.ID == 0 {
+		policy.CreatedBy = userID
+	}
+	policy.MinLength = input.MinLength
+	policy.RequireUpper = input.RequireUpper
+	policy.RequireLower = input.RequireLower
+	policy.RequireDigit = input.RequireDigit
+	policy.RequireSymbol = input.RequireSymbol
+	policy.HistoryCount = input.HistoryCount
+	policy.UpdatedBy = userID
+
+	if err := c.DB.Save(&policy).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Password policy updated successfully",
+		"data":    policy,
+	})
+}
+
+func (c *UserController) GetUserOwners(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+	}
+
+	var owners []models.UserOwner
+	if err := c.DB.Where("user_id = ?", id).Order("owner_code ASC").Find(&owners).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"data": owners, "success": true})
+}
+
+// UpdateUserOwners mengganti daftar owner user, owner_codes kosong berarti user bisa akses semua owner
+func (c *UserController) UpdateUserOwners(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID"})
+	}
+
+	var input struct {
+		OwnerCodes []string `json:"owner_codes"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var user models.User
+	if err := c.DB.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+	if int(user.ID) == userID {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Cannot change your own owner access"})
+	}
+
+	seen := map[string]bool{}
+	owners := []models.UserOwner{}
+	for _, code := range input.OwnerCodes {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		owners = append(owners, models.UserOwner{
+			UserID:    user.ID,
+			OwnerCode: code,
+			CreatedAt: time.Now(),
+			CreatedBy: userID,
+		})
+	}
+
+	// user yang sendirinya dibatasi owner tidak boleh memberi owner di luar scope-nya,
+	// termasuk daftar kosong yang berarti semua owner
+	if _, restricted := helpers.OwnerCodesFromDB(c.DB); restricted && len(owners) == 0 {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only users with access to all owners can grant access to all owners"})
+	}
+	for _, owner := range owners {
+		if !helpers.IsOwnerAllowed(c.DB, owner.OwnerCode) {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": helpers.ErrOwnerNotAllowed.Error() + ": " + owner.OwnerCode})
+		}
+	}
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserOwner{}).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if len(owners) > 0 {
+		if err := tx.Create(&owners).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	helpers.InvalidateOwnerCache()
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "User owners updated successfully",
+		"data":    owners,
+	})
+}
@@ -0,0 +1,507 @@
+package controllers
+
+import (
+	"errors"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"fiber-app/types"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type WaveController struct {
+	DB *gorm.DB
+}
+
+func (c *WaveController) findWave(db *gorm.DB, waveNo string) (models.Wave, error) {
+	var wave models.Wave
+	if err := db.Preload("Outbounds").Where("wave_no = ?", waveNo).First(&wave).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return wave, fiber.NewError(fiber.StatusNotFound, "Wave "+waveNo+" not found")
+		}
+		return wave, err
+	}
+	return wave, nil
+}
+
+// GetCandidates menampilkan outbound open yang bisa dimasukkan ke wave sesuai kriteria di query string
+func (c *WaveController) GetCandidates(ctx *fiber.Ctx) error {
+	var filter repositories.WaveFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	candidates, err := repositories.NewWaveRepository(c.DB).GetCandidates(filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": candidates})
+}
+
+func (c *WaveController) CreateWave(ctx *fiber.Ctx) error {
+	var payload struct {
+		repositories.WaveFilter
+		OutboundIDs []int  `json:"outbound_ids"`
+		Remarks     string `json:"remarks"`
+	}
+	if err := ctx.BodyParser(&payload); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "message": "Invalid payload", "error": err.Error()})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	repo := repositories.NewWaveRepository(tx)
+	candidates, err := repo.GetCandidates(payload.WaveFilter)
+	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// kalau outbound dipilih manual, ambil yang memenuhi kriteria saja
+	if len(payload.OutboundIDs) > 0 {
+		selected := map[int]bool{}
+		for _, id := range payload.OutboundIDs {
+			selected[id] = true
+		}
+		var filtered []repositories.WaveCandidate
+		for _, candidate := range candidates {
+			if selected[candidate.ID] {
+				filtered = append(filtered, candidate)
+			}
+		}
+		if len(filtered) != len(selected) {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Some outbounds are not open, do not match the criteria or already in another wave"})
+		}
+		candidates = filtered
+	}
+
+	if len(candidates) == 0 {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No outbound matches the wave criteria"})
+	}
+
+	whsCode, ownerCode := candidates[0].WhsCode, candidates[0].OwnerCode
+	for _, candidate := range candidates {
+		if candidate.WhsCode != whsCode {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "All outbounds in a wave must be in the same warehouse"})
+		}
+		if candidate.OwnerCode != ownerCode {
+			ownerCode = ""
+		}
+	}
+
+	waveNo, err := repo.GenerateWaveNo(ownerCode, whsCode)
+	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "message": "Failed to generate wave no", "error": err.Error()})
+	}
+
+	wave := models.Wave{
+		WaveNo:          waveNo,
+		WhsCode:         whsCode,
+		OwnerCode:       ownerCode,
+		PlanPickupDate:  payload.PlanPickupDate,
+		TransporterCode: payload.TransporterCode,
+		CustCity:        payload.CustCity,
+		OrderNo:         payload.OrderNo,
+		Status:          "open",
+		Remarks:         payload.Remarks,
+		CreatedBy:       userID,
+		UpdatedBy:       userID,
+	}
+	if err := tx.Create(&wave).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	for i, candidate := range candidates {
+		wave.Outbounds = append(wave.Outbounds, models.WaveOutbound{
+			WaveID:     wave.ID,
+			WaveNo:     wave.WaveNo,
+			OutboundID: types.SnowflakeID(candidate.ID),
+			OutboundNo: candidate.OutboundNo,
+			SortSlot:   i + 1,
+			CreatedBy:  userID,
+		})
+	}
+	if err := tx.Create(&wave.Outbounds).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "message": "Wave " + wave.WaveNo + " created successfully", "data": wave})
+}
+
+func (c *WaveController) GetAllWaves(ctx *fiber.Ctx) error {
+	list, err := repositories.NewWaveRepository(c.DB).GetWaveList()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": list})
+}
+
+func (c *WaveController) GetWaveByNo(ctx *fiber.Ctx) error {
+	wave, err := c.findWave(c.DB, ctx.Params("wave_no"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": wave})
+}
+
+// ReleaseWave mengalokasikan semua outbound di wave dalam satu transaksi.
+// Detail diurutkan per item supaya item yang sama dialokasikan berurutan dari lokasi yang sama.
+func (c *WaveController) ReleaseWave(ctx *fiber.Ctx) error {
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	wave, err := c.findWave(tx, ctx.Params("wave_no"))
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+
+	if wave.Status != "open" {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Wave " + wave.WaveNo + " is " + wave.Status})
+	}
+
+	// status dipindah duluan dengan syarat masih open, cancel atau release lain yang bersamaan
+	// tidak bisa ikut jalan
+	result := tx.Model(&models.Wave{}).Where("id = ? AND status = ?", wave.ID, "open").Updates(map[string]interface{}{
+		"status":      "released",
+		"released_at": time.Now(),
+		"released_by": userID,
+		"updated_by":  userID,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Wave " + wave.WaveNo + " is no longer open"})
+	}
+
+	var outboundIDs []types.SnowflakeID
+	for _, wo := range wave.Outbounds {
+		outboundIDs = append(outboundIDs, wo.OutboundID)
+	}
+
+	var openCount int64
+	if err := tx.Model(&models.OutboundHeader{}).Where("id IN ? AND status = ?", outboundIDs, "open").Count(&openCount).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if int(openCount) != len(outboundIDs) {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Some outbounds in the wave are no longer open"})
+	}
+
+	var outboundDetails []models.OutboundDetail
+	if err := tx.Where("outbound_id IN ?", outboundIDs).Find(&outboundDetails).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sort.SliceStable(outboundDetails, func(i, j int) bool {
+		if outboundDetails[i].ItemCode != outboundDetails[j].ItemCode {
+			return outboundDetails[i].ItemCode < outboundDetails[j].ItemCode
+		}
+		return outboundDetails[i].OutboundNo < outboundDetails[j].OutboundNo
+	})
+
+	var itemCodes []string
+	allocationRepo := repositories.NewAllocationRepository(tx)
+	for _, outboundDetail := range outboundDetails {
+		if err := allocationRepo.AllocateDetail(outboundDetail, wave.ID, userID); err != nil {
+			tx.Rollback()
+			var businessErr *repositories.BusinessError
+			if errors.As(err, &businessErr) {
+				response := fiber.Map{"error": outboundDetail.OutboundNo + ": " + err.Error()}
+				if err := generateReplenishment(c.DB, []string{outboundDetail.ItemCode}, wave.WaveNo, userID); err != nil {
+					response["warning"] = "Replenishment not generated: " + err.Error()
+				}
+				return ctx.Status(fiber.StatusBadRequest).JSON(response)
+			}
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		itemCodes = append(itemCodes, outboundDetail.ItemCode)
+	}
+
+	if err := tx.Model(&models.OutboundHeader{}).Where("id IN ?", outboundIDs).Updates(map[string]interface{}{
+		"status":     "picking",
+		"updated_by": userID,
+	}).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// satu task pick untuk seluruh wave, picker mengikuti pick list gabungan
+	if _, err := repositories.NewTaskRepository(tx).CreateTask(models.WarehouseTask{
+		TaskType:  repositories.TaskPick,
+		RefNo:     wave.WaveNo,
+		WhsCode:   wave.WhsCode,
+		OwnerCode: wave.OwnerCode,
+		CreatedBy: userID,
+	}); err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	response := fiber.Map{"success": true, "message": "Wave " + wave.WaveNo + " released successfully"}
+	if err := generateReplenishment(c.DB, itemCodes, wave.WaveNo, userID); err != nil {
+		response["warning"] = "Replenishment not generated: " + err.Error()
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func (c *WaveController) GetPickList(ctx *fiber.Ctx) error {
+	wave, err := c.findWave(c.DB, ctx.Params("wave_no"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	items, err := repositories.NewWaveRepository(c.DB).GetWavePickList(wave.ID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": fiber.Map{"wave": wave, "items": items}})
+}
+
+func (c *WaveController) GetSortProgress(ctx *fiber.Ctx) error {
+	wave, err := c.findWave(c.DB, ctx.Params("wave_no"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	items, err := repositories.NewWaveRepository(c.DB).GetSortProgress(wave.ID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "data": items})
+}
+
+// SortItem adalah put-to-order: barang hasil picking wave discan lalu diarahkan ke slot outbound
+// yang masih membutuhkan. Hasil sort dicatat sebagai outbound barcode sehingga outbound bisa
+// lanjut picking complete dan packing seperti biasa.
+func (c *WaveController) SortItem(ctx *fiber.Ctx) error {
+	var payload struct {
+		OutboundNo string `json:"outbound_no"`
+		Barcode    string `json:"barcode"`
+		SerialNo   string `json:"serial_no"`
+		Qty        int    `json:"qty"`
+	}
+	if err := ctx.BodyParser(&payload); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if payload.Barcode == "" || payload.Qty < 1 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Barcode and qty are required"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := c.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	wave, err := c.findWave(tx, ctx.Params("wave_no"))
+	if err != nil {
+		tx.Rollback()
+		return errorResponse(ctx, err)
+	}
+	if wave.Status != "released" {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Wave " + wave.WaveNo + " is " + wave.Status})
+	}
+
+	var product models.Product
+	if err := tx.Where("barcode = ?", payload.Barcode).First(&product).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found", "message": "Product not found"})
+	}
+
+	serialNumber := product.Barcode
+	if product.HasSerial == "Y" {
+		if payload.SerialNo == "" || payload.Qty != 1 {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Serial item must be sorted one serial at a time"})
+		}
+		serialNumber = payload.SerialNo
+	}
+
+	progress, err := repositories.NewWaveRepository(tx).GetSortProgress(wave.ID)
+	if err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// pilih outbound tujuan: yang diminta, atau slot pertama yang masih kurang
+	var target *repositories.WaveSortItem
+	for i := range progress {
+		item := &progress[i]
+		if item.Barcode != payload.Barcode || item.QtyPick-item.QtySorted < payload.Qty {
+			continue
+		}
+		if payload.OutboundNo != "" && item.OutboundNo != payload.OutboundNo {
+			continue
+		}
+		target = item
+		break
+	}
+	if target == nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No outbound in wave needs this item for the given quantity"})
+	}
+
+	if product.HasSerial == "Y" {
+		var count int64
+		if err := tx.Model(&models.OutboundBarcode{}).Where("outbound_id = ? AND barcode = ? AND serial_number = ?", target.OutboundID, payload.Barcode, serialNumber).Count(&count).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		if count > 0 {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Item already scanned"})
+		}
+
+		serialRepo := repositories.NewSerialRepository(tx)
+		if err := serialRepo.ValidatePick(product.ItemCode, serialNumber); err != nil {
+			tx.Rollback()
+			return ctx.Status(repositories.ErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var outbound models.OutboundHeader
+		if err := tx.Where("id = ?", target.OutboundID).First(&outbound).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := serialRepo.Pick(outbound, product, serialNumber, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	var picking models.OutboundPicking
+	if err := tx.Where("wave_id = ? AND outbound_id = ? AND barcode = ?", wave.ID, target.OutboundID, payload.Barcode).First(&picking).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	outboundBarcode := models.OutboundBarcode{
+		OutboundId:       types.SnowflakeID(target.OutboundID),
+		OutboundNo:       target.OutboundNo,
+		OutboundDetailId: picking.OutboundDetailId,
+		ItemID:           int(product.ID),
+		ItemCode:         product.ItemCode,
+		Barcode:          payload.Barcode,
+		SerialNumber:     serialNumber,
+		Quantity:         payload.Qty,
+		Status:           "pending",
+		CreatedBy:        userID,
+	}
+	if err := tx.Create(&outboundBarcode).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	target.QtySorted += payload.Qty
+
+	sorted := true
+	for _, item := range progress {
+		if item.QtySorted < item.QtyPick {
+			sorted = false
+			break
+		}
+	}
+	if sorted {
+		if err := tx.Model(&models.Wave{}).Where("id = ?", wave.ID).Updates(map[string]interface{}{
+			"status":     "sorted",
+			"sorted_at":  time.Now(),
+			"updated_by": userID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := repositories.NewTaskRepository(tx).CloseTask(repositories.TaskPick, wave.WaveNo, userID); err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Put item to slot " + target.OutboundNo,
+		"data": fiber.Map{
+			"outbound_no": target.OutboundNo,
+			"sort_slot":   target.SortSlot,
+			"qty_pick":    target.QtyPick,
+			"qty_sorted":  target.QtySorted,
+			"wave_sorted": sorted,
+		},
+	})
+}
+
+// CancelWave hanya untuk wave yang belum di-release, outbound kembali bisa dipicking sendiri
+func (c *WaveController) CancelWave(ctx *fiber.Ctx) error {
+	wave, err := c.findWave(c.DB, ctx.Params("wave_no"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	if wave.Status != "open" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Only open wave can be cancelled"})
+	}
+
+	// syarat masih open supaya tidak membatalkan wave yang sedang di-release
+	result := c.DB.Model(&models.Wave{}).Where("id = ? AND status = ?", wave.ID, "open").Updates(map[string]interface{}{
+		"status":     "cancel",
+		"updated_by": int(ctx.Locals("userID").(float64)),
+	})
+	if result.Error != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected != 1 {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Wave " + wave.WaveNo + " is no longer open"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Wave " + wave.WaveNo + " cancelled successfully"})
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fiber-app/config"
+	"fiber-app/controllers/helpers"
 	"fiber-app/migration"
 	"fiber-app/models"
 	"fmt"
@@ -166,6 +167,11 @@ func GetDBConnection(dbName string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// filter owner otomatis untuk user yang di-assign ke owner tertentu
+	if err := helpers.RegisterOwnerScope(db); err != nil {
+		return nil, err
+	}
+
 	// Simpan ke pool
 	dbPool[dbName] = db
 	return db, nil
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fiber-app/controllers/helpers"
 	"fmt"
 	"reflect"
 
@@ -8,39 +9,54 @@ import (
 	"gorm.io/gorm"
 )
 
-func InjectDBMiddleware(controller interface{}) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		dbName, ok := c.Locals("unit").(string)
+// dbLocalKey key ctx.Locals untuk koneksi database milik request
+const dbLocalKey = "db"
 
-		fmt.Println("Environment: ", dbName)
+// InjectDBMiddleware menyimpan koneksi database unit (sudah dibatasi owner user) di ctx.Locals,
+// controller diisi per request lewat Handle
+func InjectDBMiddleware(c *fiber.Ctx) error {
+	dbName, ok := c.Locals("unit").(string)
 
-		if !ok || dbName == "" {
-			return fiber.NewError(fiber.StatusInternalServerError, "database name not found in context")
-		}
+	fmt.Println("Environment: ", dbName)
 
-		db, err := GetDBConnection(dbName)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "error connecting to database")
-		}
+	if !ok || dbName == "" {
+		return fiber.NewError(fiber.StatusInternalServerError, "database name not found in context")
+	}
 
-		// Inject ke field DB di controller
-		val := reflect.ValueOf(controller)
-		if val.Kind() != reflect.Ptr || val.IsNil() {
-			return fiber.NewError(fiber.StatusInternalServerError, "controller must be a non-nil pointer")
+	db, err := GetDBConnection(dbName)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "error connecting to database")
+	}
+
+	// batasi query ke owner yang di-assign ke user (diisi AuthMiddleware)
+	if codes, ok := c.Locals("ownerCodes").([]string); ok {
+		db = helpers.WithOwnerScope(db, codes)
+	}
+
+	c.Locals(dbLocalKey, db)
+	return c.Next()
+}
+
+// Handle membuat controller baru tiap request dengan field DB dari InjectDBMiddleware.
+// Controller tidak dipakai bersama antar request, jadi request paralel dari unit / owner
+// berbeda tidak saling menimpa koneksi. Contoh: database.Handle((*controllers.HoldController).GetHolds)
+func Handle[T any](handler func(*T, *fiber.Ctx) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		db, ok := c.Locals(dbLocalKey).(*gorm.DB)
+		if !ok || db == nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "database not found in context")
 		}
 
-		elem := val.Elem()
-		dbField := elem.FieldByName("DB")
+		controller := new(T)
+		dbField := reflect.ValueOf(controller).Elem().FieldByName("DB")
 		if !dbField.IsValid() || !dbField.CanSet() {
 			return fiber.NewError(fiber.StatusInternalServerError, "DB field not found or cannot be set in controller")
 		}
-
 		if dbField.Type() != reflect.TypeOf((*gorm.DB)(nil)) {
 			return fiber.NewError(fiber.StatusInternalServerError, "DB field has wrong type")
 		}
-
 		dbField.Set(reflect.ValueOf(db))
 
-		return c.Next()
+		return handler(controller, c)
 	}
 }
@@ -4,6 +4,7 @@ package database
 import (
 	"errors"
 	"fiber-app/config"
+	"fiber-app/controllers/helpers"
 	"fiber-app/controllers/idgen"
 	"fiber-app/models"
 	"fiber-app/types"
@@ -17,6 +18,9 @@ func RunSeeders(db *gorm.DB) {
 	SeedUoms(db)
 	// SeedWarehouse(db)
 	SeedUserMaster(db)
+	SeedPermissions(db)
+	SeedPasswordPolicy(db)
+	SeedTokenSetting(db)
 	SeedCategory(db)
 	SeedDivision(db)
 }
@@ -173,6 +177,13 @@ func SeedUserMaster(db *gorm.DB) {
 		var existing models.User
 		err := db.Where("email = ?", user.Email).First(&existing).Error
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			hashed, err := helpers.HashPassword(user.Password)
+			if err != nil {
+				log.Println("Gagal hash password user:", user.Username, err)
+				continue
+			}
+			user.Password = hashed
+
 			if err := db.Create(&user).Error; err != nil {
 				log.Println("Gagal insert user:", user.Username, err)
 			} else {
@@ -182,6 +193,79 @@ func SeedUserMaster(db *gorm.DB) {
 	}
 }
 
+// SeedPermissions membuat permission dari permission map di routes, role ADMIN selalu dapat semua permission
+func SeedPermissions(db *gorm.DB) {
+	var permissions []models.Permission
+	for _, name := range helpers.AllPermissionNames() {
+		var permission models.Permission
+		err := db.Where("name = ?", name).First(&permission).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			permission = models.Permission{Name: name, Description: "Route permission " + name}
+			if err := db.Create(&permission).Error; err != nil {
+				log.Println("Gagal insert permission:", name, err)
+				continue
+			}
+			log.Println("Insert permission:", name)
+		} else if err != nil {
+			log.Println("Gagal cek permission:", name, err)
+			continue
+		}
+		permissions = append(permissions, permission)
+	}
+
+	var role models.Role
+	if err := db.Where("name = ?", "ADMIN").First(&role).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		role = models.Role{Name: "ADMIN", Description: "Full access"}
+		if err := db.Create(&role).Error; err != nil {
+			log.Println("Gagal insert role ADMIN:", err)
+			return
+		}
+	} else if err != nil {
+		log.Println("Gagal cek role ADMIN:", err)
+		return
+	}
+
+	if len(permissions) > 0 {
+		if err := db.Model(&role).Association("Permissions").Append(permissions); err != nil {
+			log.Println("Gagal assign permission ke role ADMIN:", err)
+		}
+	}
+
+	// user admin bawaan seeder dapat role ADMIN supaya tidak terkunci
+	var admin models.User
+	if err := db.Where("username = ?", "admin").First(&admin).Error; err == nil {
+		if err := db.Model(&admin).Association("Roles").Append(&role); err != nil {
+			log.Println("Gagal assign role ADMIN ke user admin:", err)
+		}
+	}
+}
+
+func SeedPasswordPolicy(db *gorm.DB) {
+	var count int64
+	db.Model(&models.PasswordPolicy{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	policy := helpers.DefaultPasswordPolicy
+	if err := db.Create(&policy).Error; err != nil {
+		log.Println("Gagal insert password policy:", err)
+	}
+}
+
+func SeedTokenSetting(db *gorm.DB) {
+	var count int64
+	db.Model(&models.TokenSetting{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	setting := helpers.DefaultTokenSetting
+	if err := db.Create(&setting).Error; err != nil {
+		log.Println("Gagal insert token setting:", err)
+	}
+}
+
 func getMenuIDByName(db *gorm.DB, name string) *uint {
 	var parent models.Menu
 	err := db.Where("name = ?", name).First(&parent).Error
@@ -3,11 +3,13 @@ package main
 import (
 	"encoding/json"
 	"fiber-app/config"
+	"fiber-app/controllers/helpers"
 	"fiber-app/controllers/idgen"
 	"fiber-app/database"
 	"fiber-app/middleware"
 	"fiber-app/migration"
 	"fiber-app/routes"
+	"fiber-app/services"
 	"fiber-app/wms/master/owner"
 	"fmt"
 	"log"
@@ -31,6 +33,13 @@ type AccessLog struct {
 	UserID    int           `json:"user_id,omitempty"`
 }
 
+var configurationPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"POST /create-db":     "configuration.manage",
+	"POST /get-all-table": "configuration.manage",
+	"GET /get-all-bu":     "configuration.manage",
+	"POST /db-migrate":    "configuration.manage",
+})
+
 // channel buat log
 var logChan = make(chan AccessLog, 100)
 
@@ -125,6 +134,10 @@ func main() {
 	database.RunSeeders(unitDB)
 	owner.SeedOwner(unitDB)
 
+	// snapshot stock harian
+	services.StartDailySnapshotJob(mainDB)
+	services.StartHoldExpiryJob(mainDB)
+
 	// checkUnprocessedFiles(db)
 
 	// Initialize controllers
@@ -168,10 +181,23 @@ func main() {
 	routes.SetupMobilePackingRoutes(app)
 	routes.SetupShippingRoutes(app)
 	routes.SetupMobileInventoryRoutes(app)
+	routes.SetupMobileTaskRoutes(app)
 	owner.SetupOwnerRoutes(app)
 	routes.SetupStockTakeRoutes(app)
 	routes.SetupLocationRoutes(app)
 	routes.SetupVasRoutes(app)
+	routes.SetupReturnRoutes(app)
+	routes.SetupWaveRoutes(app)
+	routes.SetupReplenishmentRoutes(app)
+	routes.SetupTaskRoutes(app)
+	routes.SetupProductivityRoutes(app)
+	routes.SetupDockRoutes(app)
+	routes.SetupKitRoutes(app)
+	routes.SetupNumberingRoutes(app)
+	routes.SetupSerialRoutes(app)
+	routes.SetupHoldRoutes(app)
+	routes.SetupAdjustmentRoutes(app)
+	routes.SetupCycleCountRoutes(app)
 
 	// routes.SetupRfInboundRoutes(app, RfInboundController)
 	// routes.SetupOutboundRoutes(app, db)
@@ -185,10 +211,11 @@ func main() {
 	// api.Get(config.MAIN_ROUTES+"/logout", authController.Logout)
 	// api.Get(config.MAIN_ROUTES+"/isLoggedIn", middleware.AuthMiddleware, authController.IsLoggedIn)
 	api := app.Group(config.MAIN_ROUTES)
-	api.Post("/configurations/create-db", middleware.AuthMiddleware, database.CreateDatabase)
-	api.Post("/configurations/get-all-table", middleware.AuthMiddleware, database.GetAllTables())
-	api.Get("/configurations/get-all-bu", database.GetAllBusinessUnit)
-	api.Post("/configurations/db-migrate", database.MigrateDB)
+	configurationGuard := middleware.RequirePermission(config.MAIN_ROUTES+"/configurations", configurationPermissions)
+	api.Post("/configurations/create-db", middleware.AuthMiddleware, configurationGuard, database.CreateDatabase)
+	api.Post("/configurations/get-all-table", middleware.AuthMiddleware, configurationGuard, database.GetAllTables())
+	api.Get("/configurations/get-all-bu", middleware.AuthMiddleware, configurationGuard, database.GetAllBusinessUnit)
+	api.Post("/configurations/db-migrate", middleware.AuthMiddleware, configurationGuard, database.MigrateDB)
 
 	port := config.APP_PORT
 	fmt.Println("🚀 Server berjalan di port " + port)
@@ -2,8 +2,8 @@ package middleware
 
 import (
 	"fiber-app/config"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
-	"fiber-app/models"
 	"fmt"
 	"strings"
 	"time"
@@ -111,6 +111,15 @@ func AuthMiddleware(ctx *fiber.Ctx) error {
 			})
 		}
 
+		// Token tanpa session id berasal dari versi lama (exp 100 tahun), wajib login ulang
+		sessionID, _ := claims["sid"].(string)
+		jti, _ := claims["jti"].(string)
+		if sessionID == "" {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Unauthorized: Session expired, please login again",
+			})
+		}
+
 		fmt.Println("Token masih valid")
 		fmt.Println("Waktu kedaluwarsa:", time.Unix(expTime, 0))
 		fmt.Println("Sisa waktu:", time.Until(time.Unix(expTime, 0)))
@@ -120,9 +129,10 @@ func AuthMiddleware(ctx *fiber.Ctx) error {
 		ctx.Locals("userID", userID)
 		ctx.Locals("unit", unit)
 		ctx.Locals("userData", claims)
+		ctx.Locals("sessionID", sessionID)
 
 		// 🔑 Panggil GetDBConnection di sini
-		_, err := database.GetDBConnection(unit)
+		db, err := database.GetDBConnection(unit)
 		if err != nil {
 			return ctx.Status(500).JSON(fiber.Map{"message": "Failed to connect database"})
 		} else {
@@ -130,6 +140,23 @@ func AuthMiddleware(ctx *fiber.Ctx) error {
 		}
 		database.PrintActiveDBConnections()
 
+		// Cek revocation list (logout, logout all device, refresh token reuse)
+		revoked, err := helpers.IsTokenRevoked(db, jti, sessionID)
+		if err != nil {
+			return ctx.Status(500).JSON(fiber.Map{"message": "Failed to check token"})
+		}
+		if revoked {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Unauthorized: Token has been revoked",
+			})
+		}
+
+		ownerCodes, err := helpers.GetUserOwnerCodes(db, unit, uint(userID))
+		if err != nil {
+			return ctx.Status(500).JSON(fiber.Map{"message": "Failed to load user owners"})
+		}
+		ctx.Locals("ownerCodes", ownerCodes)
+
 		return ctx.Next() // Lanjut ke handler berikutnya
 	} else {
 		fmt.Println("Token tidak valid")
@@ -139,52 +166,10 @@ func AuthMiddleware(ctx *fiber.Ctx) error {
 	}
 }
 
+// CheckPermission menjaga satu route dengan satu permission, permission user diambil dari cache
 func (a *AuthMiddlewareStruct) CheckPermission(requiredPermission string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Ambil userID dari context (hasil dari AuthMiddleware)
-		userID, ok := c.Locals("userID").(float64) // Sesuaikan tipe datanya
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"message": "Unauthorized: Invalid user ID",
-			})
-		}
-
-		// Query untuk mendapatkan daftar permissions berdasarkan userID
-		var user models.User
-		if err := a.DB.Debug().Preload("Roles.Permissions").First(&user, uint(userID)).Error; err != nil {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: User not found",
-			})
-		}
-
-		// Simpan semua permissions yang dimiliki user
-		userPermissions := map[string]bool{}
-		for _, role := range user.Roles {
-			for _, perm := range role.Permissions {
-				userPermissions[perm.Name] = true
-			}
-		}
-
-		// fmt.Println("userPermissions: ", userPermissions)
-		// fmt.Println("requiredPermission: ", requiredPermission)
-		// fmt.Printf("userPermissions: %+v\n", userPermissions)
-		// fmt.Printf("requiredPermission: '%s' (length: %d)\n", requiredPermission, len(requiredPermission))
-
-		// // Cek apakah key benar-benar ada dalam map
-		// if val, exists := userPermissions[requiredPermission]; exists {
-		// 	fmt.Println("Permission found:", val)
-		// } else {
-		// 	fmt.Println("Permission NOT found!")
-		// }
-
-		// Cek apakah permission yang dibutuhkan ada dalam daftar userPermissions
-		if _, exists := userPermissions[requiredPermission]; !exists {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"message": "Forbidden: You do not have permission",
-			})
-		}
-
-		return c.Next() // Lanjut ke handler berikutnya
+		return checkUserPermission(c, requiredPermission)
 	}
 }
 
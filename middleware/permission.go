@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission menjaga semua route di bawah prefix sesuai permission map.
+// Harus dipasang setelah AuthMiddleware. Route di bawah prefix yang tidak ada di map ditolak.
+func RequirePermission(prefix string, permissions helpers.PermissionMap) fiber.Handler {
+	prefix = strings.TrimRight(prefix, "/")
+	helpers.BindPermissionGroup(prefix, permissions)
+
+	return func(ctx *fiber.Ctx) error {
+		path := ctx.Path()
+		// beberapa group memakai prefix yang sama (mis. /mobile), map lain yang menangani
+		if !helpers.PathHasPrefix(strings.TrimRight(path, "/"), prefix) {
+			return ctx.Next()
+		}
+
+		permission, ok := permissions.Resolve(ctx.Method(), strings.TrimPrefix(path, prefix))
+		if !ok {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Forbidden: No permission mapping for this endpoint",
+			})
+		}
+		if permission == "" {
+			return ctx.Next()
+		}
+
+		return checkUserPermission(ctx, permission)
+	}
+}
+
+func checkUserPermission(ctx *fiber.Ctx, permission string) error {
+	userID, ok := ctx.Locals("userID").(float64)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized: Invalid user ID",
+		})
+	}
+
+	unit, _ := ctx.Locals("unit").(string)
+	db, err := database.GetDBConnection(unit)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Failed to connect database"})
+	}
+
+	userPermissions, err := helpers.GetUserPermissions(db, unit, uint(userID))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	if !userPermissions[permission] {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message":    "Forbidden: You do not have permission",
+			"permission": permission,
+		})
+	}
+
+	return ctx.Next()
+}
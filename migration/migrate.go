@@ -15,6 +15,7 @@ func Migrate(db *gorm.DB) error {
 func MigrateBusinessUnit(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.User{},
+		&models.UserOwner{},
 		&models.UserDashboard{},
 		&models.Role{},
 		&models.Permission{},
@@ -55,6 +56,7 @@ func MigrateBusinessUnit(db *gorm.DB) error {
 		&models.Division{},
 		&models.Location{},
 		&models.Owner{},
+		&models.OwnerSetting{},
 
 		&models.MainVas{},
 		&models.VasRate{},
@@ -62,5 +64,39 @@ func MigrateBusinessUnit(db *gorm.DB) error {
 		&models.VasDetail{},
 		&models.OutboundVas{},
 		&models.LoginLog{},
+		&models.InventoryMovement{},
+		&models.StockSnapshot{},
+		&models.PasswordPolicy{},
+		&models.PasswordHistory{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.TokenSetting{},
+		&models.ReturnHeader{},
+		&models.ReturnDetail{},
+		&models.Wave{},
+		&models.WaveOutbound{},
+		&models.ReplenishmentSetting{},
+		&models.ReplenishmentTask{},
+		&models.WarehouseTask{},
+		&models.Shift{},
+		&models.DockDoor{},
+		&models.DockAppointment{},
+		&models.BomHeader{},
+		&models.BomComponent{},
+		&models.KitOrder{},
+		&models.KitOrderDetail{},
+		&models.KitOrderVas{},
+		&models.NumberingFormat{},
+		&models.NumberingSequence{},
+		&models.SerialNumber{},
+		&models.SerialEvent{},
+		&models.HoldReason{},
+		&models.InventoryHold{},
+		&models.InventoryHoldDetail{},
+		&models.AdjustmentReason{},
+		&models.AdjustmentHeader{},
+		&models.AdjustmentDetail{},
+		&models.CycleCountClass{},
+		&models.ItemClassification{},
 	)
 }
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdjustmentReason master alasan adjustment stock
+type AdjustmentReason struct {
+	gorm.Model
+	ReasonCode  string `json:"reason_code" gorm:"size:30;unique"`
+	Description string `json:"description"`
+	IsActive    bool   `json:"is_active" gorm:"default:true"`
+	CreatedBy   int
+	UpdatedBy   int
+	DeletedBy   int
+}
+
+// AdjustmentHeader dokumen koreksi stock, manual atau dari selisih stock take.
+// Nilai dihitung dari unit cost product, di atas limit owner harus diapprove sebelum diposting.
+type AdjustmentHeader struct {
+	gorm.Model
+	AdjustmentNo  string     `json:"adjustment_no" gorm:"size:50;unique"`
+	OwnerCode     string     `json:"owner_code"`
+	WhsCode       string     `json:"whs_code"`
+	SourceType    string     `json:"source_type"`
+	SourceRef     string     `json:"source_ref"`
+	ReasonCode    string     `json:"reason_code"`
+	Remarks       string     `json:"remarks"`
+	Status        string     `json:"status"`
+	QtyIn         int        `json:"qty_in"`
+	QtyOut        int        `json:"qty_out"`
+	TotalValue    float64    `json:"total_value"`
+	AbsValue      float64    `json:"abs_value"`
+	ApprovalRole  string     `json:"approval_role"`
+	ApprovedBy    int        `json:"approved_by"`
+	ApprovedAt    *time.Time `json:"approved_at"`
+	RejectedBy    int        `json:"rejected_by"`
+	RejectedAt    *time.Time `json:"rejected_at"`
+	RejectRemarks string     `json:"reject_remarks"`
+	PostedBy      int        `json:"posted_by"`
+	PostedAt      *time.Time `json:"posted_at"`
+	CreatedBy     int
+	UpdatedBy     int
+	DeletedBy     int
+
+	Details []AdjustmentDetail `gorm:"foreignKey:AdjustmentID;references:ID;constraint:OnDelete:CASCADE" json:"details"`
+}
+
+// AdjustmentDetail satu baris koreksi, qty_adjust positif menambah dan negatif mengurangi stock.
+// InventoryID 0 berarti stock baru (ditemukan saat count), inventory dibuat saat posting.
+type AdjustmentDetail struct {
+	gorm.Model
+	AdjustmentID uint    `json:"adjustment_id" gorm:"index"`
+	InventoryID  int     `json:"inventory_id"`
+	ItemID       int     `json:"item_id"`
+	ItemCode     string  `json:"item_code"`
+	Barcode      string  `json:"barcode"`
+	Location     string  `json:"location"`
+	Pallet       string  `json:"pallet"`
+	LotNo        string  `json:"lot_no"`
+	QaStatus     string  `json:"qa_status"`
+	QtySystem    int     `json:"qty_system"`
+	QtyAdjust    int     `json:"qty_adjust"`
+	UnitCost     float64 `json:"unit_cost"`
+	Value        float64 `json:"value"`
+	CreatedBy    int
+	UpdatedBy    int
+}
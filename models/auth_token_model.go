@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken disimpan dalam bentuk hash, token asli hanya ada di cookie client
+type RefreshToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	SessionID  string     `json:"session_id" gorm:"size:100;index"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy uint       `json:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// RevokedToken adalah revocation list yang dicek AuthMiddleware.
+// Jti diisi untuk satu access token, SessionID untuk semua token dalam satu session.
+type RevokedToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Jti       string    `json:"jti" gorm:"size:64;index"`
+	SessionID string    `json:"session_id" gorm:"size:100;index"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Reason    string    `json:"reason" gorm:"size:50"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy int       `json:"created_by"`
+}
+
+type TokenSetting struct {
+	gorm.Model
+	AccessTokenTTL  int `json:"access_token_ttl" gorm:"default:15"`  // menit
+	RefreshTokenTTL int `json:"refresh_token_ttl" gorm:"default:24"` // jam
+	CreatedBy       int
+	UpdatedBy       int
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CycleCountClass target frekuensi count per class ABC. Item masuk class pertama yang
+// cumulative_pct-nya belum terlampaui saat diurutkan dari score terbesar.
+type CycleCountClass struct {
+	gorm.Model
+	ClassCode     string  `json:"class_code" gorm:"size:10;unique"`
+	CumulativePct float64 `json:"cumulative_pct"`
+	FrequencyDays int     `json:"frequency_days"`
+	IsActive      bool    `json:"is_active" gorm:"default:true"`
+	CreatedBy     int
+	UpdatedBy     int
+	DeletedBy     int
+}
+
+// ItemClassification hasil klasifikasi terakhir per item dan tanggal terakhir item selesai dicount
+type ItemClassification struct {
+	gorm.Model
+	ItemID       int        `json:"item_id" gorm:"uniqueIndex"`
+	ItemCode     string     `json:"item_code"`
+	OwnerCode    string     `json:"owner_code"`
+	ClassCode    string     `json:"class_code" gorm:"size:10;index"`
+	Basis        string     `json:"basis"`
+	Score        float64    `json:"score"`
+	ClassifiedAt time.Time  `json:"classified_at"`
+	LastCountAt  *time.Time `json:"last_count_at"`
+	CreatedBy    int
+	UpdatedBy    int
+}
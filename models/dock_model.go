@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DockDoor master pintu dock per gudang, DoorType inbound / outbound / both
+type DockDoor struct {
+	gorm.Model
+	DoorCode  string `json:"door_code" gorm:"size:20;unique"`
+	Name      string `json:"name"`
+	WhsCode   string `json:"whs_code"`
+	DoorType  string `json:"door_type" gorm:"size:20;default:'both'"`
+	IsActive  bool   `json:"is_active" gorm:"default:true"`
+	CreatedBy int
+	UpdatedBy int
+	DeletedBy int
+}
+
+// DockAppointment booking slot pintu dock untuk truk inbound atau outbound.
+// Status: booked -> arrived (check-in) -> docked -> departed (check-out), atau cancel.
+type DockAppointment struct {
+	gorm.Model
+	AppointmentNo   string     `json:"appointment_no" gorm:"size:50;unique"`
+	DoorID          uint       `json:"door_id" gorm:"index"`
+	DoorCode        string     `json:"door_code"`
+	WhsCode         string     `json:"whs_code"`
+	OwnerCode       string     `json:"owner_code"`
+	Direction       string     `json:"direction" gorm:"size:20"`
+	RefNo           string     `json:"ref_no" gorm:"size:50;index"`
+	TransporterCode string     `json:"transporter_code"`
+	TruckNo         string     `json:"truck_no"`
+	Driver          string     `json:"driver"`
+	StartTime       time.Time  `json:"start_time" gorm:"index"`
+	EndTime         time.Time  `json:"end_time"`
+	Status          string     `json:"status" gorm:"size:20;default:'booked'"`
+	ArrivedAt       *time.Time `json:"arrived_at"`
+	DockedAt        *time.Time `json:"docked_at"`
+	DepartedAt      *time.Time `json:"departed_at"`
+	Remarks         string     `json:"remarks"`
+	CreatedBy       int
+	UpdatedBy       int
+	DeletedBy       int
+}
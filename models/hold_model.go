@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HoldReason master alasan hold / quarantine
+type HoldReason struct {
+	gorm.Model
+	ReasonCode       string `json:"reason_code" gorm:"size:30;unique"`
+	Description      string `json:"description"`
+	RequiresApproval bool   `json:"requires_approval"`
+	IsActive         bool   `json:"is_active" gorm:"default:true"`
+	CreatedBy        int
+	UpdatedBy        int
+	DeletedBy        int
+}
+
+// InventoryHold blokir stock berdasarkan kriteria, field kriteria yang kosong berarti semua.
+// Qty available stock yang cocok dipindah ke qty_suspend selama hold aktif.
+type InventoryHold struct {
+	gorm.Model
+	HoldNo         string     `json:"hold_no" gorm:"size:50;unique"`
+	HoldType       string     `json:"hold_type"`
+	OwnerCode      string     `json:"owner_code"`
+	WhsCode        string     `json:"whs_code"`
+	ItemCode       string     `json:"item_code"`
+	LotNo          string     `json:"lot_no"`
+	Location       string     `json:"location"`
+	InboundNo      string     `json:"inbound_no"`
+	ReasonCode     string     `json:"reason_code"`
+	Remarks        string     `json:"remarks"`
+	Status         string     `json:"status" gorm:"default:'active'"`
+	QtyHeld        int        `json:"qty_held"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	ApprovedBy     int        `json:"approved_by"`
+	ApprovedAt     *time.Time `json:"approved_at"`
+	ReleasedBy     int        `json:"released_by"`
+	ReleasedAt     *time.Time `json:"released_at"`
+	ReleaseRemarks string     `json:"release_remarks"`
+	CreatedBy      int
+	UpdatedBy      int
+	DeletedBy      int
+
+	Details []InventoryHoldDetail `gorm:"foreignKey:HoldID;references:ID;constraint:OnDelete:CASCADE" json:"details"`
+}
+
+// InventoryHoldDetail qty per inventory yang disuspend oleh hold
+type InventoryHoldDetail struct {
+	gorm.Model
+	HoldID      uint   `json:"hold_id" gorm:"index"`
+	InventoryID int    `json:"inventory_id" gorm:"index"`
+	ItemCode    string `json:"item_code"`
+	Location    string `json:"location"`
+	LotNo       string `json:"lot_no"`
+	QtyHeld     int    `json:"qty_held"`
+	QtyReleased int    `json:"qty_released"`
+	CreatedBy   int
+	UpdatedBy   int
+}
@@ -74,6 +74,9 @@ type InboundDetail struct {
 	Location     string            `json:"location" required:"required"`
 	Status       string            `json:"status" gorm:"default:'draft'"`
 	RecDate      string            `json:"rec_date" required:"required"`
+	LotNo        string            `json:"lot_no"`
+	MfgDate      string            `json:"mfg_date"`
+	ExpDate      string            `json:"exp_date"`
 	Uom          string            `json:"uom" required:"required"`
 	IsSerial     string            `json:"is_serial"`
 	SN           string            `json:"sn"`
@@ -119,6 +122,9 @@ type InboundBarcode struct {
 	Pallet          string         `json:"pallet"`
 	Location        string         `json:"location"`
 	Quantity        int            `json:"quantity"`
+	LotNo           string         `json:"lot_no"`
+	MfgDate         string         `json:"mfg_date"`
+	ExpDate         string         `json:"exp_date"`
 	WhsCode         string         `json:"whs_code"`
 	OwnerCode       string         `json:"owner_code"`
 	DivisionCode    string         `json:"division_code"`
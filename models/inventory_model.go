@@ -15,6 +15,9 @@ type Inventory struct {
 	InboundID       types.SnowflakeID `json:"inbound_id" gorm:"default:null"`
 	InboundDetailId int               `json:"inbound_detail_id"`
 	RecDate         string            `json:"rec_date"`
+	LotNo           string            `json:"lot_no"`
+	MfgDate         string            `json:"mfg_date"`
+	ExpDate         string            `json:"exp_date"`
 	Pallet          string            `json:"pallet"`
 	Location        string            `json:"location"`
 	ItemId          int               `json:"item_id"`
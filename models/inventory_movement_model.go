@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fiber-app/controllers/idgen"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InventoryMovement adalah ledger perubahan qty inventory, hanya insert (tidak pernah di-update / delete)
+type InventoryMovement struct {
+	ID                 int64     `json:"ID" gorm:"primaryKey"`
+	InventoryID        int       `json:"inventory_id" gorm:"index"`
+	MovementType       string    `json:"movement_type" gorm:"index"`
+	RefNo              string    `json:"ref_no" gorm:"index"`
+	OwnerCode          string    `json:"owner_code"`
+	WhsCode            string    `json:"whs_code"`
+	ItemID             int       `json:"item_id" gorm:"index"`
+	ItemCode           string    `json:"item_code"`
+	Location           string    `json:"location" gorm:"index"`
+	Pallet             string    `json:"pallet"`
+	QaStatus           string    `json:"qa_status"`
+	LotNo              string    `json:"lot_no"`
+	QtyOnhandBefore    int       `json:"qty_onhand_before"`
+	QtyOnhandAfter     int       `json:"qty_onhand_after"`
+	QtyAvailableBefore int       `json:"qty_available_before"`
+	QtyAvailableAfter  int       `json:"qty_available_after"`
+	QtyAllocatedBefore int       `json:"qty_allocated_before"`
+	QtyAllocatedAfter  int       `json:"qty_allocated_after"`
+	QtyShippedBefore   int       `json:"qty_shipped_before"`
+	QtyShippedAfter    int       `json:"qty_shipped_after"`
+	QtyChange          int       `json:"qty_change"`
+	CreatedAt          time.Time `json:"created_at" gorm:"index"`
+	CreatedBy          int       `json:"created_by"`
+}
+
+func (m *InventoryMovement) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = idgen.GenerateID()
+	return
+}
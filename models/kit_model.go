@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BomHeader bill of materials untuk satu item kit, komponen dihitung per 1 unit kit
+type BomHeader struct {
+	gorm.Model
+	KitItemID        int    `json:"kit_item_id" gorm:"index"`
+	KitItemCode      string `json:"kit_item_code" gorm:"size:100;uniqueIndex"`
+	OwnerCode        string `json:"owner_code"`
+	Description      string `json:"description"`
+	AssemblyVasID    int    `json:"assembly_vas_id"`
+	DisassemblyVasID int    `json:"disassembly_vas_id"`
+	IsActive         bool   `json:"is_active" gorm:"default:true"`
+	CreatedBy        int
+	UpdatedBy        int
+	DeletedBy        int
+
+	Components []BomComponent `gorm:"foreignKey:BomID;references:ID;constraint:OnDelete:CASCADE" json:"components"`
+}
+
+type BomComponent struct {
+	gorm.Model
+	BomID     uint   `json:"bom_id" gorm:"index"`
+	ItemID    int    `json:"item_id"`
+	ItemCode  string `json:"item_code"`
+	Quantity  int    `json:"quantity"`
+	CreatedBy int
+	UpdatedBy int
+	DeletedBy int
+}
+
+// KitOrder work order assembly (komponen jadi kit) atau disassembly (kit jadi komponen)
+type KitOrder struct {
+	gorm.Model
+	KitOrderNo     string     `json:"kit_order_no" gorm:"size:50;unique"`
+	OrderType      string     `json:"order_type"`
+	BomID          uint       `json:"bom_id" gorm:"index"`
+	KitItemID      int        `json:"kit_item_id"`
+	KitItemCode    string     `json:"kit_item_code"`
+	OwnerCode      string     `json:"owner_code"`
+	WhsCode        string     `json:"whs_code"`
+	Quantity       int        `json:"quantity"`
+	Location       string     `json:"location"`
+	SourceLocation string     `json:"source_location"`
+	Status         string     `json:"status" gorm:"default:'open'"`
+	Remarks        string     `json:"remarks"`
+	CompleteAt     *time.Time `json:"complete_at"`
+	CompleteBy     int
+	CancelAt       *time.Time `json:"cancel_at"`
+	CancelBy       int
+	CreatedBy      int
+	UpdatedBy      int
+	DeletedBy      int
+
+	Details []KitOrderDetail `gorm:"foreignKey:KitOrderID;references:ID;constraint:OnDelete:CASCADE" json:"details"`
+	Vas     []KitOrderVas    `gorm:"foreignKey:KitOrderID;references:ID;constraint:OnDelete:CASCADE" json:"vas"`
+}
+
+// KitOrderDetail inventory yang dipakai (consume) dan dihasilkan (produce) saat order di-complete
+type KitOrderDetail struct {
+	gorm.Model
+	KitOrderID  uint   `json:"kit_order_id" gorm:"index"`
+	KitOrderNo  string `json:"kit_order_no" gorm:"size:50"`
+	Direction   string `json:"direction"`
+	InventoryID int    `json:"inventory_id"`
+	ItemID      int    `json:"item_id"`
+	ItemCode    string `json:"item_code"`
+	Location    string `json:"location"`
+	Pallet      string `json:"pallet"`
+	LotNo       string `json:"lot_no"`
+	Quantity    int    `json:"quantity"`
+	CreatedBy   int
+	UpdatedBy   int
+	DeletedBy   int
+}
+
+// KitOrderVas baris billing VAS dari kit order, sama seperti OutboundVas untuk outbound
+type KitOrderVas struct {
+	gorm.Model
+	KitOrderID   uint    `json:"kit_order_id" gorm:"index"`
+	KitOrderNo   string  `json:"kit_order_no"`
+	OwnerCode    string  `json:"owner_code"`
+	MainVasID    int     `json:"main_vas_id"`
+	MainVasName  string  `json:"main_vas_name"`
+	DefaultPrice float64 `json:"default_price"`
+	Qty          int     `json:"qty"`
+	TotalPrice   float64 `json:"total_price"`
+	CreatedBy    int
+	UpdatedBy    int
+	DeletedBy    int
+}
@@ -12,7 +12,17 @@ type Location struct {
 	Level        string `json:"level"`
 	Bin          string `json:"bin"`
 	Area         string `json:"area"`
+	WhsCode      string `json:"whs_code"`                             // kosong = bisa dipakai semua warehouse
+	OwnerCode    string `json:"owner_code"`                           // kosong = lokasi bersama
+	PickSequence int    `json:"pick_sequence" gorm:"default:0;index"` // urutan jalur picking, 0 = belum diatur
 	IsActive     bool   `json:"is_active" gorm:"default:true"`
+	// aturan putaway, kapasitas 0 = tidak dibatasi
+	LocationType string  `json:"location_type" gorm:"size:20;default:'rack'"`
+	MaxPallet    int     `json:"max_pallet" gorm:"default:0"`
+	MaxCbm       float64 `json:"max_cbm" gorm:"default:0"`
+	MaxWeight    float64 `json:"max_weight" gorm:"default:0"`
+	SingleItem   bool    `json:"single_item" gorm:"default:false"` // tidak boleh campur item
+	SingleLot    bool    `json:"single_lot" gorm:"default:false"`  // tidak boleh campur lot per item
 	CreatedBy    int
 	UpdatedBy    int
 	DeletedBy    int
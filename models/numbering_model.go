@@ -0,0 +1,28 @@
+package models
+
+import "gorm.io/gorm"
+
+// NumberingFormat template nomor dokumen per jenis dokumen, owner dan warehouse.
+// Owner / warehouse kosong berarti berlaku untuk semua.
+type NumberingFormat struct {
+	gorm.Model
+	DocType     string `json:"doc_type" gorm:"size:30;uniqueIndex:idx_numbering_format"`
+	OwnerCode   string `json:"owner_code" gorm:"size:50;uniqueIndex:idx_numbering_format"`
+	WhsCode     string `json:"whs_code" gorm:"size:50;uniqueIndex:idx_numbering_format"`
+	Prefix      string `json:"prefix" gorm:"size:30"`       // boleh berisi {OWNER}, {WHS}, {REF}
+	DatePattern string `json:"date_pattern" gorm:"size:10"` // YYYYMMDD, YYMMDD, YYMM, YY, kosong
+	Padding     int    `json:"padding" gorm:"default:4"`
+	ResetPeriod string `json:"reset_period" gorm:"size:10;default:'daily'"` // daily, monthly, yearly, never
+	IsActive    bool   `json:"is_active" gorm:"default:true"`
+	CreatedBy   int
+	UpdatedBy   int
+	DeletedBy   int
+}
+
+// NumberingSequence nomor terakhir per prefix dan periode, dikunci saat increment
+type NumberingSequence struct {
+	gorm.Model
+	DocType string `json:"doc_type" gorm:"size:30"`
+	SeqKey  string `json:"seq_key" gorm:"size:150;uniqueIndex"`
+	LastNo  int    `json:"last_no"`
+}
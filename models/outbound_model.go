@@ -63,6 +63,7 @@ type OutboundDetail struct {
 	Status       string            `json:"status" gorm:"default:'draft'"`
 	Uom          string            `json:"uom" required:"required"`
 	QaStatus     string            `json:"qa_status" gorm:"default:'A'"`
+	LotNo        string            `json:"lot_no"`
 	SN           string            `json:"sn"`
 	SNCheck      string            `json:"sn_check" gorm:"default:'N'"`
 	VasID        int               `json:"vas_id"`
@@ -150,7 +151,11 @@ type OutboundPicking struct {
 	Location         string            `json:"location"`
 	Quantity         int               `json:"quantity"`
 	QaStatus         string            `json:"qa_status"`
+	LotNo            string            `json:"lot_no"`
+	ExpDate          string            `json:"exp_date"`
+	AllocationRule   string            `json:"allocation_rule"`
 	Reason           string            `json:"reason"`
+	WaveID           uint              `json:"wave_id" gorm:"default:0;index"`
 	CreatedBy        int
 	UpdatedBy        int
 	DeletedBy        int
@@ -6,10 +6,33 @@ import (
 
 type Owner struct {
 	gorm.Model
-	Code        string `json:"code" gorm:"unique"`
-	Name        string `json:"name" gorm:"unique"`
-	Description string `json:"description"`
-	CreatedBy   int
-	UpdatedBy   int
-	DeletedBy   int
+	Code           string `json:"code" gorm:"unique"`
+	Name           string `json:"name" gorm:"unique"`
+	Description    string `json:"description"`
+	AllocationRule string `json:"allocation_rule"`
+	CreatedBy      int
+	UpdatedBy      int
+	DeletedBy      int
+}
+
+// OwnerSetting berisi default per owner yang dipakai transaksi inbound/outbound.
+// Field kosong diisi dari helpers.DefaultOwnerSetting.
+type OwnerSetting struct {
+	gorm.Model
+	OwnerCode       string `json:"owner_code" gorm:"size:50;uniqueIndex"`
+	AllocationRule  string `json:"allocation_rule" gorm:"size:30"`
+	QaStatus        string `json:"qa_status" gorm:"size:10"`
+	DivisionCode    string `json:"division_code" gorm:"size:50"`
+	SerialMandatory bool   `json:"serial_mandatory"`
+	DefaultUom      string `json:"default_uom" gorm:"size:20"`
+	InboundPrefix   string `json:"inbound_prefix" gorm:"size:10"`
+	OutboundPrefix  string `json:"outbound_prefix" gorm:"size:10"`
+	BillingCurrency string `json:"billing_currency" gorm:"size:3"`
+	// adjustment dengan nilai absolut di atas limit harus diapprove user dengan role ini.
+	// Tanpa role, approval oleh user lain hanya boleh kalau AdjustmentAnyApprover aktif.
+	AdjustmentApprovalRole  string  `json:"adjustment_approval_role" gorm:"size:50"`
+	AdjustmentApprovalLimit float64 `json:"adjustment_approval_limit" gorm:"default:0"`
+	AdjustmentAnyApprover   bool    `json:"adjustment_any_approver" gorm:"default:false"`
+	CreatedBy               int
+	UpdatedBy               int
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordPolicy disimpan satu baris per business unit
+type PasswordPolicy struct {
+	gorm.Model
+	MinLength     int  `json:"min_length" gorm:"default:8"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	HistoryCount  int  `json:"history_count" gorm:"default:3"`
+	CreatedBy     int
+	UpdatedBy     int
+}
+
+type PasswordHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Password  string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy int       `json:"created_by"`
+}
@@ -31,6 +31,8 @@ type Product struct {
 	HasSerial      string            `json:"has_serial" gorm:"default:'N'"`
 	ManualBook     string            `json:"manual_book" gorm:"default:'N'"`
 	HasAdaptor     string            `json:"has_adaptor" gorm:"default:'N'"`
+	AllocationRule string            `json:"allocation_rule"`
+	UnitCost       float64           `json:"unit_cost" gorm:"default:0"` // nilai per uom untuk adjustment
 	Remarks        string            `json:"remarks"`
 	CreatedBy      int
 	UpdatedBy      int
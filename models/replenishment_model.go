@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReplenishmentSetting batas min/max stok item di lokasi pick face
+type ReplenishmentSetting struct {
+	gorm.Model
+	ItemID      int    `json:"item_id"`
+	ItemCode    string `json:"item_code" gorm:"size:100;uniqueIndex:idx_replenish_item_location"`
+	Location    string `json:"location" gorm:"size:50;uniqueIndex:idx_replenish_item_location"`
+	OwnerCode   string `json:"owner_code"`
+	WhsCode     string `json:"whs_code"`
+	MinQty      int    `json:"min_qty"`
+	MaxQty      int    `json:"max_qty"`
+	ReplenishTo int    `json:"replenish_to"` // target qty setelah replenish, 0 = sampai max
+	IsActive    bool   `json:"is_active" gorm:"default:true"`
+	CreatedBy   int
+	UpdatedBy   int
+	DeletedBy   int
+}
+
+// ReplenishmentTask perintah pindah stok dari lokasi reserve ke pick face
+type ReplenishmentTask struct {
+	gorm.Model
+	TaskNo          string `json:"task_no" gorm:"size:50;unique"`
+	SettingID       uint   `json:"setting_id" gorm:"index"`
+	ItemID          int    `json:"item_id"`
+	ItemCode        string `json:"item_code"`
+	Barcode         string `json:"barcode"`
+	OwnerCode       string `json:"owner_code"`
+	WhsCode         string `json:"whs_code"`
+	FromInventoryID int    `json:"from_inventory_id"`
+	FromLocation    string `json:"from_location"`
+	FromPallet      string `json:"from_pallet"`
+	ToLocation      string `json:"to_location"`
+	Quantity        int    `json:"quantity"`
+	QtyMoved        int    `json:"qty_moved"`
+	Source          string `json:"source"` // manual / allocation
+	RefNo           string `json:"ref_no"`
+	Status          string `json:"status" gorm:"default:'open'"`
+	CreatedBy       int
+	UpdatedBy       int
+	DeletedBy       int
+	CompleteAt      *time.Time `json:"complete_at"`
+	CompleteBy      int
+}
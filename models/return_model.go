@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fiber-app/types"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReturnHeader adalah dokumen RMA untuk barang yang dikembalikan customer dari outbound yang sudah dikirim
+type ReturnHeader struct {
+	gorm.Model
+	ReturnNo     string            `json:"return_no" gorm:"size:50;unique"`
+	ReturnDate   string            `json:"return_date"`
+	OutboundID   types.SnowflakeID `json:"outbound_id" gorm:"index"`
+	OutboundNo   string            `json:"outbound_no" gorm:"size:50;index"`
+	ShipmentID   string            `json:"shipment_id"`
+	CustomerCode string            `json:"customer_code"`
+	OwnerCode    string            `json:"owner_code"`
+	WhsCode      string            `json:"whs_code"`
+	Reason       string            `json:"reason"`
+	Status       string            `json:"status" gorm:"default:'open'"`
+	Remarks      string            `json:"remarks"`
+	CreatedBy    int
+	UpdatedBy    int
+	DeletedBy    int
+	CompleteAt   *time.Time `json:"complete_at"`
+	CompleteBy   int
+	CancelAt     *time.Time `json:"cancel_at"`
+	CancelBy     int
+
+	Details []ReturnDetail `gorm:"foreignKey:ReturnID;references:ID;constraint:OnDelete:CASCADE" json:"details"`
+}
+
+type ReturnDetail struct {
+	gorm.Model
+	ReturnID          uint   `json:"return_id" gorm:"index"`
+	ReturnNo          string `json:"return_no" gorm:"size:50"`
+	OutboundDetailID  int    `json:"outbound_detail_id" gorm:"index"`
+	OutboundBarcodeID int    `json:"outbound_barcode_id"`
+	ItemID            int    `json:"item_id"`
+	ItemCode          string `json:"item_code"`
+	Barcode           string `json:"barcode"`
+	SerialNumber      string `json:"serial_number"`
+	Quantity          int    `json:"quantity"`
+	Uom               string `json:"uom"`
+	Reason            string `json:"reason"`
+	Disposition       string `json:"disposition"`
+	QaStatus          string `json:"qa_status"`
+	Location          string `json:"location"`
+	OwnerCode         string `json:"owner_code"`
+	WhsCode           string `json:"whs_code"`
+	InventoryID       int    `json:"inventory_id"`
+	CreatedBy         int
+	UpdatedBy         int
+	DeletedBy         int
+}
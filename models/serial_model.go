@@ -0,0 +1,39 @@
+package models
+
+import "gorm.io/gorm"
+
+// SerialNumber registry serial per item: status dan posisi terakhir, riwayat lengkap ada di SerialEvent
+type SerialNumber struct {
+	gorm.Model
+	ItemID       int    `json:"item_id"`
+	ItemCode     string `json:"item_code" gorm:"size:100;uniqueIndex:idx_serial_item"`
+	SerialNo     string `json:"serial_no" gorm:"size:100;uniqueIndex:idx_serial_item;index"`
+	OwnerCode    string `json:"owner_code"`
+	WhsCode      string `json:"whs_code"`
+	Status       string `json:"status"`
+	Location     string `json:"location"`
+	Pallet       string `json:"pallet"`
+	InventoryID  int    `json:"inventory_id"`
+	InboundNo    string `json:"inbound_no"`
+	OutboundNo   string `json:"outbound_no"`
+	CustomerCode string `json:"customer_code"`
+	CreatedBy    int
+	UpdatedBy    int
+	DeletedBy    int
+}
+
+type SerialEvent struct {
+	gorm.Model
+	SerialID     uint   `json:"serial_id" gorm:"index"`
+	ItemCode     string `json:"item_code"`
+	SerialNo     string `json:"serial_no" gorm:"size:100;index"`
+	EventType    string `json:"event_type"`
+	Status       string `json:"status"`
+	OwnerCode    string `json:"owner_code"`
+	WhsCode      string `json:"whs_code"`
+	Location     string `json:"location"`
+	Pallet       string `json:"pallet"`
+	RefNo        string `json:"ref_no"`
+	CustomerCode string `json:"customer_code"`
+	CreatedBy    int
+}
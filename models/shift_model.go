@@ -0,0 +1,17 @@
+package models
+
+import "gorm.io/gorm"
+
+// Shift definisi jam kerja untuk laporan produktivitas, format jam HH:MM.
+// Shift yang EndTime lebih kecil dari StartTime melewati tengah malam.
+type Shift struct {
+	gorm.Model
+	ShiftCode string `json:"shift_code" gorm:"size:20;unique"`
+	Name      string `json:"name"`
+	StartTime string `json:"start_time" gorm:"size:5"`
+	EndTime   string `json:"end_time" gorm:"size:5"`
+	IsActive  bool   `json:"is_active" gorm:"default:true"`
+	CreatedBy int
+	UpdatedBy int
+	DeletedBy int
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// StockSnapshot menyimpan saldo inventory per hari (diambil oleh job harian)
+type StockSnapshot struct {
+	ID           uint      `json:"ID" gorm:"primaryKey"`
+	SnapshotDate string    `json:"snapshot_date" gorm:"index;size:10"`
+	TakenAt      time.Time `json:"taken_at" gorm:"index"`
+	OwnerCode    string    `json:"owner_code"`
+	WhsCode      string    `json:"whs_code"`
+	ItemID       int       `json:"item_id"`
+	ItemCode     string    `json:"item_code" gorm:"index"`
+	Location     string    `json:"location"`
+	QaStatus     string    `json:"qa_status"`
+	QtyOnhand    int       `json:"qty_onhand"`
+	QtyAvailable int       `json:"qty_available"`
+	QtyAllocated int       `json:"qty_allocated"`
+	CreatedAt    time.Time
+	CreatedBy    int
+}
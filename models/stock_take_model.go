@@ -6,12 +6,16 @@ import (
 
 type StockTake struct {
 	gorm.Model
-	Code      string          `json:"code" gorm:"unique"`
-	Status    string          `json:"status" gorm:"default:'open'"`
-	CreatedBy int             `json:"created_by"`
-	UpdatedBy int             `json:"updated_by"`
-	DeletedBy int             `json:"deleted_by"`
-	Items     []StockTakeItem `gorm:"foreignKey:StockTakeID;references:ID;constraint:OnDelete:CASCADE" json:"items"`
+	Code       string          `json:"code" gorm:"unique"`
+	Status     string          `json:"status" gorm:"default:'open'"`
+	CountType  string          `json:"count_type" gorm:"size:20;default:'full'"` // full atau cycle (dari planner)
+	WhsCode    string          `json:"whs_code"`
+	IsBlind    bool            `json:"is_blind"`
+	ParentCode string          `json:"parent_code"` // stock take asal untuk recount
+	CreatedBy  int             `json:"created_by"`
+	UpdatedBy  int             `json:"updated_by"`
+	DeletedBy  int             `json:"deleted_by"`
+	Items      []StockTakeItem `gorm:"foreignKey:StockTakeID;references:ID;constraint:OnDelete:CASCADE" json:"items"`
 }
 
 type StockTakeItem struct {
@@ -26,6 +30,7 @@ type StockTakeItem struct {
 	SystemQty    int
 	CountedQty   int
 	Difference   int
+	ClassCode    string
 	Notes        string
 	CreatedBy    int
 	UpdatedBy    int
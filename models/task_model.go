@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WarehouseTask antrian kerja operator (putaway, pick, replenish, count, move).
+// RefNo menunjuk dokumen sumber: inbound no, outbound/wave no, replenishment task no, stock take code.
+type WarehouseTask struct {
+	gorm.Model
+	TaskType       string     `json:"task_type" gorm:"size:20;index"`
+	RefNo          string     `json:"ref_no" gorm:"size:50;index"`
+	WhsCode        string     `json:"whs_code"`
+	OwnerCode      string     `json:"owner_code"`
+	Location       string     `json:"location"`
+	ToLocation     string     `json:"to_location"`
+	ItemCode       string     `json:"item_code"`
+	Quantity       int        `json:"quantity"`
+	Priority       int        `json:"priority" gorm:"default:0"`
+	AssignedUserID int        `json:"assigned_user_id" gorm:"default:0"`
+	AssignedRole   string     `json:"assigned_role"`
+	Status         string     `json:"status" gorm:"size:20;default:'open';index"`
+	ClaimedBy      int        `json:"claimed_by" gorm:"default:0"`
+	ClaimedAt      *time.Time `json:"claimed_at"`
+	StartedAt      *time.Time `json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+	CompletedBy    int        `json:"completed_by"`
+	Remarks        string     `json:"remarks"`
+	CreatedBy      int
+	UpdatedBy      int
+	DeletedBy      int
+}
@@ -16,11 +16,22 @@ type User struct {
 	BaseRoute   string       `json:"base_route"`
 	Roles       []Role       `gorm:"many2many:user_roles;"`
 	Permissions []Permission `gorm:"many2many:user_permissions;"`
+	Owners      []UserOwner  `json:"owners,omitempty" gorm:"foreignKey:UserID"`
 	CreatedBy   int
 	UpdatedBy   int
 	DeletedBy   int
 }
 
+// UserOwner membatasi data yang bisa diakses user ke owner tertentu.
+// User tanpa UserOwner sama sekali tidak dibatasi.
+type UserOwner struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_user_owner"`
+	OwnerCode string    `json:"owner_code" gorm:"size:50;uniqueIndex:idx_user_owner"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy int       `json:"created_by"`
+}
+
 type UserDashboard struct {
 	gorm.Model
 	Username  string `json:"username" gorm:"unique"`
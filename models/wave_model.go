@@ -0,0 +1,41 @@
+package models
+
+import (
+	"fiber-app/types"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Wave mengelompokkan beberapa outbound supaya dialokasikan dan dipicking sekaligus
+type Wave struct {
+	gorm.Model
+	WaveNo          string     `json:"wave_no" gorm:"size:50;unique"`
+	WhsCode         string     `json:"whs_code"`
+	OwnerCode       string     `json:"owner_code"`
+	PlanPickupDate  string     `json:"plan_pickup_date"`
+	TransporterCode string     `json:"transporter_code"`
+	CustCity        string     `json:"cust_city"`
+	OrderNo         string     `json:"order_no"`
+	Status          string     `json:"status" gorm:"default:'open'"`
+	Remarks         string     `json:"remarks"`
+	ReleasedAt      *time.Time `json:"released_at"`
+	ReleasedBy      int        `json:"released_by"`
+	SortedAt        *time.Time `json:"sorted_at"`
+	CreatedBy       int
+	UpdatedBy       int
+	DeletedBy       int
+
+	Outbounds []WaveOutbound `gorm:"foreignKey:WaveID;references:ID;constraint:OnDelete:CASCADE" json:"outbounds"`
+}
+
+// WaveOutbound adalah outbound di dalam wave, SortSlot adalah slot put-wall untuk proses sorting
+type WaveOutbound struct {
+	gorm.Model
+	WaveID     uint              `json:"wave_id" gorm:"index"`
+	WaveNo     string            `json:"wave_no" gorm:"size:50"`
+	OutboundID types.SnowflakeID `json:"outbound_id" gorm:"index"`
+	OutboundNo string            `json:"outbound_no"`
+	SortSlot   int               `json:"sort_slot"`
+	CreatedBy  int
+}
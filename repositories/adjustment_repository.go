@@ -0,0 +1,570 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type AdjustmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAdjustmentRepository(db *gorm.DB) *AdjustmentRepository {
+	return &AdjustmentRepository{db}
+}
+
+const (
+	AdjustmentManual    = "manual"
+	AdjustmentStockTake = "stock_take"
+
+	AdjustmentPending  = "pending"
+	AdjustmentPosted   = "posted"
+	AdjustmentRejected = "rejected"
+)
+
+// AdjustmentLine input satu baris adjustment. Tanpa inventory_id berarti stock baru,
+// wajib item, lokasi dan qty positif.
+type AdjustmentLine struct {
+	InventoryID int    `json:"inventory_id"`
+	ItemCode    string `json:"item_code"`
+	Barcode     string `json:"barcode"`
+	Location    string `json:"location"`
+	LotNo       string `json:"lot_no"`
+	QtyAdjust   int    `json:"qty_adjust"`
+}
+
+type AdjustmentList struct {
+	ID                uint       `json:"ID"`
+	AdjustmentNo      string     `json:"adjustment_no"`
+	OwnerCode         string     `json:"owner_code"`
+	WhsCode           string     `json:"whs_code"`
+	SourceType        string     `json:"source_type"`
+	SourceRef         string     `json:"source_ref"`
+	ReasonCode        string     `json:"reason_code"`
+	ReasonDescription string     `json:"reason_description"`
+	Status            string     `json:"status"`
+	QtyIn             int        `json:"qty_in"`
+	QtyOut            int        `json:"qty_out"`
+	TotalValue        float64    `json:"total_value"`
+	AbsValue          float64    `json:"abs_value"`
+	ApprovalRole      string     `json:"approval_role"`
+	CreatedAt         time.Time  `json:"created_at"`
+	PostedAt          *time.Time `json:"posted_at"`
+}
+
+func (r *AdjustmentRepository) GenerateAdjustmentNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocAdjustment, ownerCode, whsCode, "")
+}
+
+func (r *AdjustmentRepository) validateReason(reasonCode string) error {
+	var reason models.AdjustmentReason
+	if err := r.db.Where("reason_code = ?", reasonCode).First(&reason).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &BusinessError{Message: "Reason code " + reasonCode + " not found"}
+		}
+		return err
+	}
+	if !reason.IsActive {
+		return &BusinessError{Message: "Reason code " + reasonCode + " is inactive"}
+	}
+	return nil
+}
+
+// buildDetail melengkapi baris adjustment dari inventory atau product, nilai dari unit cost product
+func (r *AdjustmentRepository) buildDetail(header models.AdjustmentHeader, line AdjustmentLine) (models.AdjustmentDetail, error) {
+	var detail models.AdjustmentDetail
+	if line.QtyAdjust == 0 {
+		return detail, &BusinessError{Message: "Adjustment qty cannot be 0"}
+	}
+
+	var product models.Product
+	if line.InventoryID > 0 {
+		var inventory models.Inventory
+		if err := r.db.Where("id = ?", line.InventoryID).First(&inventory).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return detail, &BusinessError{Message: fmt.Sprintf("Inventory %d not found", line.InventoryID)}
+			}
+			return detail, err
+		}
+		if inventory.OwnerCode != header.OwnerCode || inventory.WhsCode != header.WhsCode {
+			return detail, &BusinessError{Message: fmt.Sprintf("Inventory %d is not in owner %s warehouse %s", line.InventoryID, header.OwnerCode, header.WhsCode)}
+		}
+		if err := checkReduce(inventory, line.QtyAdjust); err != nil {
+			return detail, err
+		}
+		if err := r.db.Where("id = ?", inventory.ItemId).First(&product).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return detail, err
+		}
+
+		detail = models.AdjustmentDetail{
+			InventoryID: int(inventory.ID),
+			ItemID:      inventory.ItemId,
+			ItemCode:    inventory.ItemCode,
+			Barcode:     inventory.Barcode,
+			Location:    inventory.Location,
+			Pallet:      inventory.Pallet,
+			LotNo:       inventory.LotNo,
+			QaStatus:    inventory.QaStatus,
+			QtySystem:   inventory.QtyOnhand,
+		}
+	} else {
+		if line.QtyAdjust < 0 {
+			return detail, &BusinessError{Message: "Negative adjustment needs an inventory"}
+		}
+		if line.Location == "" || (line.ItemCode == "" && line.Barcode == "") {
+			return detail, &BusinessError{Message: "Item and location are required for new stock"}
+		}
+
+		query := r.db.Where("owner_code = ?", header.OwnerCode)
+		if line.ItemCode != "" {
+			query = query.Where("item_code = ?", line.ItemCode)
+		} else {
+			query = query.Where("barcode = ?", line.Barcode)
+		}
+		if err := query.First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return detail, &BusinessError{Message: "Item " + line.ItemCode + line.Barcode + " not found for owner " + header.OwnerCode}
+			}
+			return detail, err
+		}
+		if _, err := NewLocationRepository(r.db).ValidateLocation(line.Location, header.WhsCode, header.OwnerCode); err != nil {
+			return detail, err
+		}
+
+		detail = models.AdjustmentDetail{
+			ItemID:   int(product.ID),
+			ItemCode: product.ItemCode,
+			Barcode:  product.Barcode,
+			Location: line.Location,
+			Pallet:   line.Location,
+			LotNo:    line.LotNo,
+			QaStatus: "A",
+		}
+	}
+
+	detail.QtyAdjust = line.QtyAdjust
+	detail.UnitCost = product.UnitCost
+	detail.Value = product.UnitCost * float64(line.QtyAdjust)
+	return detail, nil
+}
+
+// Create menyimpan adjustment. Nilai absolut di atas limit owner menunggu approval,
+// selain itu langsung diposting. Dipanggil di dalam transaction.
+func (r *AdjustmentRepository) Create(header *models.AdjustmentHeader, lines []AdjustmentLine, userID int) error {
+	if header.OwnerCode == "" || header.WhsCode == "" {
+		return &BusinessError{Message: "Owner and warehouse are required"}
+	}
+	if len(lines) == 0 {
+		return &BusinessError{Message: "Adjustment needs at least one line"}
+	}
+	if err := r.validateReason(header.ReasonCode); err != nil {
+		return err
+	}
+
+	header.Details = nil
+	header.QtyIn, header.QtyOut, header.TotalValue, header.AbsValue = 0, 0, 0, 0
+	for _, line := range lines {
+		detail, err := r.buildDetail(*header, line)
+		if err != nil {
+			return err
+		}
+		if detail.QtyAdjust > 0 {
+			header.QtyIn += detail.QtyAdjust
+		} else {
+			header.QtyOut -= detail.QtyAdjust
+		}
+		header.TotalValue += detail.Value
+		header.AbsValue += math.Abs(detail.Value)
+		detail.CreatedBy = userID
+		detail.UpdatedBy = userID
+		header.Details = append(header.Details, detail)
+	}
+
+	setting, err := helpers.GetOwnerSetting(r.db, header.OwnerCode)
+	if err != nil {
+		return err
+	}
+
+	header.AdjustmentNo, err = r.GenerateAdjustmentNo(header.OwnerCode, header.WhsCode)
+	if err != nil {
+		return err
+	}
+	if header.SourceType == "" {
+		header.SourceType = AdjustmentManual
+	}
+	header.Status = AdjustmentPending
+	if header.AbsValue > setting.AdjustmentApprovalLimit {
+		header.ApprovalRole = setting.AdjustmentApprovalRole
+	}
+	header.CreatedBy = userID
+	header.UpdatedBy = userID
+	if err := r.db.Create(header).Error; err != nil {
+		return err
+	}
+
+	detail := fmt.Sprintf("in %d, out %d, value %.2f", header.QtyIn, header.QtyOut, header.TotalValue)
+	if err := helpers.InsertTransactionHistory(r.db, header.AdjustmentNo, AdjustmentPending, "ADJUSTMENT", detail, userID); err != nil {
+		return err
+	}
+
+	if header.AbsValue > setting.AdjustmentApprovalLimit {
+		return nil
+	}
+	return r.post(header, userID)
+}
+
+func (r *AdjustmentRepository) userHasRole(userID int, role string) (bool, error) {
+	var count int64
+	err := r.db.Raw(`SELECT COUNT(*) FROM user_roles ur
+		INNER JOIN roles r ON r.id = ur.role_id AND r.deleted_at IS NULL
+		WHERE ur.user_id = ? AND r.name = ?`, userID, role).Scan(&count).Error
+	return count > 0, err
+}
+
+func (r *AdjustmentRepository) checkApprover(header models.AdjustmentHeader, userID int) error {
+	if header.Status != AdjustmentPending {
+		return &BusinessError{Message: fmt.Sprintf("Adjustment %s is %s, not pending", header.AdjustmentNo, header.Status)}
+	}
+	if header.CreatedBy == userID {
+		return &BusinessError{Message: "Adjustment cannot be approved by its creator"}
+	}
+	if header.ApprovalRole == "" {
+		setting, err := helpers.GetOwnerSetting(r.db, header.OwnerCode)
+		if err != nil {
+			return err
+		}
+		if !setting.AdjustmentAnyApprover {
+			return &BusinessError{Message: "Owner " + header.OwnerCode + " has no adjustment approval role"}
+		}
+		return nil
+	}
+
+	ok, err := r.userHasRole(userID, header.ApprovalRole)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &BusinessError{Message: "Adjustment " + header.AdjustmentNo + " needs approval by role " + header.ApprovalRole}
+	}
+	return nil
+}
+
+// Approve lalu posting ke inventory. Dipanggil di dalam transaction.
+func (r *AdjustmentRepository) Approve(header *models.AdjustmentHeader, userID int) error {
+	if err := r.checkApprover(*header, userID); err != nil {
+		return err
+	}
+
+	// keluar dari pending dengan syarat status belum berubah, approve / reject bersamaan
+	// hanya satu yang lolos dan adjustment tidak diposting dua kali
+	now := time.Now()
+	result := r.db.Model(&models.AdjustmentHeader{}).Where("id = ? AND status = ?", header.ID, AdjustmentPending).Updates(map[string]interface{}{
+		"status":      AdjustmentPosted,
+		"approved_by": userID,
+		"approved_at": now,
+		"updated_by":  userID,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return &BusinessError{Message: "Adjustment " + header.AdjustmentNo + " is no longer pending", Status: fiber.StatusConflict}
+	}
+	header.ApprovedBy = userID
+	header.ApprovedAt = &now
+	if err := helpers.InsertTransactionHistory(r.db, header.AdjustmentNo, "approved", "ADJUSTMENT", "", userID); err != nil {
+		return err
+	}
+	return r.post(header, userID)
+}
+
+func (r *AdjustmentRepository) Reject(header *models.AdjustmentHeader, remarks string, userID int) error {
+	if err := r.checkApprover(*header, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := r.db.Model(&models.AdjustmentHeader{}).Where("id = ? AND status = ?", header.ID, AdjustmentPending).Updates(map[string]interface{}{
+		"status":         AdjustmentRejected,
+		"rejected_by":    userID,
+		"rejected_at":    now,
+		"reject_remarks": remarks,
+		"updated_by":     userID,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return &BusinessError{Message: "Adjustment " + header.AdjustmentNo + " is no longer pending", Status: fiber.StatusConflict}
+	}
+	header.Status = AdjustmentRejected
+	return helpers.InsertTransactionHistory(r.db, header.AdjustmentNo, AdjustmentRejected, "ADJUSTMENT", remarks, userID)
+}
+
+// freeQty stock yang tidak dialokasikan dan tidak di-hold
+func freeQty(inventory models.Inventory) int {
+	return inventory.QtyOnhand - inventory.QtyAllocated - inventory.QtySuspend
+}
+
+// checkReduce adjustment negatif hanya boleh mengambil qty bebas, stock yang sudah
+// dialokasikan atau di-hold harus dilepas dulu
+func checkReduce(inventory models.Inventory, qtyAdjust int) error {
+	if qtyAdjust >= 0 || freeQty(inventory)+qtyAdjust >= 0 {
+		return nil
+	}
+	return &BusinessError{Message: fmt.Sprintf("Cannot reduce %d from %s at %s, free qty %d", -qtyAdjust, inventory.ItemCode, inventory.Location, freeQty(inventory))}
+}
+
+// post menerapkan selisih ke qty on hand dan available. Qty bebas dicek ulang saat posting
+// karena stock bisa sudah bergerak sejak adjustment dibuat.
+func (r *AdjustmentRepository) post(header *models.AdjustmentHeader, userID int) error {
+	var details []models.AdjustmentDetail
+	if err := r.db.Where("adjustment_id = ?", header.ID).Order("id").Find(&details).Error; err != nil {
+		return err
+	}
+
+	for _, detail := range details {
+		if detail.InventoryID == 0 {
+			if err := r.createInventory(*header, detail, userID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var inventory models.Inventory
+		if err := r.db.Where("id = ?", detail.InventoryID).First(&inventory).Error; err != nil {
+			return err
+		}
+
+		if err := checkReduce(inventory, detail.QtyAdjust); err != nil {
+			return err
+		}
+		query := r.db.Model(&models.Inventory{}).Where("id = ?", inventory.ID)
+		if detail.QtyAdjust < 0 {
+			query = query.Where("qty_onhand - qty_allocated - qty_suspend + ? >= 0", detail.QtyAdjust)
+		}
+		result := query.Updates(map[string]interface{}{
+			"qty_onhand":    gorm.Expr("qty_onhand + ?", detail.QtyAdjust),
+			"qty_available": gorm.Expr("qty_available + ?", detail.QtyAdjust),
+			"updated_by":    userID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &BusinessError{Message: fmt.Sprintf("Stock %s at %s changed while posting adjustment %s", inventory.ItemCode, inventory.Location, header.AdjustmentNo), Status: fiber.StatusConflict}
+		}
+
+		movementType := helpers.MovementAdjustIn
+		if detail.QtyAdjust < 0 {
+			movementType = helpers.MovementAdjustOut
+		}
+		if err := helpers.InsertInventoryMovement(r.db, inventory, movementType, header.AdjustmentNo, userID); err != nil {
+			return err
+		}
+		if detail.QtyAdjust < 0 {
+			if err := NewSerialRepository(r.db).Move(inventory, models.Inventory{}, -detail.QtyAdjust, header.AdjustmentNo, userID); err != nil {
+				return err
+			}
+		} else if err := NewHoldRepository(r.db).Apply(inventory, userID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	header.Status = AdjustmentPosted
+	header.PostedBy = userID
+	header.PostedAt = &now
+	if err := r.db.Model(&models.AdjustmentHeader{}).Where("id = ?", header.ID).Updates(map[string]interface{}{
+		"status":     AdjustmentPosted,
+		"posted_by":  userID,
+		"posted_at":  now,
+		"updated_by": userID,
+	}).Error; err != nil {
+		return err
+	}
+	return helpers.InsertTransactionHistory(r.db, header.AdjustmentNo, AdjustmentPosted, "ADJUSTMENT", header.SourceRef, userID)
+}
+
+// createInventory stock baru dari adjustment positif tanpa inventory, pallet = lokasi
+func (r *AdjustmentRepository) createInventory(header models.AdjustmentHeader, detail models.AdjustmentDetail, userID int) error {
+	var product models.Product
+	if err := r.db.Where("id = ?", detail.ItemID).First(&product).Error; err != nil {
+		return err
+	}
+
+	newInventory := models.Inventory{
+		OwnerCode:    header.OwnerCode,
+		WhsCode:      header.WhsCode,
+		RecDate:      time.Now().Format("2006-01-02"),
+		LotNo:        detail.LotNo,
+		Pallet:       detail.Pallet,
+		Location:     detail.Location,
+		ItemId:       detail.ItemID,
+		ItemCode:     detail.ItemCode,
+		Barcode:      detail.Barcode,
+		QaStatus:     detail.QaStatus,
+		Uom:          product.Uom,
+		QtyOrigin:    detail.QtyAdjust,
+		QtyOnhand:    detail.QtyAdjust,
+		QtyAvailable: detail.QtyAdjust,
+		Trans:        "adjustment",
+		CreatedBy:    userID,
+	}
+	if err := r.db.Create(&newInventory).Error; err != nil {
+		return err
+	}
+	if err := helpers.InsertInventoryMovement(r.db, models.Inventory{ID: newInventory.ID}, helpers.MovementAdjustIn, header.AdjustmentNo, userID); err != nil {
+		return err
+	}
+	if err := NewHoldRepository(r.db).Apply(newInventory, userID); err != nil {
+		return err
+	}
+
+	return r.db.Model(&models.AdjustmentDetail{}).Where("id = ?", detail.ID).Update("inventory_id", int(newInventory.ID)).Error
+}
+
+// foundLine stock yang discan tapi tidak ada di system, owner dari item dan warehouse dari lokasi
+func (r *AdjustmentRepository) foundLine(barcode, locationCode string, qty int) (string, string, AdjustmentLine, error) {
+	var product models.Product
+	if err := r.db.Where("barcode = ?", barcode).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", AdjustmentLine{}, &BusinessError{Message: "Barcode " + barcode + " counted at " + locationCode + " is not a known item"}
+		}
+		return "", "", AdjustmentLine{}, err
+	}
+	var location models.Location
+	if err := r.db.Where("location_code = ?", locationCode).First(&location).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", AdjustmentLine{}, &BusinessError{Message: "Location " + locationCode + " not found"}
+		}
+		return "", "", AdjustmentLine{}, err
+	}
+	if location.WhsCode == "" {
+		return "", "", AdjustmentLine{}, &BusinessError{Message: "Location " + locationCode + " has no warehouse, adjust " + barcode + " manually"}
+	}
+	return product.OwnerCode, location.WhsCode, AdjustmentLine{ItemCode: product.ItemCode, Location: locationCode, QtyAdjust: qty}, nil
+}
+
+// FromStockTake mengisi hasil count ke stock take item lalu membuat satu adjustment per owner dan
+// warehouse dari selisihnya. Barcode yang discan tapi tidak ada di system jadi stock baru.
+// Dipanggil di dalam transaction.
+func (r *AdjustmentRepository) FromStockTake(stockTake models.StockTake, reasonCode string, userID int) ([]models.AdjustmentHeader, error) {
+	if stockTake.Status == StockTakePosted || stockTake.Status == StockTakeRecount {
+		return nil, &BusinessError{Message: "Stock take " + stockTake.Code + " is already " + stockTake.Status}
+	}
+	if err := r.validateReason(reasonCode); err != nil {
+		return nil, err
+	}
+
+	stockTakeRepo := NewStockTakeRepository(r.db)
+	items, found, err := stockTakeRepo.ApplyCounts(stockTake, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		ownerCode, whsCode string
+		lines              []AdjustmentLine
+	}
+	groups := map[string]*group{}
+	order := []string{}
+	addLine := func(ownerCode, whsCode string, line AdjustmentLine) {
+		key := ownerCode + "|" + whsCode
+		if groups[key] == nil {
+			groups[key] = &group{ownerCode: ownerCode, whsCode: whsCode}
+			order = append(order, key)
+		}
+		groups[key].lines = append(groups[key].lines, line)
+	}
+
+	for _, item := range items {
+		if item.Difference == 0 {
+			continue
+		}
+
+		// item recount untuk stock yang ditemukan di count sebelumnya
+		if item.InventoryID == 0 {
+			if item.Difference < 0 {
+				continue
+			}
+			found = append(found, StockTakeFound{Location: item.Location, Barcode: item.Barcode, Qty: item.Difference})
+			continue
+		}
+
+		var inventory models.Inventory
+		if err := r.db.Where("id = ?", item.InventoryID).First(&inventory).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &BusinessError{Message: fmt.Sprintf("Inventory %d of stock take item %s at %s not found", item.InventoryID, item.Barcode, item.Location)}
+			}
+			return nil, err
+		}
+		addLine(inventory.OwnerCode, inventory.WhsCode, AdjustmentLine{InventoryID: int(inventory.ID), QtyAdjust: item.Difference})
+	}
+
+	for _, f := range found {
+		ownerCode, whsCode, line, err := r.foundLine(f.Barcode, f.Location, f.Qty)
+		if err != nil {
+			return nil, err
+		}
+		addLine(ownerCode, whsCode, line)
+	}
+
+	var headers []models.AdjustmentHeader
+	for _, key := range order {
+		header := models.AdjustmentHeader{
+			OwnerCode:  groups[key].ownerCode,
+			WhsCode:    groups[key].whsCode,
+			SourceType: AdjustmentStockTake,
+			SourceRef:  stockTake.Code,
+			ReasonCode: reasonCode,
+			Remarks:    "Stock take " + stockTake.Code,
+		}
+		if err := r.Create(&header, groups[key].lines, userID); err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+
+	if err := stockTakeRepo.Finish(stockTake, StockTakePosted, userID); err != nil {
+		return nil, err
+	}
+	if stockTake.CountType == CountCycle {
+		if err := NewCycleCountRepository(r.db).MarkCounted(items, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := helpers.InsertTransactionHistory(r.db, stockTake.Code, StockTakePosted, "STOCK_TAKE", fmt.Sprintf("%d adjustments", len(headers)), userID); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+func (r *AdjustmentRepository) GetAdjustmentList(status string) ([]AdjustmentList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+	sql := `SELECT a.id, a.adjustment_no, a.owner_code, a.whs_code, a.source_type, a.source_ref,
+	a.reason_code, r.description AS reason_description, a.status, a.qty_in, a.qty_out,
+	a.total_value, a.abs_value, a.approval_role, a.created_at, a.posted_at
+	FROM adjustment_headers a
+	LEFT JOIN adjustment_reasons r ON r.reason_code = a.reason_code AND r.deleted_at IS NULL
+	WHERE a.deleted_at IS NULL` + ownerSQL
+	args := ownerArgs
+	if status != "" {
+		sql += " AND a.status = ?"
+		args = append(args, status)
+	}
+	sql += " ORDER BY a.id DESC"
+
+	var list []AdjustmentList
+	if err := r.db.Raw(sql, args...).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
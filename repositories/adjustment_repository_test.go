@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"fiber-app/models"
+	"testing"
+)
+
+func TestCheckReduce(t *testing.T) {
+	inventory := models.Inventory{ItemCode: "ITEM-1", Location: "A-01", QtyOnhand: 10, QtyAvailable: 4, QtyAllocated: 4, QtySuspend: 2}
+
+	tests := []struct {
+		name      string
+		qtyAdjust int
+		wantErr   bool
+	}{
+		{name: "positive", qtyAdjust: 5},
+		{name: "within free qty", qtyAdjust: -3},
+		{name: "all free qty", qtyAdjust: -4},
+		{name: "into allocated qty", qtyAdjust: -5, wantErr: true},
+		{name: "whole on hand", qtyAdjust: -10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReduce(inventory, tt.qtyAdjust)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkReduce(%d) error = %v, wantErr %v", tt.qtyAdjust, err, tt.wantErr)
+			}
+		})
+	}
+}
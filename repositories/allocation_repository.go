@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Strategy alokasi stock untuk picking outbound
+const (
+	AllocationFIFO           = "FIFO"
+	AllocationFEFO           = "FEFO"
+	AllocationLIFO           = "LIFO"
+	AllocationSmallestPallet = "SMALLEST_PALLET"
+	AllocationFewestLocation = "FEWEST_LOCATION"
+)
+
+// urutan inventory untuk tiap strategy, dipakai di Order() query kandidat
+var allocationOrders = map[string]string{
+	AllocationFIFO: "rec_date ASC, qty_available ASC, pallet ASC, location ASC",
+	// stock tanpa expiry diambil paling akhir
+	AllocationFEFO: `CASE WHEN exp_date IS NULL OR exp_date = '' THEN 1 ELSE 0 END ASC,
+		exp_date ASC, rec_date ASC, qty_available ASC, pallet ASC, location ASC`,
+	AllocationLIFO:           "rec_date DESC, qty_available ASC, pallet ASC, location ASC",
+	AllocationSmallestPallet: "qty_available ASC, rec_date ASC, pallet ASC, location ASC",
+	// lokasi dengan total qty terbesar diambil duluan supaya jumlah lokasi picking minimal.
+	// Total hanya dari stock yang lolos filter kandidat (expiry, lot, hold), parameter lihat FindCandidates.
+	AllocationFewestLocation: `(SELECT SUM(i2.qty_available) FROM inventories i2
+		WHERE i2.location = inventories.location AND i2.item_id = inventories.item_id
+		AND i2.whs_code = inventories.whs_code AND i2.qty_available > 0 AND i2.deleted_at IS NULL
+		AND (i2.exp_date IS NULL OR i2.exp_date = '' OR i2.exp_date >= ?)
+		AND (? = '' OR i2.lot_no = ?)
+		AND NOT ` + heldInventorySQL("i2") + `) DESC,
+		location ASC, qty_available DESC, rec_date ASC`,
+}
+
+type AllocationRepository struct {
+	db *gorm.DB
+}
+
+func NewAllocationRepository(db *gorm.DB) *AllocationRepository {
+	return &AllocationRepository{db: db}
+}
+
+func IsValidAllocationStrategy(strategy string) bool {
+	_, ok := allocationOrders[strategy]
+	return ok
+}
+
+// ResolveStrategy mencari strategy alokasi: product dulu, lalu owner setting, default FIFO
+func (r *AllocationRepository) ResolveStrategy(ownerCode string, itemID int) (string, error) {
+	var product models.Product
+	if err := r.db.Select("id, allocation_rule").Where("id = ?", itemID).First(&product).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if IsValidAllocationStrategy(product.AllocationRule) {
+		return product.AllocationRule, nil
+	}
+
+	setting, err := helpers.GetOwnerSetting(r.db, ownerCode)
+	if err != nil {
+		return "", err
+	}
+	if IsValidAllocationStrategy(setting.AllocationRule) {
+		return setting.AllocationRule, nil
+	}
+
+	// rule lama yang masih tersimpan di tabel owners
+	var owner models.Owner
+	if err := r.db.Select("id, allocation_rule").Where("code = ?", ownerCode).First(&owner).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if IsValidAllocationStrategy(owner.AllocationRule) {
+		return owner.AllocationRule, nil
+	}
+
+	return AllocationFIFO, nil
+}
+
+// FindCandidates mengambil inventory yang bisa dialokasikan sesuai urutan strategy.
+// Stock yang sudah expired tidak ikut dialokasikan, lotNo kosong berarti semua lot.
+func (r *AllocationRepository) FindCandidates(strategy string, itemID int, whsCode string, lotNo string) ([]models.Inventory, error) {
+	order, ok := allocationOrders[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown allocation strategy %s", strategy)
+	}
+
+	today := time.Now().Format("2006-01-02")
+
+	query := r.db.
+		Where("item_id = ? AND whs_code = ? AND qty_available > 0", itemID, whsCode).
+		Where("(exp_date IS NULL OR exp_date = '' OR exp_date >= ?)", today)
+
+	if lotNo != "" {
+		query = query.Where("lot_no = ?", lotNo)
+	}
+	query = ExcludeHeld(query, "inventories")
+
+	orderExpr := clause.Expr{SQL: order, WithoutParentheses: true}
+	if strategy == AllocationFewestLocation {
+		orderExpr.Vars = []interface{}{today, lotNo, lotNo, time.Now()}
+	}
+
+	var inventories []models.Inventory
+	if err := query.Order(clause.OrderBy{Expression: orderExpr}).Find(&inventories).Error; err != nil {
+		return nil, err
+	}
+
+	return inventories, nil
+}
+
+// AllocatedQty qty outbound detail yang sudah punya picking sheet (mis. dari cross-dock)
+func (r *AllocationRepository) AllocatedQty(outboundDetailID int) (int, error) {
+	var allocated int
+	err := r.db.Model(&models.OutboundPicking{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("outbound_detail_id = ?", outboundDetailID).
+		Scan(&allocated).Error
+	return allocated, err
+}
+
+// AllocateDetail membuat picking sheet untuk sisa qty outbound detail yang belum dialokasikan
+// dan memindahkan qty inventory dari available ke allocated. waveID 0 berarti picking per outbound.
+func (r *AllocationRepository) AllocateDetail(outboundDetail models.OutboundDetail, waveID uint, userID int) error {
+	allocated, err := r.AllocatedQty(int(outboundDetail.ID))
+	if err != nil {
+		return err
+	}
+
+	qtyReq := outboundDetail.Quantity - allocated
+	if qtyReq < 1 {
+		return nil
+	}
+
+	strategy, err := r.ResolveStrategy(outboundDetail.OwnerCode, outboundDetail.ItemID)
+	if err != nil {
+		return err
+	}
+
+	inventories, err := r.FindCandidates(strategy, outboundDetail.ItemID, outboundDetail.WhsCode, outboundDetail.LotNo)
+	if err != nil {
+		return err
+	}
+
+	if len(inventories) == 0 {
+		message := "Item " + outboundDetail.ItemCode + " not found"
+		if outboundDetail.LotNo != "" {
+			message += " for lot " + outboundDetail.LotNo
+		}
+		return &BusinessError{Message: message}
+	}
+
+	var product models.Product
+	if err := r.db.Where("id = ?", outboundDetail.ItemID).First(&product).Error; err != nil {
+		return errors.New("Product not found")
+	}
+
+	for _, inventory := range inventories {
+
+		if qtyReq < 1 {
+			break
+		}
+
+		qtyPick := 0
+
+		if inventory.QtyAvailable >= qtyReq {
+			qtyPick = qtyReq
+		} else {
+			qtyPick = inventory.QtyAvailable
+		}
+
+		if err := r.allocateInventory(outboundDetail, product, inventory, qtyPick, strategy, waveID, userID); err != nil {
+			return err
+		}
+
+		qtyReq -= qtyPick
+	}
+
+	if qtyReq > 0 {
+		return &BusinessError{Message: "Insufficient stock for item " + outboundDetail.ItemCode}
+	}
+
+	return nil
+}
+
+// AllocateFromInventory mengalokasikan qty outbound detail langsung dari satu inventory tertentu
+func (r *AllocationRepository) AllocateFromInventory(outboundDetail models.OutboundDetail, inventory models.Inventory, qty int, rule string, userID int) error {
+	if qty < 1 || inventory.QtyAvailable < qty {
+		return &BusinessError{Message: "Insufficient stock for item " + outboundDetail.ItemCode + " in " + inventory.Location}
+	}
+
+	var product models.Product
+	if err := r.db.Where("id = ?", outboundDetail.ItemID).First(&product).Error; err != nil {
+		return errors.New("Product not found")
+	}
+
+	return r.allocateInventory(outboundDetail, product, inventory, qty, rule, 0, userID)
+}
+
+func (r *AllocationRepository) allocateInventory(outboundDetail models.OutboundDetail, product models.Product, inventory models.Inventory, qtyPick int, strategy string, waveID uint, userID int) error {
+	// Insert picking sheet
+	pickingSheet := models.OutboundPicking{
+		InventoryID:      int(inventory.ID),
+		OutboundId:       outboundDetail.OutboundID,
+		OutboundNo:       outboundDetail.OutboundNo,
+		OutboundDetailId: int(outboundDetail.ID),
+		OwnerCode:        outboundDetail.OwnerCode,
+		ItemID:           outboundDetail.ItemID,
+		Barcode:          product.Barcode,
+		ItemCode:         product.ItemCode,
+		Pallet:           inventory.Pallet,
+		Location:         inventory.Location,
+		Quantity:         qtyPick,
+		WhsCode:          inventory.WhsCode,
+		QaStatus:         inventory.QaStatus,
+		LotNo:            inventory.LotNo,
+		ExpDate:          inventory.ExpDate,
+		AllocationRule:   strategy,
+		WaveID:           waveID,
+		CreatedBy:        userID,
+	}
+
+	if err := r.db.Create(&pickingSheet).Error; err != nil {
+		return errors.New("Failed to create picking sheet")
+	}
+
+	// Update Inventory
+	if err := r.db.
+		Model(&models.Inventory{}).
+		Where("id = ?", inventory.ID).
+		Updates(map[string]interface{}{
+			"qty_available": gorm.Expr("qty_available - ?", qtyPick),
+			"qty_allocated": gorm.Expr("qty_allocated + ?", qtyPick),
+			"updated_by":    userID,
+			"updated_at":    time.Now(),
+		}).Error; err != nil {
+		return errors.New("Failed to update inventory")
+	}
+
+	return helpers.InsertInventoryMovement(r.db, inventory, helpers.MovementAllocate, outboundDetail.OutboundNo, userID)
+}
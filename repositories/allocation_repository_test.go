@@ -0,0 +1,25 @@
+package repositories
+
+import "testing"
+
+func TestIsValidAllocationStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     bool
+	}{
+		{AllocationFIFO, true},
+		{AllocationFEFO, true},
+		{AllocationLIFO, true},
+		{AllocationSmallestPallet, true},
+		{AllocationFewestLocation, true},
+		{"", false},
+		{"fifo", false},
+		{"RANDOM", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidAllocationStrategy(tt.strategy); got != tt.want {
+			t.Errorf("IsValidAllocationStrategy(%q) = %v, want %v", tt.strategy, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BusinessError menandakan request ditolak aturan bisnis (stock kurang, status tidak sesuai,
+// data tidak valid), bukan error database. Status kosong berarti 400.
+type BusinessError struct {
+	Message string
+	Status  int
+}
+
+func (e *BusinessError) Error() string {
+	return e.Message
+}
+
+// ErrorStatus status http untuk error dari repository: BusinessError dan fiber.Error sesuai
+// statusnya, selain itu 500
+func ErrorStatus(err error) int {
+	var businessErr *BusinessError
+	if errors.As(err, &businessErr) {
+		if businessErr.Status != 0 {
+			return businessErr.Status
+		}
+		return fiber.StatusBadRequest
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type CrossDockRepository struct {
+	db *gorm.DB
+}
+
+func NewCrossDockRepository(db *gorm.DB) *CrossDockRepository {
+	return &CrossDockRepository{db}
+}
+
+// label allocation_rule di picking sheet hasil cross-dock
+const AllocationCrossDock = "CROSS_DOCK"
+
+type CrossDockMatch struct {
+	InboundBarcodeID int    `json:"inbound_barcode_id"`
+	ItemCode         string `json:"item_code"`
+	LotNo            string `json:"lot_no"`
+	QtyReceived      int    `json:"qty_received"`
+	OutboundNo       string `json:"outbound_no"`
+	OutboundDetailID int    `json:"outbound_detail_id"`
+	CustomerCode     string `json:"customer_code"`
+	PlanPickupDate   string `json:"plan_pickup_date"`
+	QtyDemand        int    `json:"qty_demand"`
+	Quantity         int    `json:"quantity"`
+}
+
+type crossDockDemand struct {
+	OutboundDetailID int
+	OutboundNo       string
+	CustomerCode     string
+	PlanPickupDate   string
+	LotNo            string
+	Remaining        int
+}
+
+// demand = outbound open yang belum masuk wave, sisa qty detail yang belum punya picking sheet
+const crossDockDemandSQL = `SELECT d.id AS outbound_detail_id, h.outbound_no, h.customer_code, h.plan_pickup_date,
+	COALESCE(d.lot_no, '') AS lot_no,
+	d.quantity - COALESCE((SELECT SUM(p.quantity) FROM outbound_pickings p
+		WHERE p.outbound_detail_id = d.id AND p.deleted_at IS NULL), 0) AS remaining
+	FROM outbound_details d
+	INNER JOIN outbound_headers h ON d.outbound_id = h.id
+	WHERE h.status = 'open' AND h.deleted_at IS NULL AND d.deleted_at IS NULL
+	AND d.item_id = ? AND d.owner_code = ? AND d.whs_code = ?
+	AND NOT EXISTS (SELECT 1 FROM wave_outbounds wo
+		INNER JOIN waves w ON wo.wave_id = w.id
+		WHERE wo.outbound_id = h.id AND w.status <> 'cancel'
+		AND w.deleted_at IS NULL AND wo.deleted_at IS NULL)`
+
+// pickup paling awal didahulukan, tanpa jadwal pickup paling akhir
+const crossDockDemandOrder = ` ORDER BY CASE WHEN COALESCE(h.plan_pickup_date, '') = '' THEN 1 ELSE 0 END,
+	h.plan_pickup_date, h.outbound_no, d.id`
+
+// FindMatches mencocokkan barcode inbound yang masih pending (QA available, belum expired)
+// dengan demand outbound untuk item, owner dan gudang yang sama. Satu barcode bisa memenuhi
+// beberapa outbound, outbound dengan jadwal pickup paling awal didahulukan.
+func (r *CrossDockRepository) FindMatches(inboundID int) ([]CrossDockMatch, error) {
+	var barcodes []models.InboundBarcode
+	if err := r.db.Where("inbound_id = ? AND status = ? AND qa_status = ?", inboundID, "pending", "A").
+		Order("id").Find(&barcodes).Error; err != nil {
+		return nil, err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	uomRepo := NewUomRepository(r.db)
+	demands := map[string][]*crossDockDemand{}
+	var matches []CrossDockMatch
+
+	for _, barcode := range barcodes {
+		if barcode.ExpDate != "" && barcode.ExpDate < today {
+			continue
+		}
+
+		var detail models.InboundDetail
+		if err := r.db.Where("id = ?", barcode.InboundDetailId).Take(&detail).Error; err != nil {
+			return nil, errors.New("inbound detail not found for item: " + barcode.ItemCode)
+		}
+		uomConversion, err := uomRepo.ConversionQty(barcode.ItemCode, barcode.Quantity, detail.Uom)
+		if err != nil {
+			return nil, err
+		}
+
+		// demand di-cache per item supaya barcode berikutnya memakai sisa demand
+		key := barcode.ItemCode + "|" + barcode.OwnerCode + "|" + barcode.WhsCode
+		lines, ok := demands[key]
+		if !ok {
+			ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "h.owner_code")
+			args := append([]interface{}{barcode.ItemID, barcode.OwnerCode, barcode.WhsCode}, ownerArgs...)
+			var rows []crossDockDemand
+			if err := r.db.Raw(crossDockDemandSQL+ownerSQL+crossDockDemandOrder, args...).Scan(&rows).Error; err != nil {
+				return nil, err
+			}
+			for i := range rows {
+				if rows[i].Remaining > 0 {
+					lines = append(lines, &rows[i])
+				}
+			}
+			demands[key] = lines
+		}
+
+		qtyLeft := uomConversion.QtyConverted
+		for _, demand := range lines {
+			if qtyLeft < 1 {
+				break
+			}
+			if demand.Remaining < 1 || (demand.LotNo != "" && demand.LotNo != barcode.LotNo) {
+				continue
+			}
+
+			qty := demand.Remaining
+			if qty > qtyLeft {
+				qty = qtyLeft
+			}
+			matches = append(matches, CrossDockMatch{
+				InboundBarcodeID: int(barcode.ID),
+				ItemCode:         barcode.ItemCode,
+				LotNo:            barcode.LotNo,
+				QtyReceived:      uomConversion.QtyConverted,
+				OutboundNo:       demand.OutboundNo,
+				OutboundDetailID: demand.OutboundDetailID,
+				CustomerCode:     demand.CustomerCode,
+				PlanPickupDate:   demand.PlanPickupDate,
+				QtyDemand:        demand.Remaining,
+				Quantity:         qty,
+			})
+			demand.Remaining -= qty
+			qtyLeft -= qty
+		}
+	}
+
+	return matches, nil
+}
+
+// Execute memindahkan barcode yang cocok ke lokasi staging (putaway utuh per barcode) lalu
+// membuat picking sheet outbound langsung dari inventory staging tersebut. Sisa qty barcode
+// yang tidak dibutuhkan outbound tetap available di staging. barcodeIDs kosong = semua match.
+func (r *CrossDockRepository) Execute(ctx *fiber.Ctx, inbound models.InboundHeader, stagingLocation string, barcodeIDs []int, userID int) ([]CrossDockMatch, error) {
+	location, err := NewLocationRepository(r.db).ValidateLocation(stagingLocation, inbound.WhsCode, inbound.OwnerCode)
+	if err != nil {
+		return nil, err
+	}
+	if location.LocationType != LocationTypeStaging {
+		return nil, &BusinessError{Message: "location " + stagingLocation + " is not a staging location"}
+	}
+
+	matches, err := r.FindMatches(int(inbound.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	selected := map[int]bool{}
+	for _, id := range barcodeIDs {
+		selected[id] = true
+	}
+
+	inboundRepo := NewInboundRepository(r.db)
+	allocationRepo := NewAllocationRepository(r.db)
+	inventories := map[int]models.Inventory{}
+	var applied []CrossDockMatch
+
+	for _, match := range matches {
+		if len(selected) > 0 && !selected[match.InboundBarcodeID] {
+			continue
+		}
+
+		inventory, ok := inventories[match.InboundBarcodeID]
+		if !ok {
+			var barcode models.InboundBarcode
+			if err := r.db.Where("id = ?", match.InboundBarcodeID).Take(&barcode).Error; err != nil {
+				return nil, err
+			}
+			if _, err := inboundRepo.PutawayItem(ctx, match.InboundBarcodeID, stagingLocation); err != nil {
+				return nil, err
+			}
+			if inventory, err = r.stagedInventory(barcode, stagingLocation); err != nil {
+				return nil, err
+			}
+			inventories[match.InboundBarcodeID] = inventory
+		} else if err := r.db.Where("id = ?", inventory.ID).Take(&inventory).Error; err != nil {
+			// baris staging bisa digabung dengan barcode lain, baca ulang sebelum alokasi berikutnya
+			return nil, err
+		}
+
+		var detail models.OutboundDetail
+		if err := r.db.Where("id = ?", match.OutboundDetailID).Take(&detail).Error; err != nil {
+			return nil, err
+		}
+		if err := allocationRepo.AllocateFromInventory(detail, inventory, match.Quantity, AllocationCrossDock, userID); err != nil {
+			return nil, err
+		}
+
+		applied = append(applied, match)
+	}
+
+	return applied, nil
+}
+
+// stagedInventory inventory hasil putaway barcode ke staging, kunci sama dengan PutawayItem
+func (r *CrossDockRepository) stagedInventory(barcode models.InboundBarcode, location string) (models.Inventory, error) {
+	var inventory models.Inventory
+	err := r.db.Where(`inbound_detail_id = ? AND item_code = ? AND location = ? AND barcode = ? AND
+		whs_code = ? AND qa_status = ? AND COALESCE(lot_no, '') = ? AND COALESCE(exp_date, '') = ?`,
+		barcode.InboundDetailId, barcode.ItemCode, location, barcode.Barcode,
+		barcode.WhsCode, barcode.QaStatus, barcode.LotNo, barcode.ExpDate).
+		First(&inventory).Error
+	return inventory, err
+}
@@ -0,0 +1,545 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CycleCountRepository struct {
+	db *gorm.DB
+}
+
+func NewCycleCountRepository(db *gorm.DB) *CycleCountRepository {
+	return &CycleCountRepository{db}
+}
+
+const (
+	ClassBasisPick  = "pick"
+	ClassBasisValue = "value"
+)
+
+// class bawaan kalau belum ada setting: 80% pick / nilai teratas A, 15% berikutnya B, sisanya C
+var defaultCycleCountClasses = []models.CycleCountClass{
+	{ClassCode: "A", CumulativePct: 80, FrequencyDays: 30, IsActive: true},
+	{ClassCode: "B", CumulativePct: 95, FrequencyDays: 90, IsActive: true},
+	{ClassCode: "C", CumulativePct: 100, FrequencyDays: 180, IsActive: true},
+}
+
+type itemScore struct {
+	ItemID int
+	Score  float64
+}
+
+type CycleCountDue struct {
+	ItemID      int        `json:"item_id"`
+	ItemCode    string     `json:"item_code"`
+	OwnerCode   string     `json:"owner_code"`
+	ClassCode   string     `json:"class_code"`
+	LastCountAt *time.Time `json:"last_count_at"`
+	DueDate     *time.Time `json:"due_date"`
+}
+
+// CycleCountAccuracy inventory record accuracy: persentase baris count tanpa selisih
+type CycleCountAccuracy struct {
+	Period       string  `json:"period"`
+	ClassCode    string  `json:"class_code"`
+	LinesCounted int     `json:"lines_counted"`
+	LinesExact   int     `json:"lines_exact"`
+	Ira          float64 `json:"ira"`
+	QtySystem    int     `json:"qty_system"`
+	QtyVariance  int     `json:"qty_variance"`
+}
+
+// GetClasses class aktif urut cumulative_pct, class terakhir menampung sisa item
+func (r *CycleCountRepository) GetClasses() ([]models.CycleCountClass, error) {
+	var classes []models.CycleCountClass
+	if err := r.db.Where("is_active = ?", true).Order("cumulative_pct").Find(&classes).Error; err != nil {
+		return nil, err
+	}
+	if len(classes) == 0 {
+		return defaultCycleCountClasses, nil
+	}
+	return classes, nil
+}
+
+func (r *CycleCountRepository) scores(basis string, days int) (map[int]float64, error) {
+	var rows []itemScore
+	var err error
+	switch basis {
+	case ClassBasisPick:
+		err = r.db.Model(&models.OutboundPicking{}).
+			Select("item_id, COUNT(*) AS score").
+			Where("created_at >= ?", time.Now().AddDate(0, 0, -days)).
+			Group("item_id").
+			Scan(&rows).Error
+	case ClassBasisValue:
+		ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "i.owner_code")
+		err = r.db.Raw(`SELECT i.item_id, SUM(i.qty_onhand * p.unit_cost) AS score
+			FROM inventories i
+			INNER JOIN products p ON p.id = i.item_id AND p.deleted_at IS NULL
+			WHERE i.deleted_at IS NULL AND i.qty_onhand > 0`+ownerSQL+`
+			GROUP BY i.item_id`, ownerArgs...).Scan(&rows).Error
+	default:
+		return nil, &BusinessError{Message: "basis must be " + ClassBasisPick + " or " + ClassBasisValue}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[int]float64{}
+	for _, row := range rows {
+		result[row.ItemID] = row.Score
+	}
+	return result, nil
+}
+
+// abcClass class untuk item dengan score tertentu, cumulative = total score item di atasnya.
+// Item masuk class pertama yang cut-off-nya belum terlewati, item tanpa score masuk class terakhir.
+func abcClass(classes []models.CycleCountClass, cumulative, score, total float64) string {
+	if total > 0 && score > 0 {
+		for _, class := range classes {
+			if cumulative/total*100 < class.CumulativePct {
+				return class.ClassCode
+			}
+		}
+	}
+	return classes[len(classes)-1].ClassCode
+}
+
+// Classify mengelompokkan semua item ke class ABC berdasarkan jumlah pick dalam days hari terakhir
+// atau nilai stock on hand. Tanggal count terakhir item tetap dipertahankan.
+func (r *CycleCountRepository) Classify(basis string, days int, userID int) (map[string]int, error) {
+	if days <= 0 {
+		days = 90
+	}
+	classes, err := r.GetClasses()
+	if err != nil {
+		return nil, err
+	}
+	scores, err := r.scores(basis, days)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []models.Product
+	if err := r.db.Select("id, item_code, owner_code").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	sort.SliceStable(products, func(i, j int) bool {
+		return scores[int(products[i].ID)] > scores[int(products[j].ID)]
+	})
+
+	total := 0.0
+	for _, product := range products {
+		total += scores[int(product.ID)]
+	}
+
+	now := time.Now()
+	summary := map[string]int{}
+	cumulative := 0.0
+	for _, product := range products {
+		score := scores[int(product.ID)]
+		classCode := abcClass(classes, cumulative, score, total)
+		cumulative += score
+
+		var classification models.ItemClassification
+		err := r.db.Where("item_id = ?", product.ID).First(&classification).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		classification.ItemID = int(product.ID)
+		classification.ItemCode = product.ItemCode
+		classification.OwnerCode = product.OwnerCode
+		classification.ClassCode = classCode
+		classification.Basis = basis
+		classification.Score = score
+		classification.ClassifiedAt = now
+		classification.UpdatedBy = userID
+		if classification.ID == 0 {
+			classification.CreatedBy = userID
+			err = r.db.Create(&classification).Error
+		} else {
+			err = r.db.Save(&classification).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		summary[classCode]++
+	}
+	return summary, nil
+}
+
+// GetDue item yang sudah waktunya dicount sesuai frekuensi class-nya, belum pernah dicount
+// paling depan. Item yang masih ada di cycle count yang belum selesai tidak diambil lagi.
+func (r *CycleCountRepository) GetDue(asOf time.Time) ([]CycleCountDue, error) {
+	classes, err := r.GetClasses()
+	if err != nil {
+		return nil, err
+	}
+	frequency := map[string]int{}
+	rank := map[string]int{}
+	for i, class := range classes {
+		frequency[class.ClassCode] = class.FrequencyDays
+		rank[class.ClassCode] = i
+	}
+
+	var classifications []models.ItemClassification
+	if err := r.db.Where(`item_id NOT IN (SELECT i.item_id FROM stock_take_items i
+		INNER JOIN stock_takes s ON s.id = i.stock_take_id
+		WHERE s.deleted_at IS NULL AND i.deleted_at IS NULL AND s.count_type = ? AND s.status = ?)`, CountCycle, StockTakeOpen).
+		Find(&classifications).Error; err != nil {
+		return nil, err
+	}
+
+	due := []CycleCountDue{}
+	for _, c := range classifications {
+		days, ok := frequency[c.ClassCode]
+		if !ok {
+			continue
+		}
+		item := CycleCountDue{ItemID: c.ItemID, ItemCode: c.ItemCode, OwnerCode: c.OwnerCode, ClassCode: c.ClassCode, LastCountAt: c.LastCountAt}
+		if c.LastCountAt != nil {
+			dueDate := c.LastCountAt.AddDate(0, 0, days)
+			if dueDate.After(asOf) {
+				continue
+			}
+			item.DueDate = &dueDate
+		}
+		due = append(due, item)
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		if rank[due[i].ClassCode] != rank[due[j].ClassCode] {
+			return rank[due[i].ClassCode] < rank[due[j].ClassCode]
+		}
+		if due[i].DueDate == nil || due[j].DueDate == nil {
+			return due[i].DueDate == nil && due[j].DueDate != nil
+		}
+		return due[i].DueDate.Before(*due[j].DueDate)
+	})
+	return due, nil
+}
+
+// Generate membuat cycle count untuk item yang jatuh tempo di warehouse, maksimal maxLocations
+// lokasi per run. Semua stock di lokasi terpilih ikut dicount supaya satu lokasi selesai sekali jalan,
+// dan satu task count dibuat per lokasi.
+func (r *CycleCountRepository) Generate(whsCode string, maxLocations int, blind bool, userID int) (models.StockTake, error) {
+	var stockTake models.StockTake
+	if whsCode == "" {
+		return stockTake, &BusinessError{Message: "Warehouse is required"}
+	}
+
+	due, err := r.GetDue(time.Now())
+	if err != nil {
+		return stockTake, err
+	}
+	if len(due) == 0 {
+		return stockTake, &BusinessError{Message: "No items due for counting"}
+	}
+
+	classOf := map[int]string{}
+	dueIDs := []int{}
+	for _, item := range due {
+		classOf[item.ItemID] = item.ClassCode
+		dueIDs = append(dueIDs, item.ItemID)
+	}
+
+	// lokasi diurutkan sesuai prioritas item jatuh tempo pertama yang ada di lokasi tersebut
+	var dueStock []models.Inventory
+	if err := r.db.Where("whs_code = ? AND qty_onhand > 0 AND item_id IN ?", whsCode, dueIDs).Find(&dueStock).Error; err != nil {
+		return stockTake, err
+	}
+	priority := map[int]int{}
+	for i, id := range dueIDs {
+		priority[id] = i
+	}
+	sort.SliceStable(dueStock, func(i, j int) bool {
+		return priority[dueStock[i].ItemId] < priority[dueStock[j].ItemId]
+	})
+
+	locations := []string{}
+	selected := map[string]bool{}
+	for _, inventory := range dueStock {
+		if selected[inventory.Location] {
+			continue
+		}
+		if maxLocations > 0 && len(locations) >= maxLocations {
+			break
+		}
+		selected[inventory.Location] = true
+		locations = append(locations, inventory.Location)
+	}
+	if len(locations) == 0 {
+		return stockTake, &BusinessError{Message: "No stock of due items in warehouse " + whsCode}
+	}
+
+	var inventories []models.Inventory
+	if err := r.db.Where("whs_code = ? AND qty_onhand > 0 AND location IN ?", whsCode, locations).
+		Order("location, item_code, id").Find(&inventories).Error; err != nil {
+		return stockTake, err
+	}
+
+	// item lain di lokasi yang sama ikut dicount, class-nya tetap dicatat untuk IRA per class
+	otherIDs := []int{}
+	for _, inventory := range inventories {
+		if _, ok := classOf[inventory.ItemId]; !ok {
+			otherIDs = append(otherIDs, inventory.ItemId)
+		}
+	}
+	if len(otherIDs) > 0 {
+		var others []models.ItemClassification
+		if err := r.db.Where("item_id IN ?", otherIDs).Find(&others).Error; err != nil {
+			return stockTake, err
+		}
+		for _, other := range others {
+			classOf[other.ItemID] = other.ClassCode
+		}
+	}
+
+	code, err := NewNumberingRepository(r.db).Next(DocStockTake, "", whsCode, "")
+	if err != nil {
+		return stockTake, err
+	}
+	stockTake = models.StockTake{
+		Code:      code,
+		Status:    StockTakeOpen,
+		CountType: CountCycle,
+		WhsCode:   whsCode,
+		IsBlind:   blind,
+		CreatedBy: userID,
+	}
+	if err := r.db.Create(&stockTake).Error; err != nil {
+		return stockTake, err
+	}
+
+	for _, inventory := range inventories {
+		stockTake.Items = append(stockTake.Items, models.StockTakeItem{
+			StockTakeID: stockTake.ID,
+			ItemID:      int64(inventory.ItemId),
+			InventoryID: int64(inventory.ID),
+			Location:    inventory.Location,
+			Pallet:      inventory.Pallet,
+			Barcode:     inventory.Barcode,
+			SystemQty:   inventory.QtyOnhand,
+			ClassCode:   classOf[inventory.ItemId],
+			CreatedBy:   userID,
+		})
+	}
+	if err := r.db.Create(&stockTake.Items).Error; err != nil {
+		return stockTake, err
+	}
+
+	return stockTake, r.createLocationTasks(stockTake, userID)
+}
+
+func (r *CycleCountRepository) createLocationTasks(stockTake models.StockTake, userID int) error {
+	lines := map[string]int{}
+	locations := []string{}
+	for _, item := range stockTake.Items {
+		if lines[item.Location] == 0 {
+			locations = append(locations, item.Location)
+		}
+		lines[item.Location]++
+	}
+
+	for _, location := range locations {
+		remarks := "cycle count"
+		if stockTake.ParentCode != "" {
+			remarks = "recount " + stockTake.ParentCode
+		}
+		if err := r.db.Create(&models.WarehouseTask{
+			TaskType:  TaskCount,
+			RefNo:     stockTake.Code,
+			WhsCode:   stockTake.WhsCode,
+			Location:  location,
+			Quantity:  lines[location],
+			Priority:  defaultTaskPriority[TaskCount],
+			Status:    TaskStatusOpen,
+			Remarks:   remarks,
+			CreatedBy: userID,
+			UpdatedBy: userID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recount membuat count ulang (selalu blind) untuk baris yang selisih, termasuk stock yang ditemukan
+// di lokasi tapi tidak ada di system. Stock take asal ditutup dengan status recount, baris yang
+// cocok dianggap sudah dicount. Qty system diambil ulang dari inventory saat ini.
+func (r *CycleCountRepository) Recount(stockTake models.StockTake, userID int) (models.StockTake, error) {
+	var recount models.StockTake
+	if stockTake.Status != StockTakeOpen {
+		return recount, &BusinessError{Message: fmt.Sprintf("Stock take %s is %s, only open count can be recounted", stockTake.Code, stockTake.Status)}
+	}
+
+	stockTakeRepo := NewStockTakeRepository(r.db)
+	items, found, err := stockTakeRepo.ApplyCounts(stockTake, userID)
+	if err != nil {
+		return recount, err
+	}
+
+	var exact, variance []models.StockTakeItem
+	for _, item := range items {
+		if item.Difference == 0 {
+			exact = append(exact, item)
+			continue
+		}
+
+		// inventory yang sudah habis dicount ulang sebagai stock baru
+		var inventory models.Inventory
+		if item.InventoryID > 0 {
+			err := r.db.Where("id = ?", item.InventoryID).First(&inventory).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return recount, err
+			}
+		}
+		variance = append(variance, models.StockTakeItem{
+			ItemID:      item.ItemID,
+			InventoryID: int64(inventory.ID),
+			Location:    item.Location,
+			Pallet:      item.Pallet,
+			Barcode:     item.Barcode,
+			SystemQty:   inventory.QtyOnhand,
+			ClassCode:   item.ClassCode,
+			CreatedBy:   userID,
+		})
+	}
+	for _, f := range found {
+		var product models.Product
+		if err := r.db.Where("barcode = ?", f.Barcode).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return recount, &BusinessError{Message: "Barcode " + f.Barcode + " counted at " + f.Location + " is not a known item"}
+			}
+			return recount, err
+		}
+		variance = append(variance, models.StockTakeItem{
+			ItemID:    int64(product.ID),
+			Location:  f.Location,
+			Pallet:    f.Location,
+			Barcode:   f.Barcode,
+			CreatedBy: userID,
+		})
+	}
+	if len(variance) == 0 {
+		return recount, &BusinessError{Message: "Stock take " + stockTake.Code + " has no variance, post it instead"}
+	}
+
+	code, err := NewNumberingRepository(r.db).Next(DocStockTake, "", stockTake.WhsCode, "")
+	if err != nil {
+		return recount, err
+	}
+	recount = models.StockTake{
+		Code:       code,
+		Status:     StockTakeOpen,
+		CountType:  stockTake.CountType,
+		WhsCode:    stockTake.WhsCode,
+		IsBlind:    true,
+		ParentCode: stockTake.Code,
+		CreatedBy:  userID,
+	}
+	if err := r.db.Create(&recount).Error; err != nil {
+		return recount, err
+	}
+	for i := range variance {
+		variance[i].StockTakeID = recount.ID
+	}
+	if err := r.db.Create(&variance).Error; err != nil {
+		return recount, err
+	}
+	recount.Items = variance
+
+	if err := r.createLocationTasks(recount, userID); err != nil {
+		return recount, err
+	}
+	if err := stockTakeRepo.Finish(stockTake, StockTakeRecount, userID); err != nil {
+		return recount, err
+	}
+	if stockTake.CountType == CountCycle {
+		if err := r.MarkCounted(exact, userID); err != nil {
+			return recount, err
+		}
+	}
+	return recount, nil
+}
+
+// MarkCounted mencatat tanggal count terakhir item yang sudah selesai dicount
+func (r *CycleCountRepository) MarkCounted(items []models.StockTakeItem, userID int) error {
+	ids := []int64{}
+	for _, item := range items {
+		ids = append(ids, item.ItemID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.db.Model(&models.ItemClassification{}).Where("item_id IN ?", ids).Updates(map[string]interface{}{
+		"last_count_at": time.Now(),
+		"updated_by":    userID,
+	}).Error
+}
+
+// GetAccuracy IRA per bulan dan class dari hasil akhir cycle count. Baris yang direcount
+// dihitung dari hasil recount-nya, baris yang cocok di count pertama tetap dihitung.
+func (r *CycleCountRepository) GetAccuracy(from, to time.Time) ([]CycleCountAccuracy, error) {
+	var rows []struct {
+		CreatedAt  time.Time
+		ClassCode  string
+		SystemQty  int
+		Difference int
+	}
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "inv.owner_code")
+	args := append([]interface{}{CountCycle, StockTakePosted, StockTakeRecount, from, to}, ownerArgs...)
+	if err := r.db.Raw(`SELECT s.created_at, i.class_code, i.system_qty, i.difference
+		FROM stock_take_items i
+		INNER JOIN stock_takes s ON s.id = i.stock_take_id
+		LEFT JOIN inventories inv ON inv.id = i.inventory_id
+		WHERE s.deleted_at IS NULL AND i.deleted_at IS NULL AND s.count_type = ?
+		AND (s.status = ? OR (s.status = ? AND i.difference = 0))
+		AND s.created_at >= ? AND s.created_at < ?`+ownerSQL+`
+		ORDER BY s.created_at`, args...).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := []CycleCountAccuracy{}
+	index := map[string]int{}
+	for _, row := range rows {
+		key := row.CreatedAt.Format("2006-01") + "|" + row.ClassCode
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, CycleCountAccuracy{Period: row.CreatedAt.Format("2006-01"), ClassCode: row.ClassCode})
+		}
+
+		result[i].LinesCounted++
+		if row.Difference == 0 {
+			result[i].LinesExact++
+		}
+		result[i].QtySystem += row.SystemQty
+		if row.Difference < 0 {
+			result[i].QtyVariance -= row.Difference
+		} else {
+			result[i].QtyVariance += row.Difference
+		}
+	}
+	for i := range result {
+		result[i].Ira = float64(result[i].LinesExact) / float64(result[i].LinesCounted) * 100
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].ClassCode < result[j].ClassCode
+	})
+	return result, nil
+}
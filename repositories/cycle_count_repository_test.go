@@ -0,0 +1,34 @@
+package repositories
+
+import "testing"
+
+func TestAbcClass(t *testing.T) {
+	classes := defaultCycleCountClasses
+
+	// total 100: A sampai kumulatif 80, B sampai 95, sisanya C
+	tests := []struct {
+		name       string
+		cumulative float64
+		score      float64
+		total      float64
+		want       string
+	}{
+		{"top item", 0, 50, 100, "A"},
+		{"still below A cut-off", 79, 1, 100, "A"},
+		{"exactly at A cut-off", 80, 5, 100, "B"},
+		{"inside B", 90, 3, 100, "B"},
+		{"exactly at B cut-off", 95, 2, 100, "C"},
+		{"tail", 99, 1, 100, "C"},
+		{"single item takes everything", 0, 100, 100, "A"},
+		{"no score", 40, 0, 100, "C"},
+		{"no activity at all", 0, 0, 0, "C"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := abcClass(classes, tt.cumulative, tt.score, tt.total); got != tt.want {
+				t.Errorf("abcClass(%v, %v, %v) = %s, want %s", tt.cumulative, tt.score, tt.total, got, tt.want)
+			}
+		})
+	}
+}
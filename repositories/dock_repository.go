@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DockRepository struct {
+	db *gorm.DB
+}
+
+func NewDockRepository(db *gorm.DB) *DockRepository {
+	return &DockRepository{db}
+}
+
+const (
+	DockInbound  = "inbound"
+	DockOutbound = "outbound"
+	DockBoth     = "both"
+
+	AppointmentBooked   = "booked"
+	AppointmentArrived  = "arrived"
+	AppointmentDocked   = "docked"
+	AppointmentDeparted = "departed"
+	AppointmentCancel   = "cancel"
+)
+
+// status appointment yang masih memakai slot pintu
+var activeAppointmentStatus = []string{AppointmentBooked, AppointmentArrived, AppointmentDocked}
+
+// format jam di header inbound / outbound
+const DockTimeLayout = "2006-01-02 15:04"
+
+type DockScheduleItem struct {
+	AppointmentNo   string     `json:"appointment_no"`
+	DoorCode        string     `json:"door_code"`
+	Direction       string     `json:"direction"`
+	RefNo           string     `json:"ref_no"`
+	OwnerCode       string     `json:"owner_code"`
+	TransporterCode string     `json:"transporter_code"`
+	TruckNo         string     `json:"truck_no"`
+	StartTime       time.Time  `json:"start_time"`
+	EndTime         time.Time  `json:"end_time"`
+	Status          string     `json:"status"`
+	ArrivedAt       *time.Time `json:"arrived_at"`
+	DockedAt        *time.Time `json:"docked_at"`
+	DepartedAt      *time.Time `json:"departed_at"`
+}
+
+type DockDoorSchedule struct {
+	DoorCode     string             `json:"door_code"`
+	Name         string             `json:"name"`
+	DoorType     string             `json:"door_type"`
+	Appointments []DockScheduleItem `json:"appointments"`
+}
+
+type DockHourLoad struct {
+	Hour         string  `json:"hour"`
+	Appointments int     `json:"appointments"`
+	Doors        int     `json:"doors"`
+	Utilization  float64 `json:"utilization"`
+}
+
+type DockSchedule struct {
+	Date    string             `json:"date"`
+	WhsCode string             `json:"whs_code"`
+	Doors   []DockDoorSchedule `json:"doors"`
+	Hourly  []DockHourLoad     `json:"hourly"`
+	Waiting []DockScheduleItem `json:"waiting"`
+}
+
+// ParseDockTime menerima "YYYY-MM-DD HH:MM" (waktu lokal) atau RFC3339
+func ParseDockTime(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(DockTimeLayout, value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, &BusinessError{Message: "invalid time " + value + ", use YYYY-MM-DD HH:MM"}
+	}
+	return t.Local(), nil
+}
+
+func (r *DockRepository) GenerateAppointmentNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocAppointment, ownerCode, whsCode, "")
+}
+
+// ValidateSlot cek pintu aktif, cocok gudang dan arah, dan tidak bentrok dengan booking lain.
+// Cek bentrok mengabaikan owner scope karena pintu dipakai bersama.
+func (r *DockRepository) ValidateSlot(doorCode, whsCode, direction string, start, end time.Time, excludeID uint) (models.DockDoor, error) {
+	var door models.DockDoor
+	if !end.After(start) {
+		return door, &BusinessError{Message: "end time must be after start time"}
+	}
+
+	if err := r.db.Where("door_code = ?", doorCode).First(&door).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return door, &BusinessError{Message: "dock door " + doorCode + " not found"}
+		}
+		return door, err
+	}
+	if !door.IsActive {
+		return door, &BusinessError{Message: "dock door " + doorCode + " is inactive"}
+	}
+	if door.WhsCode != "" && whsCode != "" && door.WhsCode != whsCode {
+		return door, &BusinessError{Message: "dock door " + doorCode + " belongs to warehouse " + door.WhsCode}
+	}
+	if door.DoorType != "" && door.DoorType != DockBoth && door.DoorType != direction {
+		return door, &BusinessError{Message: "dock door " + doorCode + " is for " + door.DoorType + " only"}
+	}
+
+	var conflict models.DockAppointment
+	err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).
+		Where("door_id = ? AND id <> ? AND status IN ?", door.ID, excludeID, activeAppointmentStatus).
+		Where("start_time < ? AND end_time > ?", end, start).
+		Order("start_time").
+		First(&conflict).Error
+	if err == nil {
+		return door, &BusinessError{Message: fmt.Sprintf("dock door %s is booked by %s (%s - %s)",
+			doorCode, conflict.AppointmentNo, conflict.StartTime.Format(DockTimeLayout), conflict.EndTime.Format("15:04"))}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return door, err
+	}
+	return door, nil
+}
+
+// GetSchedule jadwal dock satu hari: booking per pintu, beban per jam dan truk yang sudah
+// datang tapi belum masuk pintu
+func (r *DockRepository) GetSchedule(date, whsCode string) (DockSchedule, error) {
+	schedule := DockSchedule{Date: date, WhsCode: whsCode}
+
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return schedule, &BusinessError{Message: "invalid date, use YYYY-MM-DD"}
+	}
+	nextDay := day.AddDate(0, 0, 1)
+
+	doorQuery := r.db.Where("is_active = ?", true)
+	if whsCode != "" {
+		doorQuery = doorQuery.Where("whs_code = ? OR COALESCE(whs_code, '') = ''", whsCode)
+	}
+	var doors []models.DockDoor
+	if err := doorQuery.Order("door_code").Find(&doors).Error; err != nil {
+		return schedule, err
+	}
+
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+	sql := `SELECT a.appointment_no, a.door_code, a.direction, a.ref_no, a.owner_code, a.transporter_code,
+	a.truck_no, a.start_time, a.end_time, a.status, a.arrived_at, a.docked_at, a.departed_at
+	FROM dock_appointments a
+	WHERE a.deleted_at IS NULL AND a.status <> 'cancel'
+	AND a.start_time < ? AND a.end_time > ?` + ownerSQL
+	args := append([]interface{}{nextDay, day}, ownerArgs...)
+	if whsCode != "" {
+		sql += " AND a.whs_code = ?"
+		args = append(args, whsCode)
+	}
+	sql += " ORDER BY a.start_time, a.door_code"
+
+	var items []DockScheduleItem
+	if err := r.db.Raw(sql, args...).Scan(&items).Error; err != nil {
+		return schedule, err
+	}
+
+	byDoor := map[string][]DockScheduleItem{}
+	for _, item := range items {
+		byDoor[item.DoorCode] = append(byDoor[item.DoorCode], item)
+		if item.Status == AppointmentArrived {
+			schedule.Waiting = append(schedule.Waiting, item)
+		}
+	}
+	for _, door := range doors {
+		schedule.Doors = append(schedule.Doors, DockDoorSchedule{
+			DoorCode:     door.DoorCode,
+			Name:         door.Name,
+			DoorType:     door.DoorType,
+			Appointments: byDoor[door.DoorCode],
+		})
+	}
+
+	// utilisasi per jam (persen) = booking yang overlap jam itu dibanding jumlah pintu aktif
+	for hour := 0; hour < 24; hour++ {
+		from := day.Add(time.Duration(hour) * time.Hour)
+		to := from.Add(time.Hour)
+		load := DockHourLoad{Hour: from.Format("15:04"), Doors: len(doors)}
+		for _, item := range items {
+			if item.StartTime.Before(to) && item.EndTime.After(from) {
+				load.Appointments++
+			}
+		}
+		if load.Doors > 0 {
+			load.Utilization = math.Round(float64(load.Appointments)*10000/float64(load.Doors)) / 100
+		}
+		schedule.Hourly = append(schedule.Hourly, load)
+	}
+
+	return schedule, nil
+}
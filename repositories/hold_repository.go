@@ -0,0 +1,384 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type HoldRepository struct {
+	db *gorm.DB
+}
+
+func NewHoldRepository(db *gorm.DB) *HoldRepository {
+	return &HoldRepository{db}
+}
+
+const (
+	HoldByItem     = "item"
+	HoldByLot      = "lot"
+	HoldByLocation = "location"
+	HoldByInbound  = "inbound"
+	HoldByOwner    = "owner"
+
+	// pending: menunggu approval, stock sudah diblokir sejak hold dibuat
+	HoldPending  = "pending"
+	HoldActive   = "active"
+	HoldReleased = "released"
+)
+
+var HoldTypes = []string{HoldByItem, HoldByLot, HoldByLocation, HoldByInbound, HoldByOwner}
+
+// holdMatchSQL kondisi hold h aktif (belum expired) yang mengenai inventory alias.
+// Kriteria hold yang kosong berarti semua, hold inbound dicocokkan lewat inbound_id inventory.
+func holdMatchSQL(alias string) string {
+	return `h.deleted_at IS NULL AND h.status IN ('` + HoldPending + `', '` + HoldActive + `')
+		AND (h.expires_at IS NULL OR h.expires_at > ?)
+		AND (h.owner_code = '' OR h.owner_code = ` + alias + `.owner_code)
+		AND (h.whs_code = '' OR h.whs_code = ` + alias + `.whs_code)
+		AND (h.item_code = '' OR h.item_code = ` + alias + `.item_code)
+		AND (h.lot_no = '' OR h.lot_no = ` + alias + `.lot_no)
+		AND (h.location = '' OR h.location = ` + alias + `.location)
+		AND (h.inbound_no = '' OR EXISTS (SELECT 1 FROM inbound_headers ih
+			WHERE ih.inbound_no = h.inbound_no AND ih.id = ` + alias + `.inbound_id))`
+}
+
+// heldInventorySQL kondisi inventory yang kena hold aktif
+func heldInventorySQL(alias string) string {
+	return `EXISTS (SELECT 1 FROM inventory_holds h WHERE ` + holdMatchSQL(alias) + `)`
+}
+
+// ExcludeHeld filter query inventory supaya stock yang kena hold tidak ikut dialokasikan
+func ExcludeHeld(query *gorm.DB, alias string) *gorm.DB {
+	return query.Where("NOT "+heldInventorySQL(alias), time.Now())
+}
+
+type HoldList struct {
+	models.InventoryHold
+	ReasonDescription string `json:"reason_description"`
+	QtyReleased       int    `json:"qty_released"`
+}
+
+func (r *HoldRepository) GenerateHoldNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocHold, ownerCode, whsCode, "")
+}
+
+// Validate cek reason dan kriteria wajib sesuai jenis hold
+func (r *HoldRepository) Validate(hold models.InventoryHold) (models.HoldReason, error) {
+	var reason models.HoldReason
+	if err := r.db.Where("reason_code = ?", hold.ReasonCode).First(&reason).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reason, &BusinessError{Message: "Reason code " + hold.ReasonCode + " not found"}
+		}
+		return reason, err
+	}
+	if !reason.IsActive {
+		return reason, &BusinessError{Message: "Reason code " + hold.ReasonCode + " is inactive"}
+	}
+
+	switch hold.HoldType {
+	case HoldByItem:
+		if hold.ItemCode == "" {
+			return reason, &BusinessError{Message: "Item code is required for item hold"}
+		}
+	case HoldByLot:
+		if hold.ItemCode == "" || hold.LotNo == "" {
+			return reason, &BusinessError{Message: "Item code and lot no are required for lot hold"}
+		}
+	case HoldByLocation:
+		if hold.Location == "" {
+			return reason, &BusinessError{Message: "Location is required for location hold"}
+		}
+	case HoldByInbound:
+		if hold.InboundNo == "" {
+			return reason, &BusinessError{Message: "Inbound no is required for inbound hold"}
+		}
+		var count int64
+		if err := r.db.Model(&models.InboundHeader{}).Where("inbound_no = ?", hold.InboundNo).Count(&count).Error; err != nil {
+			return reason, err
+		}
+		if count == 0 {
+			return reason, &BusinessError{Message: "Inbound " + hold.InboundNo + " not found"}
+		}
+	case HoldByOwner:
+		if hold.OwnerCode == "" {
+			return reason, &BusinessError{Message: "Owner code is required for owner hold"}
+		}
+	default:
+		return reason, &BusinessError{Message: "Unknown hold type " + hold.HoldType}
+	}
+
+	if hold.ExpiresAt != nil && !hold.ExpiresAt.After(time.Now()) {
+		return reason, &BusinessError{Message: "Expiry must be in the future"}
+	}
+	return reason, nil
+}
+
+// matchQuery inventory dengan qty available yang cocok dengan kriteria hold
+func (r *HoldRepository) matchQuery(hold models.InventoryHold) *gorm.DB {
+	query := r.db.Model(&models.Inventory{}).Where("qty_available > 0")
+	if hold.OwnerCode != "" {
+		query = query.Where("owner_code = ?", hold.OwnerCode)
+	}
+	if hold.WhsCode != "" {
+		query = query.Where("whs_code = ?", hold.WhsCode)
+	}
+	if hold.ItemCode != "" {
+		query = query.Where("item_code = ?", hold.ItemCode)
+	}
+	if hold.LotNo != "" {
+		query = query.Where("lot_no = ?", hold.LotNo)
+	}
+	if hold.Location != "" {
+		query = query.Where("location = ?", hold.Location)
+	}
+	if hold.InboundNo != "" {
+		query = query.Where("inbound_id IN (?)", r.db.Model(&models.InboundHeader{}).Select("id").Where("inbound_no = ?", hold.InboundNo))
+	}
+	return query
+}
+
+// Place menyimpan hold dan memindahkan qty available stock yang cocok ke qty_suspend.
+// Stock yang masuk setelahnya disuspend lewat Apply. Dipanggil di dalam transaction.
+func (r *HoldRepository) Place(hold *models.InventoryHold, userID int) error {
+	reason, err := r.Validate(*hold)
+	if err != nil {
+		return err
+	}
+
+	var inventories []models.Inventory
+	if err := r.matchQuery(*hold).Order("id").Find(&inventories).Error; err != nil {
+		return err
+	}
+	if len(inventories) == 0 {
+		return &BusinessError{Message: "No available stock matches this hold"}
+	}
+
+	if hold.HoldNo, err = r.GenerateHoldNo(hold.OwnerCode, hold.WhsCode); err != nil {
+		return err
+	}
+	hold.Status = HoldActive
+	if reason.RequiresApproval {
+		hold.Status = HoldPending
+	}
+	hold.QtyHeld = 0
+	hold.CreatedBy = userID
+	hold.UpdatedBy = userID
+	if err := r.db.Omit("Details").Create(hold).Error; err != nil {
+		return err
+	}
+
+	for _, inventory := range inventories {
+		qty := inventory.QtyAvailable
+		if err := r.db.Model(&models.Inventory{}).Where("id = ?", inventory.ID).Updates(map[string]interface{}{
+			"qty_available": gorm.Expr("qty_available - ?", qty),
+			"qty_suspend":   gorm.Expr("qty_suspend + ?", qty),
+			"updated_by":    userID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := helpers.InsertInventoryMovement(r.db, inventory, helpers.MovementHold, hold.HoldNo, userID); err != nil {
+			return err
+		}
+
+		if err := r.db.Create(&models.InventoryHoldDetail{
+			HoldID:      hold.ID,
+			InventoryID: int(inventory.ID),
+			ItemCode:    inventory.ItemCode,
+			Location:    inventory.Location,
+			LotNo:       inventory.LotNo,
+			QtyHeld:     qty,
+			CreatedBy:   userID,
+			UpdatedBy:   userID,
+		}).Error; err != nil {
+			return err
+		}
+		hold.QtyHeld += qty
+	}
+
+	return r.db.Model(&models.InventoryHold{}).Where("id = ?", hold.ID).Update("qty_held", hold.QtyHeld).Error
+}
+
+// Apply dipanggil setelah stock masuk atau pindah ke inventory. Qty available yang cocok
+// dengan hold aktif ikut disuspend ke hold paling awal, jadi hold berlaku juga untuk stock
+// yang datang setelah hold dibuat. Dipanggil di dalam transaction.
+func (r *HoldRepository) Apply(stored models.Inventory, userID int) error {
+	var inventory models.Inventory
+	if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).Where("id = ?", stored.ID).First(&inventory).Error; err != nil {
+		return err
+	}
+	if inventory.QtyAvailable <= 0 {
+		return nil
+	}
+
+	var hold models.InventoryHold
+	if err := r.db.Raw(`SELECT h.* FROM inventory_holds h, inventories i
+		WHERE i.id = ? AND `+holdMatchSQL("i")+` ORDER BY h.id LIMIT 1`, inventory.ID, time.Now()).
+		Scan(&hold).Error; err != nil {
+		return err
+	}
+	if hold.ID == 0 {
+		return nil
+	}
+
+	qty := inventory.QtyAvailable
+	if err := r.db.Model(&models.Inventory{}).Where("id = ?", inventory.ID).Updates(map[string]interface{}{
+		"qty_available": gorm.Expr("qty_available - ?", qty),
+		"qty_suspend":   gorm.Expr("qty_suspend + ?", qty),
+		"updated_by":    userID,
+	}).Error; err != nil {
+		return err
+	}
+	if err := helpers.InsertInventoryMovement(r.db, inventory, helpers.MovementHold, hold.HoldNo, userID); err != nil {
+		return err
+	}
+
+	// inventory yang sama bisa kena hold lagi kalau stock putaway digabung ke baris yang sudah ada
+	var detail models.InventoryHoldDetail
+	if err := r.db.Where("hold_id = ? AND inventory_id = ?", hold.ID, inventory.ID).First(&detail).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if detail.ID == 0 {
+		detail = models.InventoryHoldDetail{
+			HoldID:      hold.ID,
+			InventoryID: int(inventory.ID),
+			ItemCode:    inventory.ItemCode,
+			Location:    inventory.Location,
+			LotNo:       inventory.LotNo,
+			CreatedBy:   userID,
+		}
+	}
+	detail.QtyHeld += qty
+	detail.UpdatedBy = userID
+	if err := r.db.Save(&detail).Error; err != nil {
+		return err
+	}
+
+	return r.db.Model(&models.InventoryHold{}).Where("id = ?", hold.ID).Updates(map[string]interface{}{
+		"qty_held":   gorm.Expr("qty_held + ?", qty),
+		"updated_by": userID,
+	}).Error
+}
+
+func (r *HoldRepository) Approve(hold models.InventoryHold, userID int) error {
+	if hold.Status != HoldPending {
+		return &BusinessError{Message: fmt.Sprintf("Hold %s is %s, only pending hold can be approved", hold.HoldNo, hold.Status)}
+	}
+	if hold.CreatedBy == userID {
+		return &BusinessError{Message: "Hold cannot be approved by its creator"}
+	}
+
+	return r.db.Model(&models.InventoryHold{}).Where("id = ?", hold.ID).Updates(map[string]interface{}{
+		"status":      HoldActive,
+		"approved_by": userID,
+		"approved_at": time.Now(),
+		"updated_by":  userID,
+	}).Error
+}
+
+// Release mengembalikan qty yang disuspend hold ke available. Qty suspend yang sudah berkurang
+// (misal diadjust) hanya dikembalikan sisanya. Dipanggil di dalam transaction.
+func (r *HoldRepository) Release(hold models.InventoryHold, remarks string, userID int) error {
+	if hold.Status == HoldReleased {
+		return &BusinessError{Message: "Hold " + hold.HoldNo + " is already released"}
+	}
+
+	var details []models.InventoryHoldDetail
+	if err := r.db.Where("hold_id = ?", hold.ID).Order("id").Find(&details).Error; err != nil {
+		return err
+	}
+
+	for _, detail := range details {
+		var inventory models.Inventory
+		if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).Where("id = ?", detail.InventoryID).First(&inventory).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+
+		qty := detail.QtyHeld - detail.QtyReleased
+		if qty > inventory.QtySuspend {
+			qty = inventory.QtySuspend
+		}
+		if qty <= 0 {
+			continue
+		}
+
+		if err := r.db.Model(&models.Inventory{}).Where("id = ?", inventory.ID).Updates(map[string]interface{}{
+			"qty_available": gorm.Expr("qty_available + ?", qty),
+			"qty_suspend":   gorm.Expr("qty_suspend - ?", qty),
+			"updated_by":    userID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := helpers.InsertInventoryMovement(r.db, inventory, helpers.MovementHoldRelease, hold.HoldNo, userID); err != nil {
+			return err
+		}
+		if err := r.db.Model(&models.InventoryHoldDetail{}).Where("id = ?", detail.ID).Updates(map[string]interface{}{
+			"qty_released": detail.QtyReleased + qty,
+			"updated_by":   userID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return r.db.Model(&models.InventoryHold{}).Where("id = ?", hold.ID).Updates(map[string]interface{}{
+		"status":          HoldReleased,
+		"released_by":     userID,
+		"released_at":     time.Now(),
+		"release_remarks": remarks,
+		"updated_by":      userID,
+	}).Error
+}
+
+// ReleaseExpired release otomatis hold yang sudah lewat expires_at
+func (r *HoldRepository) ReleaseExpired(userID int) (int, error) {
+	var holds []models.InventoryHold
+	if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).
+		Where("status IN ? AND expires_at IS NOT NULL AND expires_at <= ?", []string{HoldPending, HoldActive}, time.Now()).
+		Find(&holds).Error; err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, hold := range holds {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			return NewHoldRepository(tx.Set(helpers.OwnerScopeKey, []string(nil))).Release(hold, "expired", userID)
+		})
+		if err != nil {
+			// satu hold gagal tidak menahan hold lain, dicoba lagi di run berikutnya
+			log.Println("Hold expiry: gagal release", hold.HoldNo, ":", err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (r *HoldRepository) GetHoldList(status string) ([]HoldList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+	sql := `SELECT a.*, r.description AS reason_description, COALESCE(d.qty_released, 0) AS qty_released
+	FROM inventory_holds a
+	LEFT JOIN hold_reasons r ON r.reason_code = a.reason_code AND r.deleted_at IS NULL
+	LEFT JOIN (SELECT hold_id, SUM(qty_released) AS qty_released FROM inventory_hold_details
+		WHERE deleted_at IS NULL GROUP BY hold_id) d ON d.hold_id = a.id
+	WHERE a.deleted_at IS NULL` + ownerSQL
+	args := ownerArgs
+	if status != "" {
+		sql += " AND a.status = ?"
+		args = append(args, status)
+	}
+	sql += " ORDER BY a.id DESC"
+
+	var list []HoldList
+	if err := r.db.Raw(sql, args...).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
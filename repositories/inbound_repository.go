@@ -2,9 +2,9 @@ package repositories
 
 import (
 	"errors"
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
@@ -215,6 +215,7 @@ func (r *InboundRepository) UpdateInboundDetail(data *models.InboundDetail, hand
 }
 
 func (r *InboundRepository) GetAllInbound() ([]ListInbound, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 	var listInbound []ListInbound
 	sql := `WITH detail AS (
 				SELECT inbound_id, COUNT(item_code) as total_line,SUM(quantity) total_qty 
@@ -238,9 +239,10 @@ func (r *InboundRepository) GetAllInbound() ([]ListInbound, error) {
 			LEFT JOIN suppliers c ON a.supplier = c.supplier_code
 			LEFT JOIN transporters d ON a.transporter = d.transporter_code
 			LEFT JOIN inbound_barcode ib ON a.id = ib.inbound_id
+			WHERE 1=1` + ownerSQL + `
 			ORDER BY a.created_at DESC`
 
-	if err := r.db.Raw(sql).Scan(&listInbound).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&listInbound).Error; err != nil {
 		return nil, err
 	}
 
@@ -264,6 +266,7 @@ func (r *InboundRepository) GetAllInbound() ([]ListInbound, error) {
 }
 
 func (r *InboundRepository) GetInboundHeaderByInboundID(inbound_id int) (HeaderInbound, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
 	var result HeaderInbound
 
@@ -283,9 +286,9 @@ func (r *InboundRepository) GetInboundHeaderByInboundID(inbound_id int) (HeaderI
 	inbound_headers a
 	LEFT JOIN detail b ON a.id = b.inbound_id
 	LEFT JOIN suppliers c ON a.supplier_id = c.id
-	WHERE a.id = ?`
+	WHERE a.id = ?` + ownerSQL
 
-	if err := r.db.Raw(sql, inbound_id).Scan(&result).Error; err != nil {
+	if err := r.db.Raw(sql, append([]interface{}{inbound_id}, ownerArgs...)...).Scan(&result).Error; err != nil {
 		return result, err
 	}
 
@@ -293,6 +296,7 @@ func (r *InboundRepository) GetInboundHeaderByInboundID(inbound_id int) (HeaderI
 }
 
 func (r *InboundRepository) GetDetailItemByInboundID(inbound_id int) ([]models.FormItemInbound, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 	var result []models.FormItemInbound
 
 	sql := `SELECT 
@@ -317,10 +321,10 @@ func (r *InboundRepository) GetDetailItemByInboundID(inbound_id int) ([]models.F
         INNER JOIN inbound_details b ON a.id = b.inbound_id
 		INNER JOIN products p on p.id = b.item_id
 		LEFT JOIN handlings c ON b.handling_id = c.id
-        WHERE a.id = ?
+        WHERE a.id = ?` + ownerSQL + `
 		ORDER BY b.id ASC`
 
-	if err := r.db.Debug().Raw(sql, inbound_id).Scan(&result).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, append([]interface{}{inbound_id}, ownerArgs...)...).Scan(&result).Error; err != nil {
 		return nil, err
 	}
 
@@ -396,6 +400,7 @@ type InboundBarcode struct {
 }
 
 func (r *InboundRepository) GetInboundBarcode(inbound_id int) ([]InboundBarcode, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "c.owner_code")
 
 	var result []InboundBarcode
 	sql := `select a.id, a.inbound_id, c.code as inbound_no, a.inbound_detail_id,
@@ -404,8 +409,8 @@ func (r *InboundRepository) GetInboundBarcode(inbound_id int) ([]InboundBarcode,
 	from inbound_barcodes a
 	inner join products b ON a.item_code = b.item_code
 	inner join inbound_headers c ON a.inbound_id = c.id
-	WHERE inbound_id = ?`
-	if err := r.db.Raw(sql, inbound_id).Scan(&result).Error; err != nil {
+	WHERE inbound_id = ?` + ownerSQL
+	if err := r.db.Raw(sql, append([]interface{}{inbound_id}, ownerArgs...)...).Scan(&result).Error; err != nil {
 		return result, err
 	}
 
@@ -413,6 +418,7 @@ func (r *InboundRepository) GetInboundBarcode(inbound_id int) ([]InboundBarcode,
 }
 
 func (r *InboundRepository) GetInboundBarcodeDetail(inbound_id int, inbound_detail_id int) ([]InboundBarcode, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "c.owner_code")
 
 	var result []InboundBarcode
 	sql := `select a.id, a.inbound_id, c.inbound_no, a.inbound_detail_id,
@@ -421,8 +427,8 @@ func (r *InboundRepository) GetInboundBarcodeDetail(inbound_id int, inbound_deta
 	from inbound_barcodes a
 	inner join products b ON a.item_code = b.item_code
 	inner join inbound_headers c ON a.inbound_id = c.id
-	WHERE inbound_id = ? AND a.inbound_detail_id = ?`
-	if err := r.db.Raw(sql, inbound_id, inbound_detail_id).Scan(&result).Error; err != nil {
+	WHERE inbound_id = ? AND a.inbound_detail_id = ?` + ownerSQL
+	if err := r.db.Raw(sql, append([]interface{}{inbound_id, inbound_detail_id}, ownerArgs...)...).Scan(&result).Error; err != nil {
 		return result, err
 	}
 
@@ -442,6 +448,7 @@ type InboundBarcodeScanned struct {
 }
 
 func (r *InboundRepository) GetAllInboundScannedByInboundID(inbound_id int) ([]InboundBarcodeScanned, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "i.owner_code")
 	sqlSelect := `WITH barcode AS (
     SELECT inbound_id, inbound_detail_id, item_code, barcode, SUM(quantity) as qty_scan
     FROM inbound_barcodes
@@ -463,47 +470,18 @@ func (r *InboundRepository) GetAllInboundScannedByInboundID(inbound_id int) ([]I
 	INNER JOIN inbound_headers i ON a.inbound_id = i.id
 	LEFT JOIN barcode b ON a.inbound_id = b.inbound_id AND a.id = b.inbound_detail_id
 	LEFT JOIN products c ON a.item_code = c.item_code
-	WHERE a.inbound_id = ?
+	WHERE a.inbound_id = ?` + ownerSQL + `
 `
 	var result []InboundBarcodeScanned
-	if err := r.db.Raw(sqlSelect, inbound_id, inbound_id).Scan(&result).Error; err != nil {
+	if err := r.db.Raw(sqlSelect, append([]interface{}{inbound_id, inbound_id}, ownerArgs...)...).Scan(&result).Error; err != nil {
 		return result, err
 	}
 	return result, nil
 }
 
-func (r *InboundRepository) GenerateInboundNo() (string, error) {
-	var lastInbound models.InboundHeader
-
-	// Ambil inbound terakhir
-	if err := r.db.Last(&lastInbound).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return "", err
-	}
-
-	// Ambil tanggal sekarang dalam format YYMMDD
-	now := time.Now()
-	currentDate := now.Format("060102") // 06=YY, 01=MM, 02=DD
-
-	// Generate nomor inbound baru
-	var inboundNo string
-	if lastInbound.InboundNo != "" && len(lastInbound.InboundNo) >= 12 {
-		lastDatePart := lastInbound.InboundNo[2:8]
-		lastSequenceStr := lastInbound.InboundNo[len(lastInbound.InboundNo)-4:]
-
-		if currentDate != lastDatePart {
-			// Tanggal berbeda → reset sequence ke 1
-			inboundNo = fmt.Sprintf("IN%s%04d", currentDate, 1)
-		} else {
-			// Tanggal sama → increment sequence
-			lastSequenceInt, _ := strconv.Atoi(lastSequenceStr)
-			inboundNo = fmt.Sprintf("IN%s%04d", currentDate, lastSequenceInt+1)
-		}
-	} else {
-		// Tidak ada record sebelumnya → mulai dari 1
-		inboundNo = fmt.Sprintf("IN%s%04d", currentDate, 1)
-	}
-
-	return inboundNo, nil
+// GenerateInboundNo membuat nomor inbound lewat numbering service, default <prefix owner><YYMMDD><seq>
+func (r *InboundRepository) GenerateInboundNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocInbound, ownerCode, whsCode, "")
 }
 
 func (r *InboundRepository) PutawayItem(ctx *fiber.Ctx, inboundBarcodeID int, location string) (bool, error) {
@@ -539,21 +517,40 @@ func (r *InboundRepository) PutawayItem(ctx *fiber.Ctx, inboundBarcodeID int, lo
 		}
 		qtyConverted := uomConversion.QtyConverted
 
+		// tolak kalau lokasi tujuan tidak terdaftar, penuh atau tidak cocok
+		locationRepo := NewLocationRepository(tx)
+		targetLocation, err := locationRepo.ValidateLocation(location, barcode.WhsCode, barcode.OwnerCode)
+		if err != nil {
+			return err
+		}
+		load, err := locationRepo.NewPutawayLoad(barcode.ItemCode, barcode.LotNo, barcode.Pallet, barcode.QaStatus, qtyConverted)
+		if err != nil {
+			return err
+		}
+		if err := locationRepo.ValidatePutaway(targetLocation, load); err != nil {
+			return err
+		}
+
 		// Cek apakah data inventory dengan kombinasi yang sama sudah ada
 		var existingInv models.Inventory
+		var stored models.Inventory
 		invQuery := tx.Debug().Where(`
 			inbound_detail_id = ? AND
 			item_code = ? AND
 			location = ? AND
 			barcode = ? AND
 			whs_code = ? AND
-			qa_status = ?`,
+			qa_status = ? AND
+			COALESCE(lot_no, '') = ? AND
+			COALESCE(exp_date, '') = ?`,
 			int(detail.ID),
 			barcode.ItemCode,
 			location,
 			barcode.Barcode,
 			barcode.WhsCode,
 			barcode.QaStatus,
+			barcode.LotNo,
+			barcode.ExpDate,
 		).First(&existingInv)
 
 		if errors.Is(invQuery.Error, gorm.ErrRecordNotFound) {
@@ -562,6 +559,9 @@ func (r *InboundRepository) PutawayItem(ctx *fiber.Ctx, inboundBarcodeID int, lo
 				InboundID:       detail.InboundId,
 				InboundDetailId: int(detail.ID),
 				RecDate:         detail.RecDate,
+				LotNo:           barcode.LotNo,
+				MfgDate:         barcode.MfgDate,
+				ExpDate:         barcode.ExpDate,
 				ItemId:          int(barcode.ItemID),
 				ItemCode:        barcode.ItemCode,
 				Barcode:         barcode.Barcode,
@@ -582,8 +582,14 @@ func (r *InboundRepository) PutawayItem(ctx *fiber.Ctx, inboundBarcodeID int, lo
 			if err := tx.Create(&newInv).Error; err != nil {
 				return err
 			}
+
+			if err := helpers.InsertInventoryMovement(tx, models.Inventory{ID: newInv.ID}, helpers.MovementPutaway, detail.InboundNo, int(userID)); err != nil {
+				return err
+			}
+			stored = newInv
 		} else if invQuery.Error == nil {
 			// Sudah ada → Update qty
+			before := existingInv
 			if err := tx.Model(&existingInv).Updates(map[string]interface{}{
 				"qty_origin":    existingInv.QtyOrigin + qtyConverted,
 				"qty_onhand":    existingInv.QtyOnhand + qtyConverted,
@@ -593,10 +599,25 @@ func (r *InboundRepository) PutawayItem(ctx *fiber.Ctx, inboundBarcodeID int, lo
 			}).Error; err != nil {
 				return err
 			}
+
+			if err := helpers.InsertInventoryMovement(tx, before, helpers.MovementPutaway, detail.InboundNo, int(userID)); err != nil {
+				return err
+			}
+			stored = before
 		} else {
 			return invQuery.Error
 		}
 
+		if err := NewHoldRepository(tx).Apply(stored, int(userID)); err != nil {
+			return err
+		}
+
+		if barcode.ScanType == "SERIAL" {
+			if err := NewSerialRepository(tx).Putaway(barcode, stored, detail.InboundNo, int(userID)); err != nil {
+				return err
+			}
+		}
+
 		// Update status barcode ke "in stock"
 		if err := tx.Model(&barcode).Updates(map[string]interface{}{
 			"status":     "in stock",
@@ -606,6 +627,17 @@ func (r *InboundRepository) PutawayItem(ctx *fiber.Ctx, inboundBarcodeID int, lo
 			return err
 		}
 
+		// task putaway selesai kalau semua barcode inbound sudah masuk stock
+		var pending int64
+		if err := tx.Model(&models.InboundBarcode{}).Where("inbound_id = ? AND status = ?", barcode.InboundId, "pending").Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending == 0 {
+			if err := NewTaskRepository(tx).CloseTask(TaskPutaway, detail.InboundNo, int(userID)); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 
@@ -615,3 +647,54 @@ func (r *InboundRepository) PutawayItem(ctx *fiber.Ctx, inboundBarcodeID int, lo
 
 	return true, nil
 }
+
+// SuggestPutaway mengusulkan lokasi tujuan untuk setiap inbound barcode yang masih pending
+func (r *InboundRepository) SuggestPutaway(inboundID int) ([]PutawaySuggestion, error) {
+	var barcodes []models.InboundBarcode
+	if err := r.db.Where("inbound_id = ? AND status = ?", inboundID, "pending").Order("id").Find(&barcodes).Error; err != nil {
+		return nil, err
+	}
+
+	uomRepo := NewUomRepository(r.db)
+	locationRepo := NewLocationRepository(r.db)
+
+	loads := make([]PutawayLoad, len(barcodes))
+	for i, barcode := range barcodes {
+		var detail models.InboundDetail
+		if err := r.db.Where("id = ?", barcode.InboundDetailId).Take(&detail).Error; err != nil {
+			return nil, errors.New("inbound detail not found for item: " + barcode.ItemCode)
+		}
+
+		uomConversion, err := uomRepo.ConversionQty(barcode.ItemCode, barcode.Quantity, detail.Uom)
+		if err != nil {
+			return nil, err
+		}
+
+		if loads[i], err = locationRepo.NewPutawayLoad(barcode.ItemCode, barcode.LotNo, barcode.Pallet, barcode.QaStatus, uomConversion.QtyConverted); err != nil {
+			return nil, err
+		}
+		loads[i].WhsCode = barcode.WhsCode
+		loads[i].OwnerCode = barcode.OwnerCode
+	}
+
+	targets, err := locationRepo.SuggestPutaway(loads)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]PutawaySuggestion, len(barcodes))
+	for i, barcode := range barcodes {
+		suggestions[i] = PutawaySuggestion{
+			InboundBarcodeID: int(barcode.ID),
+			ItemCode:         barcode.ItemCode,
+			Pallet:           barcode.Pallet,
+			Quantity:         loads[i].Quantity,
+			CurrentLocation:  barcode.Location,
+			Location:         targets[i],
+		}
+		if targets[i] == "" {
+			suggestions[i].Reason = "No location with enough capacity"
+		}
+	}
+	return suggestions, nil
+}
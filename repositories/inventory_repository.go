@@ -1,6 +1,11 @@
 package repositories
 
 import (
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -19,6 +24,9 @@ type listInventory struct {
 	Barcode      string  `json:"barcode"`
 	OwnerCode    string  `json:"owner_code"`
 	RecDate      string  `json:"rec_date"`
+	LotNo        string  `json:"lot_no"`
+	MfgDate      string  `json:"mfg_date"`
+	ExpDate      string  `json:"exp_date"`
 	Category     string  `json:"category"`
 	WhsCode      string  `json:"whs_code"`
 	QaStatus     string  `json:"qa_status"`
@@ -32,8 +40,10 @@ type listInventory struct {
 }
 
 func (r *InventoryRepository) GetInventory() ([]listInventory, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
-	sqlInventory := `select a.whs_code, a.location, a.barcode, a.owner_code, a.rec_date, b.category,
+	sqlInventory := `select a.whs_code, a.location, a.barcode, a.owner_code, a.rec_date,
+	a.lot_no, a.mfg_date, a.exp_date, b.category,
 	b.item_code, b.item_name, a.qa_status,
 	sum(a.qty_origin) as qty_in,
 	sum(a.qty_onhand) as qty_onhand,
@@ -45,13 +55,14 @@ func (r *InventoryRepository) GetInventory() ([]listInventory, error) {
 	from inventories a
 	inner join products b on a.item_id = b.id
 	-- where a.qty_available > 0 or a.qty_allocated > 0
-	where a.qty_origin > 0
+	where a.qty_origin > 0` + ownerSQL + `
 	group by a.whs_code, a.location, b.item_code, b.item_name, a.qa_status,
-	a.barcode, a.owner_code, a.rec_date, b.category, a.inbound_detail_id, b.cbm`
+	a.barcode, a.owner_code, a.rec_date, a.lot_no, a.mfg_date, a.exp_date,
+	b.category, a.inbound_detail_id, b.cbm`
 
 	var inventories []listInventory
 
-	if err := r.db.Raw(sqlInventory).Scan(&inventories).Error; err != nil {
+	if err := r.db.Raw(sqlInventory, ownerArgs...).Scan(&inventories).Error; err != nil {
 		return nil, err
 	}
 
@@ -63,8 +74,10 @@ func (r *InventoryRepository) GetInventory() ([]listInventory, error) {
 }
 
 func (r *InventoryRepository) GetInventoryByInbound(inbound_id int) ([]listInventory, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
-	sqlInventory := `select a.whs_code, a.location, a.barcode, a.owner_code, a.rec_date, b.category,
+	sqlInventory := `select a.whs_code, a.location, a.barcode, a.owner_code, a.rec_date,
+	a.lot_no, a.mfg_date, a.exp_date, b.category,
 	b.item_code, b.item_name, a.qa_status,
 	sum(a.qty_origin) as qty_in,
 	sum(a.qty_onhand) as qty_onhand,
@@ -77,13 +90,14 @@ func (r *InventoryRepository) GetInventoryByInbound(inbound_id int) ([]listInven
 	inner join products b on a.item_id = b.id
 	WHERE 
 	a.inbound_id = ?
-	AND a.qty_origin > 0
+	AND a.qty_origin > 0` + ownerSQL + `
 	group by a.whs_code, a.location, b.item_code, b.item_name, a.qa_status,
-	a.barcode, a.owner_code, a.rec_date, b.category, a.inbound_detail_id, b.cbm`
+	a.barcode, a.owner_code, a.rec_date, a.lot_no, a.mfg_date, a.exp_date,
+	b.category, a.inbound_detail_id, b.cbm`
 
 	var inventories []listInventory
 
-	if err := r.db.Raw(sqlInventory, inbound_id).Scan(&inventories).Error; err != nil {
+	if err := r.db.Raw(sqlInventory, append([]interface{}{inbound_id}, ownerArgs...)...).Scan(&inventories).Error; err != nil {
 		return nil, err
 	}
 
@@ -94,6 +108,112 @@ func (r *InventoryRepository) GetInventoryByInbound(inbound_id int) ([]listInven
 	return inventories, nil
 }
 
+// TransferQty memindahkan qty available dari satu inventory ke lokasi lain (pallet = lokasi tujuan)
+// dan mencatat movement transfer out/in. Dipakai transfer RF dan replenishment.
+func (r *InventoryRepository) TransferQty(inventory models.Inventory, toLocation string, qty int, refNo string, userID int) (models.Inventory, error) {
+	var newInventory models.Inventory
+	newInventory.OwnerCode = inventory.OwnerCode
+	newInventory.DivisionCode = inventory.DivisionCode
+	newInventory.Uom = inventory.Uom
+	newInventory.InboundID = inventory.InboundID
+	newInventory.InboundDetailId = inventory.InboundDetailId
+	newInventory.RecDate = inventory.RecDate
+	newInventory.LotNo = inventory.LotNo
+	newInventory.MfgDate = inventory.MfgDate
+	newInventory.ExpDate = inventory.ExpDate
+	newInventory.ItemId = inventory.ItemId
+	newInventory.ItemCode = inventory.ItemCode
+	newInventory.Barcode = inventory.Barcode
+	newInventory.WhsCode = inventory.WhsCode
+	newInventory.Pallet = toLocation
+	newInventory.Location = toLocation
+	newInventory.QaStatus = inventory.QaStatus
+	newInventory.QtyOrigin = qty
+	newInventory.QtyOnhand = qty
+	newInventory.QtyAvailable = qty
+	newInventory.Trans = fmt.Sprintf("transfer from inventory_id : %d", inventory.ID)
+	newInventory.IsTransfer = true
+	newInventory.TransferFrom = inventory.ID
+	newInventory.CreatedAt = time.Now()
+	newInventory.CreatedBy = userID
+
+	if err := r.db.Create(&newInventory).Error; err != nil {
+		return newInventory, err
+	}
+
+	if err := helpers.InsertInventoryMovement(r.db, models.Inventory{ID: newInventory.ID}, helpers.MovementTransferIn, refNo, userID); err != nil {
+		return newInventory, err
+	}
+
+	if err := r.db.Model(&models.Inventory{}).Where("id = ?", inventory.ID).Updates(map[string]interface{}{
+		"qty_origin":    gorm.Expr("qty_origin - ?", qty),
+		"qty_onhand":    gorm.Expr("qty_onhand - ?", qty),
+		"qty_available": gorm.Expr("qty_available - ?", qty),
+		"updated_at":    time.Now(),
+		"updated_by":    userID,
+	}).Error; err != nil {
+		return newInventory, err
+	}
+
+	if err := helpers.InsertInventoryMovement(r.db, inventory, helpers.MovementTransferOut, refNo, userID); err != nil {
+		return newInventory, err
+	}
+
+	if err := NewSerialRepository(r.db).Move(inventory, newInventory, qty, refNo, userID); err != nil {
+		return newInventory, err
+	}
+
+	if err := NewHoldRepository(r.db).Apply(newInventory, userID); err != nil {
+		return newInventory, err
+	}
+
+	return newInventory, nil
+}
+
+type InvalidLocationStock struct {
+	Location       string `json:"location"`
+	LocationStatus string `json:"location_status"`
+	WhsCode        string `json:"whs_code"`
+	OwnerCode      string `json:"owner_code"`
+	Pallet         string `json:"pallet"`
+	ItemCode       string `json:"item_code"`
+	ItemName       string `json:"item_name"`
+	QaStatus       string `json:"qa_status"`
+	QtyOnhand      int    `json:"qty_onhand"`
+	QtyAvailable   int    `json:"qty_available"`
+	QtyAllocated   int    `json:"qty_allocated"`
+}
+
+// GetInventoryInvalidLocation stok onhand yang berada di lokasi tidak terdaftar atau tidak aktif
+func (r *InventoryRepository) GetInventoryInvalidLocation() ([]InvalidLocationStock, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+
+	sql := `select a.location,
+	case when l.id is null then 'unknown' else 'inactive' end as location_status,
+	a.whs_code, a.owner_code, a.pallet, a.item_code, b.item_name, a.qa_status,
+	sum(a.qty_onhand) as qty_onhand,
+	sum(a.qty_available) as qty_available,
+	sum(a.qty_allocated) as qty_allocated
+	from inventories a
+	left join products b on a.item_id = b.id
+	left join locations l on a.location = l.location_code and l.deleted_at is null
+	where a.qty_onhand > 0 and a.deleted_at is null
+	and (l.id is null or l.is_active = ?)` + ownerSQL + `
+	group by a.location, l.id, a.whs_code, a.owner_code, a.pallet, a.item_code, b.item_name, a.qa_status
+	order by a.location, a.item_code`
+
+	var result []InvalidLocationStock
+	if err := r.db.Raw(sql, append([]interface{}{false}, ownerArgs...)...).Scan(&result).Error; err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		result = []InvalidLocationStock{}
+	}
+
+	return result, nil
+}
+
 type StockOnHand struct {
 	InventoryID       int    `json:"inventory_id"`
 	InventoryDetailID int    `json:"inventory_detail_id"`
@@ -112,6 +232,8 @@ type StockOnHand struct {
 
 func (r *InventoryRepository) GetStockOnHand() ([]StockOnHand, error) {
 	var stockOnHand []StockOnHand
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "b.owner_code")
+
 	sql := `with ob_cte as 
 	(
 		select inventory_detail_id, sum(quantity) as picked from outbound_barcodes
@@ -124,9 +246,10 @@ func (r *InventoryRepository) GetStockOnHand() ([]StockOnHand, error) {
 	inventory_details a
 	inner join inventories b on a.inventory_id = b.id
 	inner join inbound_details c on a.inbound_detail_id = c.id
-	left join ob_cte d on a.id = d.inventory_detail_id`
+	left join ob_cte d on a.id = d.inventory_detail_id
+	where 1=1` + ownerSQL
 
-	if err := r.db.Raw(sql).Scan(&stockOnHand).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&stockOnHand).Error; err != nil {
 		return nil, err
 	}
 
@@ -150,6 +273,7 @@ type ResGetStockByRequest struct {
 
 func (r *InventoryRepository) GetStockByRequest(inbound_id int) ([]ResGetStockByRequest, error) {
 	var stock []ResGetStockByRequest
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
 	sql := `with obd AS(
 	select item_id from outbound_details where outbound_id = ?)
@@ -158,12 +282,133 @@ func (r *InventoryRepository) GetStockByRequest(inbound_id int) ([]ResGetStockBy
 	a.quantity - coalesce(b.quantity, 0) as available
 	from inventories a
 	left join picking_sheets b on a.id = b.inventory_id
-	where a.item_id IN (select item_id from obd)
+	where a.item_id IN (select item_id from obd)` + ownerSQL + `
 	order by rec_date desc`
 
-	if err := r.db.Raw(sql, inbound_id).Scan(&stock).Error; err != nil {
+	if err := r.db.Raw(sql, append([]interface{}{inbound_id}, ownerArgs...)...).Scan(&stock).Error; err != nil {
 		return nil, err
 	}
 
 	return stock, nil
 }
+
+type StockCardFilter struct {
+	ItemCode  string
+	Location  string
+	WhsCode   string
+	OwnerCode string
+	From      time.Time
+	To        time.Time
+}
+
+type StockCardLine struct {
+	MovementID      int64     `json:"movement_id"`
+	Date            time.Time `json:"date"`
+	MovementType    string    `json:"movement_type"`
+	RefNo           string    `json:"ref_no"`
+	InventoryID     int       `json:"inventory_id"`
+	Location        string    `json:"location"`
+	Pallet          string    `json:"pallet"`
+	WhsCode         string    `json:"whs_code"`
+	QaStatus        string    `json:"qa_status"`
+	LotNo           string    `json:"lot_no"`
+	QtyIn           int       `json:"qty_in"`
+	QtyOut          int       `json:"qty_out"`
+	AllocatedChange int       `json:"allocated_change"`
+	Balance         int       `json:"balance"`
+	CreatedBy       int       `json:"created_by"`
+}
+
+type StockCard struct {
+	ItemCode       string          `json:"item_code"`
+	Location       string          `json:"location"`
+	WhsCode        string          `json:"whs_code"`
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	OpeningBalance int             `json:"opening_balance"`
+	TotalIn        int             `json:"total_in"`
+	TotalOut       int             `json:"total_out"`
+	ClosingBalance int             `json:"closing_balance"`
+	Lines          []StockCardLine `json:"lines"`
+}
+
+func (r *InventoryRepository) movementQuery(filter StockCardFilter) *gorm.DB {
+	query := r.db.Model(&models.InventoryMovement{}).Where("item_code = ?", filter.ItemCode)
+	if filter.Location != "" {
+		query = query.Where("location = ?", filter.Location)
+	}
+	if filter.WhsCode != "" {
+		query = query.Where("whs_code = ?", filter.WhsCode)
+	}
+	if filter.OwnerCode != "" {
+		query = query.Where("owner_code = ?", filter.OwnerCode)
+	}
+	return query
+}
+
+// GetStockCard menghitung kartu stock (qty onhand) dari ledger movement untuk periode From s/d To
+func (r *InventoryRepository) GetStockCard(filter StockCardFilter) (StockCard, error) {
+	// To inklusif sampai akhir hari
+	toExclusive := filter.To.AddDate(0, 0, 1)
+
+	card := StockCard{
+		ItemCode: filter.ItemCode,
+		Location: filter.Location,
+		WhsCode:  filter.WhsCode,
+		From:     filter.From.Format("2006-01-02"),
+		To:       filter.To.Format("2006-01-02"),
+		Lines:    []StockCardLine{},
+	}
+
+	var opening struct {
+		Total int
+	}
+	if err := r.movementQuery(filter).
+		Select("COALESCE(SUM(qty_change), 0) AS total").
+		Where("created_at < ?", filter.From).
+		Scan(&opening).Error; err != nil {
+		return card, err
+	}
+	card.OpeningBalance = opening.Total
+
+	var movements []models.InventoryMovement
+	if err := r.movementQuery(filter).
+		Where("created_at >= ? AND created_at < ?", filter.From, toExclusive).
+		Order("created_at ASC, id ASC").
+		Find(&movements).Error; err != nil {
+		return card, err
+	}
+
+	balance := card.OpeningBalance
+	for _, m := range movements {
+		line := StockCardLine{
+			MovementID:      m.ID,
+			Date:            m.CreatedAt,
+			MovementType:    m.MovementType,
+			RefNo:           m.RefNo,
+			InventoryID:     m.InventoryID,
+			Location:        m.Location,
+			Pallet:          m.Pallet,
+			WhsCode:         m.WhsCode,
+			QaStatus:        m.QaStatus,
+			LotNo:           m.LotNo,
+			AllocatedChange: m.QtyAllocatedAfter - m.QtyAllocatedBefore,
+			CreatedBy:       m.CreatedBy,
+		}
+
+		if m.QtyChange > 0 {
+			line.QtyIn = m.QtyChange
+			card.TotalIn += m.QtyChange
+		} else {
+			line.QtyOut = -m.QtyChange
+			card.TotalOut += -m.QtyChange
+		}
+
+		balance += m.QtyChange
+		line.Balance = balance
+		card.Lines = append(card.Lines, line)
+	}
+	card.ClosingBalance = balance
+
+	return card, nil
+}
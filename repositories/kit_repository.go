@@ -0,0 +1,390 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type KitRepository struct {
+	db *gorm.DB
+}
+
+func NewKitRepository(db *gorm.DB) *KitRepository {
+	return &KitRepository{db}
+}
+
+const (
+	KitAssembly    = "assembly"
+	KitDisassembly = "disassembly"
+
+	KitOrderOpen     = "open"
+	KitOrderComplete = "complete"
+	KitOrderCancel   = "cancel"
+
+	KitConsume = "consume"
+	KitProduce = "produce"
+)
+
+type KitOrderList struct {
+	ID          uint       `json:"ID"`
+	KitOrderNo  string     `json:"kit_order_no"`
+	OrderType   string     `json:"order_type"`
+	KitItemCode string     `json:"kit_item_code"`
+	ItemName    string     `json:"item_name"`
+	OwnerCode   string     `json:"owner_code"`
+	WhsCode     string     `json:"whs_code"`
+	Quantity    int        `json:"quantity"`
+	Location    string     `json:"location"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompleteAt  *time.Time `json:"complete_at"`
+	TotalVas    float64    `json:"total_vas"`
+}
+
+// kitLine satu item yang dipakai atau dihasilkan oleh kit order
+type kitLine struct {
+	ItemID   int
+	ItemCode string
+	Quantity int
+}
+
+func (r *KitRepository) GenerateKitOrderNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocKitOrder, ownerCode, whsCode, "")
+}
+
+// GetBom mengambil bom aktif beserta komponennya
+func (r *KitRepository) GetBom(kitItemCode string) (models.BomHeader, error) {
+	var bom models.BomHeader
+	if err := r.db.Preload("Components").Where("kit_item_code = ?", kitItemCode).First(&bom).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bom, &BusinessError{Message: "BOM for kit item " + kitItemCode + " not found"}
+		}
+		return bom, err
+	}
+	if !bom.IsActive {
+		return bom, &BusinessError{Message: "BOM for kit item " + kitItemCode + " is inactive"}
+	}
+	if len(bom.Components) == 0 {
+		return bom, &BusinessError{Message: "BOM for kit item " + kitItemCode + " has no components"}
+	}
+	return bom, nil
+}
+
+func (r *KitRepository) GetKitOrderList(status string) ([]KitOrderList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "k.owner_code")
+
+	sql := `WITH v AS (
+		SELECT kit_order_id, SUM(total_price) AS total_vas
+		FROM kit_order_vas
+		WHERE deleted_at IS NULL
+		GROUP BY kit_order_id
+	)
+	SELECT k.id, k.kit_order_no, k.order_type, k.kit_item_code, p.item_name, k.owner_code,
+	k.whs_code, k.quantity, k.location, k.status, k.created_at, k.complete_at,
+	COALESCE(v.total_vas, 0) AS total_vas
+	FROM kit_orders k
+	LEFT JOIN products p ON k.kit_item_id = p.id
+	LEFT JOIN v ON k.id = v.kit_order_id
+	WHERE k.deleted_at IS NULL` + ownerSQL
+
+	args := ownerArgs
+	if status != "" {
+		sql += " AND k.status = ?"
+		args = append(args, status)
+	}
+	sql += " ORDER BY k.id DESC"
+
+	var list []KitOrderList
+	if err := r.db.Raw(sql, args...).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// CompleteOrder menjalankan kit order: stock input dikurangi, stock hasil dibuat di lokasi order,
+// semua perubahan dicatat ke movement ledger dan baris billing VAS dibuat dari bom.
+// Panggil dengan repository di dalam transaction.
+func (r *KitRepository) CompleteOrder(order models.KitOrder, userID int) error {
+	if order.Status != KitOrderOpen {
+		return &BusinessError{Message: "Kit order " + order.KitOrderNo + " is " + order.Status}
+	}
+
+	var bom models.BomHeader
+	if err := r.db.Preload("Components").Where("id = ?", order.BomID).First(&bom).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &BusinessError{Message: "BOM for kit item " + order.KitItemCode + " not found"}
+		}
+		return err
+	}
+
+	kit := kitLine{ItemID: order.KitItemID, ItemCode: order.KitItemCode, Quantity: order.Quantity}
+	var components []kitLine
+	for _, component := range bom.Components {
+		components = append(components, kitLine{
+			ItemID:   component.ItemID,
+			ItemCode: component.ItemCode,
+			Quantity: component.Quantity * order.Quantity,
+		})
+	}
+
+	consumes, produces := components, []kitLine{kit}
+	if order.OrderType == KitDisassembly {
+		consumes, produces = []kitLine{kit}, components
+	}
+
+	// status dipindah duluan dengan syarat masih open, complete bersamaan tidak mengonsumsi stock dua kali
+	result := r.db.Model(&models.KitOrder{}).Where("id = ? AND status = ?", order.ID, KitOrderOpen).Updates(map[string]interface{}{
+		"status":      KitOrderComplete,
+		"complete_at": time.Now(),
+		"complete_by": userID,
+		"updated_by":  userID,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return &BusinessError{Message: "Kit order " + order.KitOrderNo + " is already being completed", Status: fiber.StatusConflict}
+	}
+
+	// semua input dicek dulu supaya pesan error menyebut semua item yang kurang sekaligus
+	var shortages []string
+	for _, line := range consumes {
+		available, err := r.availableQty(order, line.ItemID)
+		if err != nil {
+			return err
+		}
+		if available < line.Quantity {
+			shortages = append(shortages, fmt.Sprintf("%s (need %d, available %d)", line.ItemCode, line.Quantity, available))
+		}
+	}
+	if len(shortages) > 0 {
+		return &BusinessError{Message: fmt.Sprintf("Insufficient stock for kit order %s: %v", order.KitOrderNo, shortages)}
+	}
+
+	location, err := NewLocationRepository(r.db).ValidateLocation(order.Location, order.WhsCode, order.OwnerCode)
+	if err != nil {
+		return err
+	}
+
+	var consumed []models.Inventory
+	for _, line := range consumes {
+		inventories, err := r.consume(order, line, userID)
+		if err != nil {
+			return err
+		}
+		consumed = append(consumed, inventories...)
+	}
+	lotNo, expDate := kitOrigin(consumed)
+
+	for _, line := range produces {
+		load, err := NewLocationRepository(r.db).NewPutawayLoad(line.ItemCode, lotNo, order.Location, "A", line.Quantity)
+		if err != nil {
+			return err
+		}
+		load.WhsCode = order.WhsCode
+		load.OwnerCode = order.OwnerCode
+		if err := NewLocationRepository(r.db).ValidatePutaway(location, load); err != nil {
+			return err
+		}
+		if err := r.produce(order, line, lotNo, expDate, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := r.createVas(order, bom, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// consumeQuery inventory yang boleh dipakai kit order: qa A, belum expired, owner dan warehouse sama
+func (r *KitRepository) consumeQuery(order models.KitOrder, itemID int) *gorm.DB {
+	query := r.db.Model(&models.Inventory{}).
+		Where("item_id = ? AND whs_code = ? AND owner_code = ? AND qa_status = 'A' AND qty_available > 0", itemID, order.WhsCode, order.OwnerCode).
+		Where("(exp_date IS NULL OR exp_date = '' OR exp_date >= ?)", time.Now().Format("2006-01-02"))
+	if order.SourceLocation != "" {
+		query = query.Where("location = ?", order.SourceLocation)
+	}
+	return ExcludeHeld(query, "inventories")
+}
+
+func (r *KitRepository) availableQty(order models.KitOrder, itemID int) (int, error) {
+	var total int
+	if err := r.consumeQuery(order, itemID).Select("COALESCE(SUM(qty_available), 0)").Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// consume mengurangi stock input sesuai strategy alokasi, mengembalikan inventory yang terpakai
+func (r *KitRepository) consume(order models.KitOrder, line kitLine, userID int) ([]models.Inventory, error) {
+	strategy, err := NewAllocationRepository(r.db).ResolveStrategy(order.OwnerCode, line.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	var inventories []models.Inventory
+	if err := r.consumeQuery(order, line.ItemID).Order(allocationOrders[strategy]).Find(&inventories).Error; err != nil {
+		return nil, err
+	}
+
+	var consumed []models.Inventory
+	remaining := line.Quantity
+	for _, inventory := range inventories {
+		if remaining == 0 {
+			break
+		}
+		qty := inventory.QtyAvailable
+		if qty > remaining {
+			qty = remaining
+		}
+
+		if err := r.db.Model(&models.Inventory{}).Where("id = ?", inventory.ID).Updates(map[string]interface{}{
+			"qty_onhand":    gorm.Expr("qty_onhand - ?", qty),
+			"qty_available": gorm.Expr("qty_available - ?", qty),
+			"updated_by":    userID,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		if err := helpers.InsertInventoryMovement(r.db, inventory, helpers.MovementKitConsume, order.KitOrderNo, userID); err != nil {
+			return nil, err
+		}
+		if err := NewSerialRepository(r.db).Move(inventory, models.Inventory{}, qty, order.KitOrderNo, userID); err != nil {
+			return nil, err
+		}
+
+		if err := r.db.Create(&models.KitOrderDetail{
+			KitOrderID:  order.ID,
+			KitOrderNo:  order.KitOrderNo,
+			Direction:   KitConsume,
+			InventoryID: int(inventory.ID),
+			ItemID:      inventory.ItemId,
+			ItemCode:    inventory.ItemCode,
+			Location:    inventory.Location,
+			Pallet:      inventory.Pallet,
+			LotNo:       inventory.LotNo,
+			Quantity:    qty,
+			CreatedBy:   userID,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		consumed = append(consumed, inventory)
+		remaining -= qty
+	}
+
+	if remaining > 0 {
+		return nil, &BusinessError{Message: fmt.Sprintf("Insufficient stock for %s, short %d", line.ItemCode, remaining)}
+	}
+	return consumed, nil
+}
+
+// kitOrigin lot dan expiry untuk stock hasil kit: expiry paling awal dari stock yang dikonsumsi
+// beserta lot-nya, kalau tidak ada yang punya expiry pakai lot stock pertama
+func kitOrigin(consumed []models.Inventory) (string, string) {
+	lotNo, expDate := "", ""
+	for _, inventory := range consumed {
+		if lotNo == "" && expDate == "" {
+			lotNo = inventory.LotNo
+		}
+		if inventory.ExpDate != "" && (expDate == "" || inventory.ExpDate < expDate) {
+			lotNo, expDate = inventory.LotNo, inventory.ExpDate
+		}
+	}
+	return lotNo, expDate
+}
+
+// produce membuat inventory baru hasil kit order dengan lot dan expiry dari stock input,
+// asal-usulnya bisa ditelusuri lewat kit order detail dan movement ledger
+func (r *KitRepository) produce(order models.KitOrder, line kitLine, lotNo, expDate string, userID int) error {
+	var product models.Product
+	if err := r.db.Where("id = ?", line.ItemID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &BusinessError{Message: "Item " + line.ItemCode + " not found"}
+		}
+		return err
+	}
+
+	newInventory := models.Inventory{
+		OwnerCode:    order.OwnerCode,
+		WhsCode:      order.WhsCode,
+		RecDate:      time.Now().Format("2006-01-02"),
+		LotNo:        lotNo,
+		ExpDate:      expDate,
+		Pallet:       order.Location,
+		Location:     order.Location,
+		ItemId:       line.ItemID,
+		ItemCode:     product.ItemCode,
+		Barcode:      product.Barcode,
+		QaStatus:     "A",
+		Uom:          product.Uom,
+		QtyOrigin:    line.Quantity,
+		QtyOnhand:    line.Quantity,
+		QtyAvailable: line.Quantity,
+		Trans:        "kitting",
+		CreatedBy:    userID,
+	}
+	if err := r.db.Create(&newInventory).Error; err != nil {
+		return err
+	}
+
+	if err := helpers.InsertInventoryMovement(r.db, models.Inventory{ID: newInventory.ID}, helpers.MovementKitProduce, order.KitOrderNo, userID); err != nil {
+		return err
+	}
+	if err := NewHoldRepository(r.db).Apply(newInventory, userID); err != nil {
+		return err
+	}
+
+	return r.db.Create(&models.KitOrderDetail{
+		KitOrderID:  order.ID,
+		KitOrderNo:  order.KitOrderNo,
+		Direction:   KitProduce,
+		InventoryID: int(newInventory.ID),
+		ItemID:      line.ItemID,
+		ItemCode:    product.ItemCode,
+		Location:    newInventory.Location,
+		Pallet:      newInventory.Pallet,
+		LotNo:       newInventory.LotNo,
+		Quantity:    line.Quantity,
+		CreatedBy:   userID,
+	}).Error
+}
+
+// createVas baris billing per kit order, harga dari default price main vas yang dipasang di bom
+func (r *KitRepository) createVas(order models.KitOrder, bom models.BomHeader, userID int) error {
+	vasID := bom.AssemblyVasID
+	if order.OrderType == KitDisassembly {
+		vasID = bom.DisassemblyVasID
+	}
+	if vasID == 0 {
+		return nil
+	}
+
+	var mainVas models.MainVas
+	if err := r.db.Where("id = ?", vasID).First(&mainVas).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &BusinessError{Message: fmt.Sprintf("VAS %d on BOM %s not found", vasID, bom.KitItemCode)}
+		}
+		return err
+	}
+
+	return r.db.Create(&models.KitOrderVas{
+		KitOrderID:   order.ID,
+		KitOrderNo:   order.KitOrderNo,
+		OwnerCode:    order.OwnerCode,
+		MainVasID:    mainVas.ID,
+		MainVasName:  mainVas.Name,
+		DefaultPrice: mainVas.DefaultPrice,
+		Qty:          order.Quantity,
+		TotalPrice:   mainVas.DefaultPrice * float64(order.Quantity),
+		CreatedBy:    userID,
+	}).Error
+}
@@ -0,0 +1,376 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type LocationRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) *LocationRepository {
+	return &LocationRepository{db}
+}
+
+const (
+	PickPathSerpentine = "serpentine"
+	PickPathCustom     = "custom"
+)
+
+type LocationSequence struct {
+	LocationCode string `json:"location_code"`
+	PickSequence int    `json:"pick_sequence"`
+}
+
+// PickPathOrderSQL urutan jalur picking untuk raw query. Lokasi tanpa pick sequence ditaruh
+// paling akhir dan diurutkan per kode lokasi.
+func PickPathOrderSQL(locationAlias, locationColumn string) string {
+	return fmt.Sprintf("CASE WHEN COALESCE(%[1]s.pick_sequence, 0) > 0 THEN 0 ELSE 1 END, %[1]s.pick_sequence, %[2]s", locationAlias, locationColumn)
+}
+
+// compareLocationPart membandingkan row/bay/level/bin secara angka kalau bisa, selain itu string
+func compareLocationPart(a, b string) int {
+	ai, errA := strconv.Atoi(a)
+	bi, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return ai - bi
+	}
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// SequenceSerpentine mengisi pick sequence lokasi aktif dengan pola ular per row:
+// row ganjil bay naik, row genap bay turun, sehingga picker tidak balik ke ujung lorong.
+func (r *LocationRepository) SequenceSerpentine(area string, start, step, userID int) ([]LocationSequence, error) {
+	var locations []models.Location
+	query := r.db.Where("is_active = ?", true)
+	if area != "" {
+		query = query.Where("area = ?", area)
+	}
+	if err := query.Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	// nomor urut row dipakai untuk menentukan arah bay
+	rowSet := map[string]bool{}
+	var rows []string
+	for _, location := range locations {
+		if !rowSet[location.Row] {
+			rowSet[location.Row] = true
+			rows = append(rows, location.Row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return compareLocationPart(rows[i], rows[j]) < 0 })
+	rowIndex := map[string]int{}
+	for i, row := range rows {
+		rowIndex[row] = i
+	}
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.Row != b.Row {
+			return rowIndex[a.Row] < rowIndex[b.Row]
+		}
+		if c := compareLocationPart(a.Bay, b.Bay); c != 0 {
+			if rowIndex[a.Row]%2 == 1 {
+				return c > 0
+			}
+			return c < 0
+		}
+		if c := compareLocationPart(a.Level, b.Level); c != 0 {
+			return c < 0
+		}
+		if c := compareLocationPart(a.Bin, b.Bin); c != 0 {
+			return c < 0
+		}
+		return a.LocationCode < b.LocationCode
+	})
+
+	sequences := make([]LocationSequence, len(locations))
+	for i, location := range locations {
+		sequences[i] = LocationSequence{LocationCode: location.LocationCode, PickSequence: start + i*step}
+	}
+
+	if err := r.UpdateSequences(sequences, userID); err != nil {
+		return nil, err
+	}
+	return sequences, nil
+}
+
+func (r *LocationRepository) UpdateSequences(sequences []LocationSequence, userID int) error {
+	for _, seq := range sequences {
+		result := r.db.Model(&models.Location{}).
+			Where("location_code = ?", seq.LocationCode).
+			Updates(map[string]interface{}{
+				"pick_sequence": seq.PickSequence,
+				"updated_by":    userID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("location %s not found", seq.LocationCode)
+		}
+	}
+	return nil
+}
+
+const (
+	LocationTypeRack       = "rack"
+	LocationTypeFloor      = "floor"
+	LocationTypeStaging    = "staging"
+	LocationTypeQuarantine = "quarantine"
+	LocationTypeDock       = "dock"
+)
+
+var LocationTypes = []string{LocationTypeRack, LocationTypeFloor, LocationTypeStaging, LocationTypeQuarantine, LocationTypeDock}
+
+// PutawayLoad barang yang akan ditaruh ke lokasi, qty dalam uom inventory
+type PutawayLoad struct {
+	ItemCode   string  `json:"item_code"`
+	LotNo      string  `json:"lot_no"`
+	Pallet     string  `json:"pallet"`
+	QaStatus   string  `json:"qa_status"`
+	WhsCode    string  `json:"whs_code"`
+	OwnerCode  string  `json:"owner_code"`
+	Quantity   int     `json:"quantity"`
+	UnitCbm    float64 `json:"unit_cbm"`
+	UnitWeight float64 `json:"unit_weight"`
+}
+
+type PutawaySuggestion struct {
+	InboundBarcodeID int    `json:"inbound_barcode_id"`
+	ItemCode         string `json:"item_code"`
+	Pallet           string `json:"pallet"`
+	Quantity         int    `json:"quantity"`
+	CurrentLocation  string `json:"current_location"`
+	Location         string `json:"location"`
+	Reason           string `json:"reason"`
+}
+
+type locationStock struct {
+	pallets map[string]bool
+	items   map[string]bool
+	lots    map[string]bool // item_code|lot_no
+	cbm     float64
+	weight  float64
+}
+
+func newLocationStock() *locationStock {
+	return &locationStock{pallets: map[string]bool{}, items: map[string]bool{}, lots: map[string]bool{}}
+}
+
+func (s *locationStock) add(load PutawayLoad) {
+	s.pallets[load.Pallet] = true
+	s.items[load.ItemCode] = true
+	s.lots[load.ItemCode+"|"+load.LotNo] = true
+	s.cbm += load.UnitCbm * float64(load.Quantity)
+	s.weight += load.UnitWeight * float64(load.Quantity)
+}
+
+// unit cbm pakai Product.CBM, kalau kosong dihitung dari dimensi (cm)
+const unitCbmSQL = `CASE WHEN COALESCE(p.cbm, 0) > 0 THEN p.cbm ELSE COALESCE(p.width, 0) * COALESCE(p.length, 0) * COALESCE(p.height, 0) / 1000000.0 END`
+
+// NewPutawayLoad melengkapi cbm dan berat per unit dari master product
+func (r *LocationRepository) NewPutawayLoad(itemCode, lotNo, pallet, qaStatus string, quantity int) (PutawayLoad, error) {
+	load := PutawayLoad{ItemCode: itemCode, LotNo: lotNo, Pallet: pallet, QaStatus: qaStatus, Quantity: quantity}
+
+	var unit struct {
+		UnitCbm    float64
+		UnitWeight float64
+	}
+	if err := r.db.Raw(`SELECT `+unitCbmSQL+` AS unit_cbm, COALESCE(p.gross_weight, 0) AS unit_weight
+		FROM products p WHERE p.item_code = ? AND p.deleted_at IS NULL`, itemCode).Scan(&unit).Error; err != nil {
+		return load, err
+	}
+	load.UnitCbm = unit.UnitCbm
+	load.UnitWeight = unit.UnitWeight
+	return load, nil
+}
+
+// stockByLocation isi lokasi saat ini dari inventory onhand, semua owner karena kapasitas fisik
+func (r *LocationRepository) stockByLocation(locationCodes []string) (map[string]*locationStock, error) {
+	var rows []struct {
+		Location   string
+		Pallet     string
+		ItemCode   string
+		LotNo      string
+		QtyOnhand  int
+		UnitCbm    float64
+		UnitWeight float64
+	}
+
+	sql := `SELECT i.location, i.pallet, i.item_code, COALESCE(i.lot_no, '') AS lot_no,
+	SUM(i.qty_onhand) AS qty_onhand,
+	MAX(` + unitCbmSQL + `) AS unit_cbm,
+	MAX(COALESCE(p.gross_weight, 0)) AS unit_weight
+	FROM inventories i
+	LEFT JOIN products p ON i.item_id = p.id
+	WHERE i.qty_onhand > 0 AND i.deleted_at IS NULL`
+	var args []interface{}
+	if len(locationCodes) > 0 {
+		sql += " AND i.location IN ?"
+		args = append(args, locationCodes)
+	}
+	sql += " GROUP BY i.location, i.pallet, i.item_code, COALESCE(i.lot_no, '')"
+
+	if err := r.db.Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stocks := map[string]*locationStock{}
+	for _, row := range rows {
+		stock, ok := stocks[row.Location]
+		if !ok {
+			stock = newLocationStock()
+			stocks[row.Location] = stock
+		}
+		stock.add(PutawayLoad{ItemCode: row.ItemCode, LotNo: row.LotNo, Pallet: row.Pallet, Quantity: row.QtyOnhand, UnitCbm: row.UnitCbm, UnitWeight: row.UnitWeight})
+	}
+	return stocks, nil
+}
+
+// checkLocation cek tipe lokasi, aturan campur item/lot dan kapasitas untuk load baru
+func checkLocation(location models.Location, stock *locationStock, load PutawayLoad) error {
+	switch location.LocationType {
+	case LocationTypeDock:
+		return &BusinessError{Message: fmt.Sprintf("location %s is a dock location and cannot hold stock", location.LocationCode)}
+	case LocationTypeQuarantine:
+		if load.QaStatus == "A" {
+			return &BusinessError{Message: fmt.Sprintf("location %s is for quarantine stock only", location.LocationCode)}
+		}
+	}
+
+	if stock == nil {
+		stock = newLocationStock()
+	}
+
+	if location.SingleItem {
+		for itemCode := range stock.items {
+			if itemCode != load.ItemCode {
+				return &BusinessError{Message: fmt.Sprintf("location %s already holds item %s and does not allow mixed items", location.LocationCode, itemCode)}
+			}
+		}
+	}
+
+	if location.SingleLot {
+		for key := range stock.lots {
+			if key != load.ItemCode+"|"+load.LotNo && strings.HasPrefix(key, load.ItemCode+"|") {
+				return &BusinessError{Message: fmt.Sprintf("location %s already holds another lot of %s and does not allow mixed lots", location.LocationCode, load.ItemCode)}
+			}
+		}
+	}
+
+	if location.MaxPallet > 0 && !stock.pallets[load.Pallet] && len(stock.pallets)+1 > location.MaxPallet {
+		return &BusinessError{Message: fmt.Sprintf("location %s is full (max %d pallet)", location.LocationCode, location.MaxPallet)}
+	}
+	if location.MaxCbm > 0 && stock.cbm+load.UnitCbm*float64(load.Quantity) > location.MaxCbm {
+		return &BusinessError{Message: fmt.Sprintf("location %s exceeds max cbm %.4f", location.LocationCode, location.MaxCbm)}
+	}
+	if location.MaxWeight > 0 && stock.weight+load.UnitWeight*float64(load.Quantity) > location.MaxWeight {
+		return &BusinessError{Message: fmt.Sprintf("location %s exceeds max weight %.2f", location.LocationCode, location.MaxWeight)}
+	}
+
+	return nil
+}
+
+// ValidateLocation validator lokasi terpusat untuk semua scan dan perpindahan stok:
+// lokasi harus terdaftar, aktif, dan cocok dengan warehouse/owner stoknya.
+// whsCode atau ownerCode kosong berarti tidak dicek.
+func (r *LocationRepository) ValidateLocation(locationCode, whsCode, ownerCode string) (models.Location, error) {
+	var location models.Location
+	if locationCode == "" {
+		return location, &BusinessError{Message: "location is required"}
+	}
+
+	// lokasi milik owner lain tetap dicari supaya pesan errornya jelas
+	if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).Where("location_code = ?", locationCode).First(&location).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return location, &BusinessError{Message: fmt.Sprintf("location %s is not registered", locationCode)}
+		}
+		return location, err
+	}
+
+	if !location.IsActive {
+		return location, &BusinessError{Message: fmt.Sprintf("location %s is inactive", locationCode)}
+	}
+	if location.WhsCode != "" && whsCode != "" && location.WhsCode != whsCode {
+		return location, &BusinessError{Message: fmt.Sprintf("location %s belongs to warehouse %s, not %s", locationCode, location.WhsCode, whsCode)}
+	}
+	if location.OwnerCode != "" && ownerCode != "" && location.OwnerCode != ownerCode {
+		return location, &BusinessError{Message: fmt.Sprintf("location %s is dedicated to owner %s", locationCode, location.OwnerCode)}
+	}
+
+	return location, nil
+}
+
+// ValidatePutaway menolak putaway ke lokasi yang penuh atau tidak cocok
+func (r *LocationRepository) ValidatePutaway(location models.Location, load PutawayLoad) error {
+	stocks, err := r.stockByLocation([]string{location.LocationCode})
+	if err != nil {
+		return err
+	}
+	return checkLocation(location, stocks[location.LocationCode], load)
+}
+
+// SuggestPutaway mengusulkan lokasi untuk setiap load. Prioritas: lokasi yang sudah berisi item
+// yang sama (konsolidasi), lalu lokasi kosong sesuai urutan jalur. Load yang sudah diusulkan
+// ikut dihitung supaya beberapa pallet tidak diarahkan ke slot yang sama kalau tidak muat.
+func (r *LocationRepository) SuggestPutaway(loads []PutawayLoad) ([]string, error) {
+	var locations []models.Location
+	if err := r.db.Where("is_active = ? AND COALESCE(location_type, '') IN ?", true, []string{"", LocationTypeRack, LocationTypeFloor, LocationTypeQuarantine}).
+		Order(PickPathOrderSQL("locations", "location_code")).
+		Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	stocks, err := r.stockByLocation(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(loads))
+	for i, load := range loads {
+		var sameItem, empty []models.Location
+		for _, location := range locations {
+			if (location.LocationType == LocationTypeQuarantine) != (load.QaStatus != "A") {
+				continue
+			}
+			if (location.WhsCode != "" && location.WhsCode != load.WhsCode) || (location.OwnerCode != "" && location.OwnerCode != load.OwnerCode) {
+				continue
+			}
+			stock := stocks[location.LocationCode]
+			if stock != nil && stock.items[load.ItemCode] {
+				sameItem = append(sameItem, location)
+			} else if stock == nil || len(stock.items) == 0 {
+				empty = append(empty, location)
+			}
+		}
+
+		for _, location := range append(sameItem, empty...) {
+			if checkLocation(location, stocks[location.LocationCode], load) != nil {
+				continue
+			}
+			result[i] = location.LocationCode
+			if stocks[location.LocationCode] == nil {
+				stocks[location.LocationCode] = newLocationStock()
+			}
+			stocks[location.LocationCode].add(load)
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,307 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NumberingRepository struct {
+	db *gorm.DB
+}
+
+func NewNumberingRepository(db *gorm.DB) *NumberingRepository {
+	return &NumberingRepository{db}
+}
+
+// jenis dokumen yang nomornya dibuat lewat numbering service
+const (
+	DocInbound       = "inbound"
+	DocOutbound      = "outbound"
+	DocPacking       = "packing"
+	DocStockTake     = "stock_take"
+	DocOrder         = "order"
+	DocKoli          = "koli"
+	DocReturn        = "return"
+	DocWave          = "wave"
+	DocReplenishment = "replenishment"
+	DocAppointment   = "dock_appointment"
+	DocKitOrder      = "kit_order"
+	DocHold          = "hold"
+	DocAdjustment    = "adjustment"
+
+	ResetDaily   = "daily"
+	ResetMonthly = "monthly"
+	ResetYearly  = "yearly"
+	ResetNever   = "never"
+)
+
+// defaultNumberingFormats format bawaan kalau belum ada setting, sama dengan format nomor yang sudah berjalan.
+// Prefix inbound / outbound diambil dari owner setting.
+var defaultNumberingFormats = map[string]models.NumberingFormat{
+	DocInbound:       {Prefix: "IN", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocOutbound:      {Prefix: "OB", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocPacking:       {Prefix: "PA", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocStockTake:     {Prefix: "ST", DatePattern: "YYYYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocOrder:         {Prefix: "SPKYM", DatePattern: "YYMM", Padding: 4, ResetPeriod: ResetMonthly},
+	DocKoli:          {Prefix: "{REF}", DatePattern: "", Padding: 4, ResetPeriod: ResetNever},
+	DocReturn:        {Prefix: "RT", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocWave:          {Prefix: "WV", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocReplenishment: {Prefix: "RP", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocAppointment:   {Prefix: "DA", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocKitOrder:      {Prefix: "KT", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocHold:          {Prefix: "HD", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+	DocAdjustment:    {Prefix: "AJ", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily},
+}
+
+// tabel dan kolom nomor tiap dokumen, dipakai sekali untuk melanjutkan nomor yang sudah ada
+// saat sequence untuk suatu prefix belum pernah dibuat
+var numberingSources = map[string][2]string{
+	DocInbound:       {"inbound_headers", "inbound_no"},
+	DocOutbound:      {"outbound_headers", "outbound_no"},
+	DocPacking:       {"outbound_packings", "packing_no"},
+	DocStockTake:     {"stock_takes", "code"},
+	DocOrder:         {"order_headers", "order_no"},
+	DocKoli:          {"outbound_scans", "no_koli"},
+	DocReturn:        {"return_headers", "return_no"},
+	DocWave:          {"waves", "wave_no"},
+	DocReplenishment: {"replenishment_tasks", "task_no"},
+	DocAppointment:   {"dock_appointments", "appointment_no"},
+	DocKitOrder:      {"kit_orders", "kit_order_no"},
+	DocHold:          {"inventory_holds", "hold_no"},
+	DocAdjustment:    {"adjustment_headers", "adjustment_no"},
+}
+
+var NumberingDatePatterns = []string{"", "YY", "YYYY", "YYMM", "YYYYMM", "YYMMDD", "YYYYMMDD"}
+
+func IsValidDocType(docType string) bool {
+	_, ok := defaultNumberingFormats[docType]
+	return ok
+}
+
+// ValidateFormat cek pattern tanggal, padding dan reset period. Reset period harus tercermin
+// di pattern tanggal, kalau tidak nomor periode berikutnya sama dengan periode sebelumnya.
+func ValidateFormat(format models.NumberingFormat) error {
+	if !IsValidDocType(format.DocType) {
+		return &BusinessError{Message: "unknown document type " + format.DocType}
+	}
+	validPattern := false
+	for _, pattern := range NumberingDatePatterns {
+		if pattern == format.DatePattern {
+			validPattern = true
+		}
+	}
+	if !validPattern {
+		return &BusinessError{Message: "date pattern must be one of " + strings.Join(NumberingDatePatterns[1:], ", ") + " or empty"}
+	}
+	if format.Padding < 1 || format.Padding > 10 {
+		return &BusinessError{Message: "padding must be between 1 and 10"}
+	}
+
+	pattern := format.DatePattern
+	switch format.ResetPeriod {
+	case ResetDaily:
+		if !strings.Contains(pattern, "DD") {
+			return &BusinessError{Message: "daily reset needs a date pattern with day"}
+		}
+	case ResetMonthly:
+		if !strings.Contains(pattern, "MM") {
+			return &BusinessError{Message: "monthly reset needs a date pattern with month"}
+		}
+	case ResetYearly:
+		if !strings.Contains(pattern, "YY") {
+			return &BusinessError{Message: "yearly reset needs a date pattern with year"}
+		}
+	case ResetNever:
+	default:
+		return &BusinessError{Message: "reset period must be daily, monthly, yearly or never"}
+	}
+
+	if strings.Contains(format.Prefix, "{REF}") && format.DocType != DocKoli {
+		return &BusinessError{Message: "{REF} is only available for koli numbering"}
+	}
+	return nil
+}
+
+// GetFormat mencari format paling spesifik: owner+warehouse, owner, warehouse, lalu global.
+// Tanpa setting sama sekali dipakai format bawaan.
+func (r *NumberingRepository) GetFormat(docType, ownerCode, whsCode string) (models.NumberingFormat, error) {
+	defaultFormat, ok := defaultNumberingFormats[docType]
+	if !ok {
+		return defaultFormat, &BusinessError{Message: "unknown document type " + docType}
+	}
+
+	var formats []models.NumberingFormat
+	if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).
+		Where("doc_type = ? AND is_active = ?", docType, true).
+		Where("owner_code IN ? AND whs_code IN ?", []string{ownerCode, ""}, []string{whsCode, ""}).
+		Find(&formats).Error; err != nil {
+		return defaultFormat, err
+	}
+
+	best, bestScore := -1, -1
+	for i, format := range formats {
+		score := 0
+		if format.OwnerCode != "" {
+			score += 2
+		}
+		if format.WhsCode != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best >= 0 {
+		return formats[best], nil
+	}
+
+	defaultFormat.DocType = docType
+	if docType == DocInbound || docType == DocOutbound {
+		setting, err := helpers.GetOwnerSetting(r.db, ownerCode)
+		if err != nil {
+			return defaultFormat, err
+		}
+		defaultFormat.Prefix = setting.InboundPrefix
+		if docType == DocOutbound {
+			defaultFormat.Prefix = setting.OutboundPrefix
+		}
+	}
+	return defaultFormat, nil
+}
+
+func formatDatePattern(pattern string, now time.Time) string {
+	return strings.NewReplacer("YYYY", now.Format("2006"), "YY", now.Format("06"), "MM", now.Format("01"), "DD", now.Format("02")).Replace(pattern)
+}
+
+func resetPeriodKey(period string, now time.Time) string {
+	switch period {
+	case ResetDaily:
+		return now.Format("20060102")
+	case ResetMonthly:
+		return now.Format("200601")
+	case ResetYearly:
+		return now.Format("2006")
+	}
+	return ""
+}
+
+// renderPrefix prefix lengkap dengan tanggal, tanpa nomor urut
+func renderPrefix(format models.NumberingFormat, ownerCode, whsCode, ref string, now time.Time) string {
+	prefix := strings.NewReplacer("{OWNER}", ownerCode, "{WHS}", whsCode, "{REF}", ref).Replace(format.Prefix)
+	return prefix + formatDatePattern(format.DatePattern, now)
+}
+
+// Next mengambil nomor dokumen berikutnya. Sequence di-increment dengan UPDATE sehingga row-nya
+// terkunci sampai transaction pemanggil selesai, request lain menunggu dan tidak dapat nomor yang sama.
+// ref hanya dipakai untuk {REF} (nomor outbound pada koli).
+func (r *NumberingRepository) Next(docType, ownerCode, whsCode, ref string) (string, error) {
+	format, err := r.GetFormat(docType, ownerCode, whsCode)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	prefix := renderPrefix(format, ownerCode, whsCode, ref, now)
+	key := docType + "|" + prefix + "|" + resetPeriodKey(format.ResetPeriod, now)
+
+	var number int
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		number, err = r.increment(tx, docType, key, prefix, format.Padding)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%0*d", prefix, format.Padding, number), nil
+}
+
+// Preview nomor yang akan didapat dokumen berikutnya, tanpa menaikkan sequence
+func (r *NumberingRepository) Preview(docType, ownerCode, whsCode, ref string) (string, error) {
+	format, err := r.GetFormat(docType, ownerCode, whsCode)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	prefix := renderPrefix(format, ownerCode, whsCode, ref, now)
+	key := docType + "|" + prefix + "|" + resetPeriodKey(format.ResetPeriod, now)
+
+	var sequence models.NumberingSequence
+	err = r.db.Where("seq_key = ?", key).First(&sequence).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sequence.LastNo, err = r.lastExisting(docType, prefix, format.Padding)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%0*d", prefix, format.Padding, sequence.LastNo+1), nil
+}
+
+func (r *NumberingRepository) increment(tx *gorm.DB, docType, key, prefix string, padding int) (int, error) {
+	result := tx.Model(&models.NumberingSequence{}).Where("seq_key = ?", key).Update("last_no", gorm.Expr("last_no + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		lastNo, err := r.lastExisting(docType, prefix, padding)
+		if err != nil {
+			return 0, err
+		}
+
+		// insert di savepoint sendiri, kalau kalah cepat dengan request lain (unique key) cukup ulang update
+		sequence := models.NumberingSequence{DocType: docType, SeqKey: key, LastNo: lastNo + 1}
+		if err := tx.Transaction(func(sp *gorm.DB) error {
+			return sp.Create(&sequence).Error
+		}); err != nil {
+			result = tx.Model(&models.NumberingSequence{}).Where("seq_key = ?", key).Update("last_no", gorm.Expr("last_no + 1"))
+			if result.Error != nil {
+				return 0, result.Error
+			}
+			if result.RowsAffected == 0 {
+				return 0, err
+			}
+		} else {
+			return sequence.LastNo, nil
+		}
+	}
+
+	var sequence models.NumberingSequence
+	if err := tx.Where("seq_key = ?", key).First(&sequence).Error; err != nil {
+		return 0, err
+	}
+	return sequence.LastNo, nil
+}
+
+// lastExisting nomor urut terbesar yang sudah terpakai di tabel dokumen untuk prefix ini
+func (r *NumberingRepository) lastExisting(docType, prefix string, padding int) (int, error) {
+	source, ok := numberingSources[docType]
+	if !ok {
+		return 0, nil
+	}
+
+	var codes []string
+	if err := r.db.Table(source[0]).Where(source[1]+" LIKE ?", prefix+"%").Pluck(source[1], &codes).Error; err != nil {
+		return 0, err
+	}
+
+	lastNo := 0
+	for _, code := range codes {
+		if len(code) != len(prefix)+padding {
+			continue
+		}
+		if sequence, err := strconv.Atoi(code[len(prefix):]); err == nil && sequence > lastNo {
+			lastNo = sequence
+		}
+	}
+	return lastNo, nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"fiber-app/models"
+	"testing"
+	"time"
+)
+
+func TestValidateFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  models.NumberingFormat
+		wantErr bool
+	}{
+		{"default inbound", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily}, false},
+		{"monthly order", models.NumberingFormat{DocType: DocOrder, Prefix: "SPK", DatePattern: "YYMM", Padding: 4, ResetPeriod: ResetMonthly}, false},
+		{"yearly with full year", models.NumberingFormat{DocType: DocWave, Prefix: "WV", DatePattern: "YYYY", Padding: 6, ResetPeriod: ResetYearly}, false},
+		{"never without date", models.NumberingFormat{DocType: DocHold, Prefix: "{OWNER}-{WHS}-", Padding: 8, ResetPeriod: ResetNever}, false},
+		{"koli with ref", models.NumberingFormat{DocType: DocKoli, Prefix: "{REF}", Padding: 4, ResetPeriod: ResetNever}, false},
+		{"unknown doc type", models.NumberingFormat{DocType: "invoice", Prefix: "INV", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: ResetDaily}, true},
+		{"unknown date pattern", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", DatePattern: "DDMMYY", Padding: 4, ResetPeriod: ResetDaily}, true},
+		{"padding zero", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", DatePattern: "YYMMDD", Padding: 0, ResetPeriod: ResetDaily}, true},
+		{"padding too long", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", DatePattern: "YYMMDD", Padding: 11, ResetPeriod: ResetDaily}, true},
+		{"daily without day", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", DatePattern: "YYMM", Padding: 4, ResetPeriod: ResetDaily}, true},
+		{"monthly without month", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", DatePattern: "YY", Padding: 4, ResetPeriod: ResetMonthly}, true},
+		{"yearly without date", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", Padding: 4, ResetPeriod: ResetYearly}, true},
+		{"unknown reset period", models.NumberingFormat{DocType: DocInbound, Prefix: "IN", DatePattern: "YYMMDD", Padding: 4, ResetPeriod: "weekly"}, true},
+		{"ref outside koli", models.NumberingFormat{DocType: DocOutbound, Prefix: "{REF}", Padding: 4, ResetPeriod: ResetNever}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFormat(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && ErrorStatus(err) != 400 {
+				t.Errorf("ValidateFormat() status = %d, want 400", ErrorStatus(err))
+			}
+		})
+	}
+}
+
+func TestRenderPrefix(t *testing.T) {
+	now := time.Date(2026, 3, 7, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		format models.NumberingFormat
+		owner  string
+		whs    string
+		ref    string
+		want   string
+	}{
+		{"short date", models.NumberingFormat{Prefix: "IN", DatePattern: "YYMMDD"}, "", "", "", "IN260307"},
+		{"full date", models.NumberingFormat{Prefix: "ST", DatePattern: "YYYYMMDD"}, "", "", "", "ST20260307"},
+		{"month", models.NumberingFormat{Prefix: "SPKYM", DatePattern: "YYMM"}, "", "", "", "SPKYM2603"},
+		{"year only", models.NumberingFormat{Prefix: "WV", DatePattern: "YYYY"}, "", "", "", "WV2026"},
+		{"no date", models.NumberingFormat{Prefix: "HD"}, "", "", "", "HD"},
+		{"owner and warehouse", models.NumberingFormat{Prefix: "{OWNER}/{WHS}/", DatePattern: "YYMM"}, "ABC", "CKP", "", "ABC/CKP/2603"},
+		{"koli ref", models.NumberingFormat{Prefix: "{REF}-"}, "", "", "OB2603070001", "OB2603070001-"},
+		{"empty placeholders", models.NumberingFormat{Prefix: "{OWNER}{WHS}AJ", DatePattern: "YY"}, "", "", "", "AJ26"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderPrefix(tt.format, tt.owner, tt.whs, tt.ref, now); got != tt.want {
+				t.Errorf("renderPrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
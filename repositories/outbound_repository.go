@@ -2,9 +2,9 @@ package repositories
 
 import (
 	"errors"
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
 	"fmt"
-	"strconv"
 	"time"
 
 	"gorm.io/gorm"
@@ -35,70 +35,13 @@ func NewOutboundRepository(db *gorm.DB) *OutboundRepository {
 	return &OutboundRepository{db: db}
 }
 
-func (r *OutboundRepository) GenerateOutboundNumber() (string, error) {
-	var lastOutbound models.OutboundHeader
-
-	// Ambil outbound terakhir
-	if err := r.db.Last(&lastOutbound).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return "", err
-	}
-
-	// Ambil tanggal sekarang dalam format YYMMDD
-	now := time.Now()
-	currentDate := now.Format("060102") // 06=YY, 01=MM, 02=DD
-
-	var outboundNo string
-	if lastOutbound.OutboundNo != "" && len(lastOutbound.OutboundNo) >= 12 {
-		lastDatePart := lastOutbound.OutboundNo[2:8]
-		lastSequenceStr := lastOutbound.OutboundNo[len(lastOutbound.OutboundNo)-4:]
-
-		if currentDate != lastDatePart {
-			// Tanggal berubah → reset nomor urut ke 1
-			outboundNo = fmt.Sprintf("OB%s%04d", currentDate, 1)
-		} else {
-			// Tanggal sama → tambahkan nomor urut
-			lastSequenceInt, _ := strconv.Atoi(lastSequenceStr)
-			outboundNo = fmt.Sprintf("OB%s%04d", currentDate, lastSequenceInt+1)
-		}
-	} else {
-		// Tidak ada outbound sebelumnya
-		outboundNo = fmt.Sprintf("OB%s%04d", currentDate, 1)
-	}
-
-	return outboundNo, nil
+// GenerateOutboundNumber membuat nomor outbound lewat numbering service, default <prefix owner><YYMMDD><seq>
+func (r *OutboundRepository) GenerateOutboundNumber(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocOutbound, ownerCode, whsCode, "")
 }
 
 func (r *OutboundRepository) GeneratePackingNumber() (string, error) {
-	var lastPacking models.OutboundPacking
-
-	// Ambil outbound terakhir
-	if err := r.db.Last(&lastPacking).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return "", err
-	}
-
-	// Ambil tanggal sekarang dalam format YYMMDD
-	now := time.Now()
-	currentDate := now.Format("060102") // 06=YY, 01=MM, 02=DD
-
-	var packingNo string
-	if lastPacking.PackingNo != "" && len(lastPacking.PackingNo) >= 12 {
-		lastDatePart := lastPacking.PackingNo[2:8]
-		lastSequenceStr := lastPacking.PackingNo[len(lastPacking.PackingNo)-4:]
-
-		if currentDate != lastDatePart {
-			// Tanggal berubah → reset nomor urut ke 1
-			packingNo = fmt.Sprintf("PA%s%04d", currentDate, 1)
-		} else {
-			// Tanggal sama → tambahkan nomor urut
-			lastSequenceInt, _ := strconv.Atoi(lastSequenceStr)
-			packingNo = fmt.Sprintf("PA%s%04d", currentDate, lastSequenceInt+1)
-		}
-	} else {
-		// Tidak ada outbound sebelumnya
-		packingNo = fmt.Sprintf("PA%s%04d", currentDate, 1)
-	}
-
-	return packingNo, nil
+	return NewNumberingRepository(r.db).Next(DocPacking, "", "", "")
 }
 
 func (r *OutboundRepository) CreateItemOutbound(header *models.OutboundHeader, data *models.OutboundDetail, handlingUsed []HandlingDetailUsed) (uint, error) {
@@ -251,6 +194,10 @@ func (r *OutboundRepository) GetAllOutboundList(dateFrom, dateTo, status string)
 	// Build dynamic WHERE clause
 	var params []interface{}
 
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+	sql += ownerSQL
+	params = append(params, ownerArgs...)
+
 	// Date From Filter (default: 7 days ago)
 	if dateFrom != "" {
 		sql += " AND a.outbound_date >= ?"
@@ -349,6 +296,7 @@ func (r *OutboundRepository) GetAllOutboundList(dateFrom, dateTo, status string)
 //	}
 func (r *OutboundRepository) GetAllOutboundListComplete() ([]OutboundList, error) {
 	var outboundList []OutboundList
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
 	sql := `WITH od AS 
 	 (select outbound_id, count(outbound_id) as total_item,
@@ -376,10 +324,10 @@ func (r *OutboundRepository) GetAllOutboundListComplete() ([]OutboundList, error
             LEFT JOIN ps ON a.id = ps.outbound_id
             LEFT JOIN kd ON a.id = kd.outbound_id
             LEFT JOIN customers cs ON a.customer_code = cs.customer_code
-			WHERE a.status = 'complete'
+			WHERE a.status = 'complete'` + ownerSQL + `
 			order by a.id desc`
 
-	if err := r.db.Raw(sql).Scan(&outboundList).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&outboundList).Error; err != nil {
 		return nil, err
 	}
 
@@ -388,6 +336,7 @@ func (r *OutboundRepository) GetAllOutboundListComplete() ([]OutboundList, error
 
 func (r *OutboundRepository) GetAllOutboundListOutboundHandling() ([]OutboundList, error) {
 	var outboundList []OutboundList
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
 	sql := `WITH od AS 
 	 (select outbound_id, count(outbound_id) as total_item,
@@ -422,10 +371,10 @@ func (r *OutboundRepository) GetAllOutboundListOutboundHandling() ([]OutboundLis
             LEFT JOIN kd ON a.id = kd.outbound_id
             LEFT JOIN customers cs ON a.customer_code = cs.customer_code
 			LEFT JOIN hd ON a.outbound_no = hd.outbound_no
-			WHERE a.status = 'complete'
+			WHERE a.status = 'complete'` + ownerSQL + `
 			order by a.id desc`
 
-	if err := r.db.Raw(sql).Scan(&outboundList).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&outboundList).Error; err != nil {
 		return nil, err
 	}
 
@@ -434,6 +383,7 @@ func (r *OutboundRepository) GetAllOutboundListOutboundHandling() ([]OutboundLis
 
 func (r *OutboundRepository) GetOutboundOpen() ([]OutboundList, error) {
 	var outboundList []OutboundList
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
 	sql := `with details as
 		(select outbound_id, count(outbound_id) as total_line,
@@ -447,9 +397,9 @@ func (r *OutboundRepository) GetOutboundOpen() ([]OutboundList, error) {
 		from outbound_headers a
 		inner join details b on a.id = b.outbound_id
 		inner join customers c on a.customer_code = c.customer_code
-		where a.status = 'open'`
+		where a.status = 'open'` + ownerSQL
 
-	if err := r.db.Raw(sql).Scan(&outboundList).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&outboundList).Error; err != nil {
 		return nil, err
 	}
 
@@ -458,6 +408,7 @@ func (r *OutboundRepository) GetOutboundOpen() ([]OutboundList, error) {
 
 func (r *OutboundRepository) GetOutboundPicking() ([]OutboundList, error) {
 	var outboundList []OutboundList
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
 	sql := `with details as
 		(select outbound_id, count(outbound_id) as total_line,
@@ -471,9 +422,9 @@ func (r *OutboundRepository) GetOutboundPicking() ([]OutboundList, error) {
 		from outbound_headers a
 		inner join details b on a.id = b.outbound_id
 		inner join customers c on a.customer_code = c.customer_code
-		where a.status = 'picking'`
+		where a.status = 'picking'` + ownerSQL
 
-	if err := r.db.Raw(sql).Scan(&outboundList).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&outboundList).Error; err != nil {
 		return nil, err
 	}
 
@@ -514,6 +465,7 @@ type PaperPickingSheet struct {
 
 func (r *OutboundRepository) GetPickingSheet(outbound_id int) ([]PaperPickingSheet, error) {
 	var outboundList []PaperPickingSheet
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "e.owner_code")
 
 	sql := `select
 	e.cust_address,
@@ -550,8 +502,9 @@ func (r *OutboundRepository) GetPickingSheet(outbound_id int) ([]PaperPickingShe
 	inner join customers f on e.customer_code = f.customer_code
 	inner join customers g on e.deliv_to = g.customer_code
 	left join transporters h on e.transporter_code = h.transporter_code
-	where a.outbound_id = ?
-	group by a.location, a.pallet, a.item_id, a.item_code,
+	left join locations l on a.location = l.location_code and l.deleted_at is null
+	where a.outbound_id = ?` + ownerSQL + `
+	group by l.pick_sequence, a.location, a.pallet, a.item_id, a.item_code,
 	b.barcode, b.item_name, b.cbm, c.rec_date, c.whs_code,
 	e.outbound_no, e.customer_code, f.customer_name, e.outbound_date, e.shipment_id,
 	e.cust_address,
@@ -568,16 +521,79 @@ func (r *OutboundRepository) GetPickingSheet(outbound_id int) ([]PaperPickingShe
 	g.customer_name,
 	h.transporter_code,
 	a.outbound_detail_id
-	Order By a.outbound_detail_id ASC`
+	Order By ` + PickPathOrderSQL("l", "a.location") + `, a.outbound_detail_id ASC`
 
-	if err := r.db.Debug().Raw(sql, outbound_id).Scan(&outboundList).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, append([]interface{}{outbound_id}, ownerArgs...)...).Scan(&outboundList).Error; err != nil {
 		return nil, err
 	}
 
 	return outboundList, nil
 }
 
+type NextPick struct {
+	PickingID    uint   `json:"picking_id"`
+	Location     string `json:"location"`
+	PickSequence int    `json:"pick_sequence"`
+	Pallet       string `json:"pallet"`
+	ItemCode     string `json:"item_code"`
+	Barcode      string `json:"barcode"`
+	Quantity     int    `json:"quantity"`
+	QtyRemaining int    `json:"qty_remaining"`
+}
+
+// GetPickingList picking list RF diurutkan mengikuti jalur lokasi
+func (r *OutboundRepository) GetPickingList(outboundNo string) ([]models.OutboundPicking, error) {
+	var pickingList []models.OutboundPicking
+	err := r.db.Model(&models.OutboundPicking{}).
+		Select("outbound_pickings.*").
+		Joins("LEFT JOIN locations l ON outbound_pickings.location = l.location_code AND l.deleted_at IS NULL").
+		Where("outbound_pickings.outbound_no = ?", outboundNo).
+		Order(PickPathOrderSQL("l", "outbound_pickings.location") + ", outbound_pickings.id").
+		Find(&pickingList).Error
+	return pickingList, err
+}
+
+// GetNextPick mencari baris picking berikutnya di jalur yang belum terpenuhi scan.
+// Qty scan per barcode dipakai untuk menutup baris picking sesuai urutan jalur. Nil kalau sudah selesai.
+func (r *OutboundRepository) GetNextPick(outboundID int) (*NextPick, error) {
+	var lines []NextPick
+	sql := `SELECT a.id AS picking_id, a.location, COALESCE(l.pick_sequence, 0) AS pick_sequence,
+	a.pallet, a.item_code, a.barcode, a.quantity
+	FROM outbound_pickings a
+	LEFT JOIN locations l ON a.location = l.location_code AND l.deleted_at IS NULL
+	WHERE a.outbound_id = ? AND a.deleted_at IS NULL
+	ORDER BY ` + PickPathOrderSQL("l", "a.location") + `, a.id`
+	if err := r.db.Raw(sql, outboundID).Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+
+	type scanned struct {
+		Barcode string
+		Qty     int
+	}
+	var scans []scanned
+	if err := r.db.Raw(`SELECT barcode, SUM(quantity) AS qty FROM outbound_barcodes
+		WHERE outbound_id = ? AND deleted_at IS NULL GROUP BY barcode`, outboundID).Scan(&scans).Error; err != nil {
+		return nil, err
+	}
+	qtyScan := map[string]int{}
+	for _, scan := range scans {
+		qtyScan[scan.Barcode] = scan.Qty
+	}
+
+	for _, line := range lines {
+		if qtyScan[line.Barcode] >= line.Quantity {
+			qtyScan[line.Barcode] -= line.Quantity
+			continue
+		}
+		line.QtyRemaining = line.Quantity - qtyScan[line.Barcode]
+		return &line, nil
+	}
+	return nil, nil
+}
+
 func (r *OutboundRepository) GetOutboundDetailList(outbound_id int) ([]OutboundDetailList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "c.owner_code")
 
 	var outboundDetailList []OutboundDetailList
 
@@ -595,9 +611,9 @@ func (r *OutboundRepository) GetOutboundDetailList(outbound_id int) ([]OutboundD
 			inner join outbound_headers c on a.outbound_id = c.id
 			inner join customers d on c.customer_code = d.customer_code
 			left join cte_outbound_barcodes e on a.id = e.outbound_detail_id
-			where a.outbound_id = ?`
+			where a.outbound_id = ?` + ownerSQL
 
-	if err := r.db.Raw(sql, outbound_id).Scan(&outboundDetailList).Error; err != nil {
+	if err := r.db.Raw(sql, append([]interface{}{outbound_id}, ownerArgs...)...).Scan(&outboundDetailList).Error; err != nil {
 		return nil, err
 	}
 
@@ -605,6 +621,7 @@ func (r *OutboundRepository) GetOutboundDetailList(outbound_id int) ([]OutboundD
 }
 
 func (r *OutboundRepository) GetOutboundDetailItem(outbound_id int, outbound_detail_id int) (OutboundDetailList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "c.owner_code")
 
 	var outboundDetailList OutboundDetailList
 
@@ -622,9 +639,9 @@ func (r *OutboundRepository) GetOutboundDetailItem(outbound_id int, outbound_det
 			inner join outbound_headers c on a.outbound_id = c.id
 			inner join customers d on c.customer_code = d.customer_code
 			left join cte_outbound_barcodes e on a.id = e.outbound_detail_id
-			where a.outbound_id = ? and a.id = ?`
+			where a.outbound_id = ? and a.id = ?` + ownerSQL
 
-	if err := r.db.Debug().Raw(sql, outbound_id, outbound_detail_id).Scan(&outboundDetailList).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, append([]interface{}{outbound_id, outbound_detail_id}, ownerArgs...)...).Scan(&outboundDetailList).Error; err != nil {
 		return outboundDetailList, err
 	}
 
@@ -704,6 +721,7 @@ type PickingItem struct {
 
 func (r *OutboundRepository) CheckPickingItem(outbound_id int, barcode string) ([]PickingItem, error) {
 	var outboundList []PickingItem
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "e.owner_code")
 
 	sql := `select
 	e.cust_address,
@@ -740,7 +758,7 @@ func (r *OutboundRepository) CheckPickingItem(outbound_id int, barcode string) (
 	inner join customers f on e.customer_code = f.customer_code
 	inner join customers g on e.deliv_to = g.customer_code
 	left join transporters h on e.transporter_code = h.transporter_code
-	where a.outbound_id = ?
+	where a.outbound_id = ?` + ownerSQL + `
 	group by a.location, a.pallet, a.item_id, a.item_code,
 	b.barcode, b.item_name, b.cbm, c.rec_date, c.whs_code,
 	e.outbound_no, e.customer_code, f.customer_name, e.outbound_date, e.shipment_id,
@@ -759,7 +777,7 @@ func (r *OutboundRepository) CheckPickingItem(outbound_id int, barcode string) (
 	h.transporter_code
 	Order By a.[location] ASC`
 
-	if err := r.db.Debug().Raw(sql, outbound_id).Scan(&outboundList).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, append([]interface{}{outbound_id}, ownerArgs...)...).Scan(&outboundList).Error; err != nil {
 		return nil, err
 	}
 
@@ -786,6 +804,7 @@ type PackingSummary struct {
 
 func (r *OutboundRepository) GetPackingSummary() ([]PackingSummary, error) {
 	var result []PackingSummary
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "oh.owner_code")
 
 	sql := `WITH ob AS (
 			SELECT count(item_id) as tot_item, sum(quantity) as tot_qty, outbound_no, outbound_id, 
@@ -804,10 +823,11 @@ func (r *OutboundRepository) GetPackingSummary() ([]PackingSummary, error) {
 		LEFT JOIN outbound_headers oh on ob.outbound_id = oh.id
 		LEFT JOIN customers cs on oh.customer_code = cs.customer_code
 		LEFT JOIN customers cd on cd.customer_code = oh.deliv_to
+		WHERE 1=1` + ownerSQL + `
 		ORDER BY op.created_at DESC
 	`
 
-	if err := r.db.Debug().Raw(sql).Scan(&result).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, ownerArgs...).Scan(&result).Error; err != nil {
 		return nil, err
 	}
 
@@ -846,6 +866,7 @@ type PackingItem struct {
 
 func (r *OutboundRepository) GetPackingItems(outboundID int, packingNo string) ([]PackingItem, error) {
 	var result []PackingItem
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "e.owner_code")
 
 	sql := `
 	SELECT
@@ -883,7 +904,7 @@ func (r *OutboundRepository) GetPackingItems(outboundID int, packingNo string) (
 	INNER JOIN customers f ON e.customer_code = f.customer_code
 	INNER JOIN customers g ON e.deliv_to = g.customer_code
 	LEFT JOIN transporters h ON e.transporter_code = h.transporter_code
-	WHERE a.outbound_id = ? AND a.packing_no = ?
+	WHERE a.outbound_id = ? AND a.packing_no = ?` + ownerSQL + `
 	GROUP BY 
 		a.item_id, 
 		a.item_code,
@@ -914,7 +935,7 @@ func (r *OutboundRepository) GetPackingItems(outboundID int, packingNo string) (
 	ORDER BY a.item_code ASC
 	`
 
-	if err := r.db.Debug().Raw(sql, outboundID, packingNo).Scan(&result).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, append([]interface{}{outboundID, packingNo}, ownerArgs...)...).Scan(&result).Error; err != nil {
 		return nil, err
 	}
 
@@ -940,6 +961,7 @@ type OutboundSummary struct {
 
 func (r *OutboundRepository) GetOutboundSummary(outboundNo string) (OutboundSummary, error) {
 	var result OutboundSummary
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "oh.owner_code")
 
 	sql := `SELECT 
 		oh.id,
@@ -960,9 +982,9 @@ func (r *OutboundRepository) GetOutboundSummary(outboundNo string) (OutboundSumm
 		LEFT JOIN customers cs ON oh.customer_code = cs.customer_code
 		LEFT JOIN customers cd ON oh.deliv_to = cd.customer_code
 		LEFT JOIN transporters tr ON oh.transporter_code = tr.transporter_code
-		WHERE oh.outbound_no = ?`
+		WHERE oh.outbound_no = ?` + ownerSQL
 
-	if err := r.db.Debug().Raw(sql, outboundNo).Scan(&result).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, append([]interface{}{outboundNo}, ownerArgs...)...).Scan(&result).Error; err != nil {
 		return result, err
 	}
 
@@ -1032,6 +1054,7 @@ type OutboundVasSum struct {
 
 func (r *OutboundRepository) GetOutboundVasSum() ([]OutboundVasSum, error) {
 	var result []OutboundVasSum
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "oh.owner_code")
 
 	sql := `WITH ov AS
 (SELECT ob.outbound_id, ob.outbound_date,
@@ -1057,10 +1080,12 @@ od.deliv_to_name,
 od.deliv_city
 from ov
 inner join order_details od ON ov.outbound_id = od.outbound_id
+inner join outbound_headers oh ON ov.outbound_id = oh.id
+where 1=1` + ownerSQL + `
 order by ov.outbound_id desc
 `
 
-	if err := r.db.Debug().Raw(sql).Scan(&result).Error; err != nil {
+	if err := r.db.Debug().Raw(sql, ownerArgs...).Scan(&result).Error; err != nil {
 		return result, err
 	}
 
@@ -0,0 +1,407 @@
+package repositories
+
+import (
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductivityRepository struct {
+	db *gorm.DB
+}
+
+func NewProductivityRepository(db *gorm.DB) *ProductivityRepository {
+	return &ProductivityRepository{db}
+}
+
+const (
+	ActivityReceiving = "receiving"
+	ActivityPutaway   = "putaway"
+	ActivityPicking   = "picking"
+	ActivityPacking   = "packing"
+	ActivityCounting  = "counting"
+)
+
+var Activities = []string{ActivityReceiving, ActivityPutaway, ActivityPicking, ActivityPacking, ActivityCounting}
+
+// batas periode laporan supaya query scan tidak terlalu berat
+const maxProductivityDays = 31
+
+type ProductivityFilter struct {
+	From      string `query:"from"`
+	To        string `query:"to"`
+	Activity  string `query:"activity"`
+	ShiftCode string `query:"shift_code"`
+	UserID    int    `query:"user_id"`
+	Metric    string `query:"metric"`
+}
+
+type ProductivityRow struct {
+	WorkDate     string    `json:"work_date"`
+	ShiftCode    string    `json:"shift_code"`
+	UserID       int       `json:"user_id"`
+	Username     string    `json:"username"`
+	Name         string    `json:"name"`
+	Activity     string    `json:"activity"`
+	Scans        int       `json:"scans"`
+	Lines        int       `json:"lines"`
+	Units        int       `json:"units"`
+	ActiveHours  int       `json:"active_hours"`
+	ScansPerHour float64   `json:"scans_per_hour"`
+	LinesPerHour float64   `json:"lines_per_hour"`
+	UnitsPerHour float64   `json:"units_per_hour"`
+	FirstScan    time.Time `json:"first_scan"`
+	LastScan     time.Time `json:"last_scan"`
+	Rank         int       `json:"rank,omitempty"`
+}
+
+type productivityEvent struct {
+	Activity  string
+	UserID    int
+	CreatedAt time.Time
+	Qty       int
+	LineID    int
+	Location  string
+	Barcode   string
+}
+
+// lineKey line yang dikerjakan: detail dokumen / inventory, untuk counting lokasi + barcode
+func (e productivityEvent) lineKey() string {
+	if e.Activity == "counting" {
+		return e.Location + "|" + e.Barcode
+	}
+	return strconv.Itoa(e.LineID)
+}
+
+type productivityAcc struct {
+	row   ProductivityRow
+	lines map[string]bool
+	hours map[string]bool
+}
+
+// setiap aktivitas diambil dari tabel scan yang sudah mencatat created_by dan created_at.
+// Kunci line dibentuk di Go (lihat lineKey) supaya query tidak perlu CAST yang beda tiap database.
+// owner_code dibawa untuk filter owner scope, scan count ikut owner item dari barcode-nya.
+// Parameter from dan to diulang untuk tiap aktivitas.
+const productivityEventSQL = `SELECT e.activity, e.user_id, e.created_at, e.qty, e.line_id, e.location, e.barcode FROM (
+	SELECT 'receiving' AS activity, created_by AS user_id, created_at, quantity AS qty,
+	inbound_detail_id AS line_id, '' AS location, '' AS barcode, owner_code
+	FROM inbound_barcodes WHERE deleted_at IS NULL AND created_at >= ? AND created_at < ?
+	UNION ALL
+	SELECT 'putaway', created_by, created_at, qty_change, inventory_id, '', '', owner_code
+	FROM inventory_movements WHERE movement_type = 'PUTAWAY' AND created_at >= ? AND created_at < ?
+	UNION ALL
+	SELECT 'picking', ob.created_by, ob.created_at, ob.quantity, ob.outbound_detail_id, '', '', oh.owner_code
+	FROM outbound_barcodes ob LEFT JOIN outbound_headers oh ON oh.id = ob.outbound_id
+	WHERE ob.deleted_at IS NULL AND ob.created_at >= ? AND ob.created_at < ?
+	UNION ALL
+	SELECT 'packing', sd.created_by, sd.created_at, sd.qty, sd.outbound_detail_id, '', '', oh.owner_code
+	FROM outbound_scan_details sd LEFT JOIN outbound_headers oh ON oh.id = sd.outbound_id
+	WHERE sd.deleted_at IS NULL AND sd.created_at >= ? AND sd.created_at < ?
+	UNION ALL
+	SELECT 'counting', created_by, created_at, counted_qty, 0, location, barcode,
+	(SELECT MAX(p.owner_code) FROM products p WHERE p.barcode = stb.barcode AND p.deleted_at IS NULL)
+	FROM stock_take_barcodes stb WHERE deleted_at IS NULL AND created_at >= ? AND created_at < ?
+) e WHERE 1 = 1`
+
+// jumlah aktivitas di productivityEventSQL, masing-masing butuh parameter from dan to
+const productivityActivities = 5
+
+type shiftWindow struct {
+	code  string
+	start int
+	end   int
+}
+
+func parseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, &BusinessError{Message: fmt.Sprintf("invalid time %s, use HH:MM", value)}
+	}
+	hour, errHour := strconv.Atoi(parts[0])
+	minute, errMinute := strconv.Atoi(parts[1])
+	if errHour != nil || errMinute != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, &BusinessError{Message: fmt.Sprintf("invalid time %s, use HH:MM", value)}
+	}
+	return hour*60 + minute, nil
+}
+
+// ValidateShift cek format jam shift
+func ValidateShift(shift models.Shift) error {
+	if shift.ShiftCode == "" {
+		return &BusinessError{Message: "shift code is required"}
+	}
+	if _, err := parseClock(shift.StartTime); err != nil {
+		return err
+	}
+	_, err := parseClock(shift.EndTime)
+	return err
+}
+
+// shiftOf menentukan shift dan tanggal kerja dari waktu scan. Scan setelah tengah malam
+// pada shift malam dihitung ke tanggal shift itu dimulai.
+func shiftOf(windows []shiftWindow, t time.Time) (string, string) {
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range windows {
+		switch {
+		case w.start == w.end:
+			return w.code, t.Format("2006-01-02")
+		case w.start < w.end:
+			if minute >= w.start && minute < w.end {
+				return w.code, t.Format("2006-01-02")
+			}
+		default:
+			if minute >= w.start {
+				return w.code, t.Format("2006-01-02")
+			}
+			if minute < w.end {
+				return w.code, t.AddDate(0, 0, -1).Format("2006-01-02")
+			}
+		}
+	}
+	return "", t.Format("2006-01-02")
+}
+
+func (r *ProductivityRepository) shiftWindows() ([]shiftWindow, error) {
+	var shifts []models.Shift
+	if err := r.db.Where("is_active = ?", true).Order("start_time").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+
+	windows := make([]shiftWindow, 0, len(shifts))
+	for _, shift := range shifts {
+		start, err := parseClock(shift.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("shift %s: %w", shift.ShiftCode, err)
+		}
+		end, err := parseClock(shift.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("shift %s: %w", shift.ShiftCode, err)
+		}
+		windows = append(windows, shiftWindow{code: shift.ShiftCode, start: start, end: end})
+	}
+	return windows, nil
+}
+
+func productivityPeriod(filter ProductivityFilter) (time.Time, time.Time, error) {
+	today := time.Now().Format("2006-01-02")
+	if filter.From == "" {
+		filter.From = today
+	}
+	if filter.To == "" {
+		filter.To = filter.From
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", filter.From, time.Local)
+	if err != nil {
+		return from, from, &BusinessError{Message: "invalid from date, use YYYY-MM-DD"}
+	}
+	to, err := time.ParseInLocation("2006-01-02", filter.To, time.Local)
+	if err != nil {
+		return from, to, &BusinessError{Message: "invalid to date, use YYYY-MM-DD"}
+	}
+	if to.Before(from) {
+		return from, to, &BusinessError{Message: "to date is before from date"}
+	}
+	if to.Sub(from).Hours()/24 >= maxProductivityDays {
+		return from, to, &BusinessError{Message: fmt.Sprintf("period is limited to %d days", maxProductivityDays)}
+	}
+	return from, to, nil
+}
+
+// aggregate menjumlahkan event per key, jam aktif = jumlah jam yang ada scan
+func (r *ProductivityRepository) aggregate(filter ProductivityFilter, keyOf func(row ProductivityRow) string) ([]*productivityAcc, error) {
+	from, to, err := productivityPeriod(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := r.shiftWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "e.owner_code")
+
+	// ambil sampai sehari setelah periode supaya shift malam di hari terakhir ikut terhitung
+	args := []interface{}{}
+	for i := 0; i < productivityActivities; i++ {
+		args = append(args, from, to.AddDate(0, 0, 2))
+	}
+	args = append(args, ownerArgs...)
+
+	var events []productivityEvent
+	if err := r.db.Raw(productivityEventSQL+ownerSQL, args...).Scan(&events).Error; err != nil {
+		return nil, err
+	}
+
+	fromDate, toDate := from.Format("2006-01-02"), to.Format("2006-01-02")
+	accs := map[string]*productivityAcc{}
+	var order []string
+	for _, event := range events {
+		if event.UserID == 0 {
+			continue
+		}
+		if filter.Activity != "" && event.Activity != filter.Activity {
+			continue
+		}
+		if filter.UserID != 0 && event.UserID != filter.UserID {
+			continue
+		}
+
+		shiftCode, workDate := shiftOf(windows, event.CreatedAt)
+		if workDate < fromDate || workDate > toDate {
+			continue
+		}
+		if filter.ShiftCode != "" && shiftCode != filter.ShiftCode {
+			continue
+		}
+
+		row := ProductivityRow{WorkDate: workDate, ShiftCode: shiftCode, UserID: event.UserID, Activity: event.Activity}
+		key := keyOf(row)
+		acc, ok := accs[key]
+		if !ok {
+			acc = &productivityAcc{row: row, lines: map[string]bool{}, hours: map[string]bool{}}
+			acc.row.FirstScan = event.CreatedAt
+			acc.row.LastScan = event.CreatedAt
+			accs[key] = acc
+			order = append(order, key)
+		}
+
+		acc.row.Scans++
+		acc.row.Units += event.Qty
+		acc.lines[event.lineKey()] = true
+		acc.hours[event.CreatedAt.Format("2006-01-02 15")] = true
+		if event.CreatedAt.Before(acc.row.FirstScan) {
+			acc.row.FirstScan = event.CreatedAt
+		}
+		if event.CreatedAt.After(acc.row.LastScan) {
+			acc.row.LastScan = event.CreatedAt
+		}
+	}
+
+	result := make([]*productivityAcc, 0, len(order))
+	for _, key := range order {
+		acc := accs[key]
+		acc.row.Lines = len(acc.lines)
+		acc.row.ActiveHours = len(acc.hours)
+		hours := float64(acc.row.ActiveHours)
+		acc.row.ScansPerHour = math.Round(float64(acc.row.Scans)/hours*100) / 100
+		acc.row.LinesPerHour = math.Round(float64(acc.row.Lines)/hours*100) / 100
+		acc.row.UnitsPerHour = math.Round(float64(acc.row.Units)/hours*100) / 100
+		result = append(result, acc)
+	}
+
+	if err := r.fillUsers(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *ProductivityRepository) fillUsers(accs []*productivityAcc) error {
+	var ids []int
+	for _, acc := range accs {
+		ids = append(ids, acc.row.UserID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var users []models.User
+	if err := r.db.Select("id, username, name").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return err
+	}
+	byID := map[int]models.User{}
+	for _, user := range users {
+		byID[int(user.ID)] = user
+	}
+	for _, acc := range accs {
+		acc.row.Username = byID[acc.row.UserID].Username
+		acc.row.Name = byID[acc.row.UserID].Name
+	}
+	return nil
+}
+
+// GetProductivity baris per tanggal kerja, shift, user dan aktivitas
+func (r *ProductivityRepository) GetProductivity(filter ProductivityFilter) ([]ProductivityRow, error) {
+	accs, err := r.aggregate(filter, func(row ProductivityRow) string {
+		return fmt.Sprintf("%s|%s|%d|%s", row.WorkDate, row.ShiftCode, row.UserID, row.Activity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ProductivityRow, len(accs))
+	for i, acc := range accs {
+		rows[i] = acc.row
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].WorkDate != rows[j].WorkDate {
+			return rows[i].WorkDate < rows[j].WorkDate
+		}
+		if rows[i].ShiftCode != rows[j].ShiftCode {
+			return rows[i].ShiftCode < rows[j].ShiftCode
+		}
+		if rows[i].Username != rows[j].Username {
+			return rows[i].Username < rows[j].Username
+		}
+		return rows[i].Activity < rows[j].Activity
+	})
+	return rows, nil
+}
+
+func leaderboardValue(row ProductivityRow, metric string) float64 {
+	switch metric {
+	case "units":
+		return float64(row.Units)
+	case "lines":
+		return float64(row.Lines)
+	case "scans":
+		return float64(row.Scans)
+	case "lines_per_hour":
+		return row.LinesPerHour
+	case "scans_per_hour":
+		return row.ScansPerHour
+	default:
+		return row.UnitsPerHour
+	}
+}
+
+// GetLeaderboard ranking user per aktivitas selama periode, default berdasarkan units per hour
+func (r *ProductivityRepository) GetLeaderboard(filter ProductivityFilter) ([]ProductivityRow, error) {
+	accs, err := r.aggregate(filter, func(row ProductivityRow) string {
+		return fmt.Sprintf("%d|%s", row.UserID, row.Activity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ProductivityRow, len(accs))
+	for i, acc := range accs {
+		rows[i] = acc.row
+		rows[i].WorkDate = ""
+		rows[i].ShiftCode = filter.ShiftCode
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Activity != rows[j].Activity {
+			return rows[i].Activity < rows[j].Activity
+		}
+		return leaderboardValue(rows[i], filter.Metric) > leaderboardValue(rows[j], filter.Metric)
+	})
+
+	for i := range rows {
+		if i > 0 && rows[i].Activity == rows[i-1].Activity {
+			rows[i].Rank = rows[i-1].Rank + 1
+		} else {
+			rows[i].Rank = 1
+		}
+	}
+	return rows, nil
+}
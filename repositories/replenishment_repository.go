@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+
+	"gorm.io/gorm"
+)
+
+type ReplenishmentRepository struct {
+	db *gorm.DB
+}
+
+func NewReplenishmentRepository(db *gorm.DB) *ReplenishmentRepository {
+	return &ReplenishmentRepository{db}
+}
+
+const (
+	ReplenishSourceManual     = "manual"
+	ReplenishSourceAllocation = "allocation"
+)
+
+type ReplenishmentTaskList struct {
+	ID           uint   `json:"ID"`
+	TaskNo       string `json:"task_no"`
+	ItemCode     string `json:"item_code"`
+	ItemName     string `json:"item_name"`
+	Barcode      string `json:"barcode"`
+	OwnerCode    string `json:"owner_code"`
+	WhsCode      string `json:"whs_code"`
+	FromLocation string `json:"from_location"`
+	FromPallet   string `json:"from_pallet"`
+	ToLocation   string `json:"to_location"`
+	Quantity     int    `json:"quantity"`
+	QtyMoved     int    `json:"qty_moved"`
+	Source       string `json:"source"`
+	RefNo        string `json:"ref_no"`
+	Status       string `json:"status"`
+	CreatedAt    string `json:"created_at"`
+}
+
+func (r *ReplenishmentRepository) GenerateTaskNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocReplenishment, ownerCode, whsCode, "")
+}
+
+func (r *ReplenishmentRepository) sumInt(sql string, args ...interface{}) (int, error) {
+	var result struct {
+		Qty int
+	}
+	err := r.db.Raw(sql, args...).Scan(&result).Error
+	return result.Qty, err
+}
+
+// GenerateTasks membuat task replenishment untuk pick face yang stoknya (plus task yang masih open)
+// sudah di bawah atau sama dengan min. Sumber diambil FIFO dari lokasi reserve, yaitu lokasi
+// yang bukan pick face item tersebut. itemCodes kosong = semua setting aktif.
+func (r *ReplenishmentRepository) GenerateTasks(itemCodes []string, source, refNo string, userID int) ([]models.ReplenishmentTask, error) {
+	query := r.db.Where("is_active = ?", true)
+	if len(itemCodes) > 0 {
+		query = query.Where("item_code IN ?", itemCodes)
+	}
+
+	var settings []models.ReplenishmentSetting
+	if err := query.Order("item_code, location").Find(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	var tasks []models.ReplenishmentTask
+	for _, setting := range settings {
+		current, err := r.sumInt(`SELECT COALESCE(SUM(qty_available), 0) AS qty FROM inventories
+			WHERE item_code = ? AND location = ? AND qa_status = 'A' AND deleted_at IS NULL`, setting.ItemCode, setting.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		pending, err := r.sumInt(`SELECT COALESCE(SUM(quantity - qty_moved), 0) AS qty FROM replenishment_tasks
+			WHERE setting_id = ? AND status = 'open' AND deleted_at IS NULL`, setting.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if current+pending > setting.MinQty {
+			continue
+		}
+
+		target := setting.ReplenishTo
+		if target <= 0 || target > setting.MaxQty {
+			target = setting.MaxQty
+		}
+		need := target - current - pending
+		if need <= 0 {
+			continue
+		}
+
+		sourceQuery := r.db.Where("item_code = ? AND qa_status = ? AND qty_available > 0 AND location <> ?", setting.ItemCode, "A", setting.Location).
+			Where("location NOT IN (?)", r.db.Model(&models.ReplenishmentSetting{}).Select("location").Where("item_code = ?", setting.ItemCode))
+		if setting.WhsCode != "" {
+			sourceQuery = sourceQuery.Where("whs_code = ?", setting.WhsCode)
+		}
+		if setting.OwnerCode != "" {
+			sourceQuery = sourceQuery.Where("owner_code = ?", setting.OwnerCode)
+		}
+
+		var inventories []models.Inventory
+		if err := sourceQuery.Order("rec_date ASC, id ASC").Find(&inventories).Error; err != nil {
+			return nil, err
+		}
+
+		for _, inventory := range inventories {
+			if need <= 0 {
+				break
+			}
+
+			// qty yang sudah dijanjikan ke task lain tidak boleh dipakai lagi
+			reserved, err := r.sumInt(`SELECT COALESCE(SUM(quantity - qty_moved), 0) AS qty FROM replenishment_tasks
+				WHERE from_inventory_id = ? AND status = 'open' AND deleted_at IS NULL`, inventory.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			qty := inventory.QtyAvailable - reserved
+			if qty <= 0 {
+				continue
+			}
+			if qty > need {
+				qty = need
+			}
+
+			taskNo, err := r.GenerateTaskNo(setting.OwnerCode, setting.WhsCode)
+			if err != nil {
+				return nil, err
+			}
+
+			task := models.ReplenishmentTask{
+				TaskNo:          taskNo,
+				SettingID:       setting.ID,
+				ItemID:          inventory.ItemId,
+				ItemCode:        inventory.ItemCode,
+				Barcode:         inventory.Barcode,
+				OwnerCode:       inventory.OwnerCode,
+				WhsCode:         inventory.WhsCode,
+				FromInventoryID: int(inventory.ID),
+				FromLocation:    inventory.Location,
+				FromPallet:      inventory.Pallet,
+				ToLocation:      setting.Location,
+				Quantity:        qty,
+				Source:          source,
+				RefNo:           refNo,
+				Status:          "open",
+				CreatedBy:       userID,
+				UpdatedBy:       userID,
+			}
+			if err := r.db.Create(&task).Error; err != nil {
+				return nil, err
+			}
+
+			if _, err := NewTaskRepository(r.db).CreateTask(models.WarehouseTask{
+				TaskType:   TaskReplenish,
+				RefNo:      task.TaskNo,
+				WhsCode:    task.WhsCode,
+				OwnerCode:  task.OwnerCode,
+				Location:   task.FromLocation,
+				ToLocation: task.ToLocation,
+				ItemCode:   task.ItemCode,
+				Quantity:   task.Quantity,
+				CreatedBy:  userID,
+			}); err != nil {
+				return nil, err
+			}
+
+			tasks = append(tasks, task)
+			need -= qty
+		}
+	}
+
+	return tasks, nil
+}
+
+// GetTaskList laporan task replenishment, diurutkan mengikuti jalur lokasi asal
+func (r *ReplenishmentRepository) GetTaskList(status string) ([]ReplenishmentTaskList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "t.owner_code")
+
+	sql := `SELECT t.id, t.task_no, t.item_code, p.item_name, t.barcode, t.owner_code, t.whs_code,
+	t.from_location, t.from_pallet, t.to_location, t.quantity, t.qty_moved, t.source, t.ref_no,
+	t.status, t.created_at
+	FROM replenishment_tasks t
+	LEFT JOIN products p ON t.item_id = p.id
+	LEFT JOIN locations l ON t.from_location = l.location_code AND l.deleted_at IS NULL
+	WHERE t.deleted_at IS NULL`
+	var args []interface{}
+	if status != "" {
+		sql += " AND t.status = ?"
+		args = append(args, status)
+	}
+	sql += ownerSQL + `
+	ORDER BY ` + PickPathOrderSQL("l", "t.from_location") + `, t.task_no`
+	args = append(args, ownerArgs...)
+
+	var list []ReplenishmentTaskList
+	if err := r.db.Raw(sql, args...).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		list = []ReplenishmentTaskList{}
+	}
+
+	return list, nil
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+
+	"gorm.io/gorm"
+)
+
+type ReturnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) *ReturnRepository {
+	return &ReturnRepository{db}
+}
+
+type ShippedItem struct {
+	OutboundDetailID int    `json:"outbound_detail_id"`
+	ItemID           int    `json:"item_id"`
+	ItemCode         string `json:"item_code"`
+	ItemName         string `json:"item_name"`
+	Barcode          string `json:"barcode"`
+	Uom              string `json:"uom"`
+	HasSerial        string `json:"has_serial"`
+	QtyShipped       int    `json:"qty_shipped"`
+	QtyReturned      int    `json:"qty_returned"`
+}
+
+type ReturnList struct {
+	ID           uint   `json:"ID"`
+	ReturnNo     string `json:"return_no"`
+	ReturnDate   string `json:"return_date"`
+	OutboundNo   string `json:"outbound_no"`
+	ShipmentID   string `json:"shipment_id"`
+	CustomerCode string `json:"customer_code"`
+	CustomerName string `json:"customer_name"`
+	OwnerCode    string `json:"owner_code"`
+	WhsCode      string `json:"whs_code"`
+	Reason       string `json:"reason"`
+	Status       string `json:"status"`
+	TotalItem    int    `json:"total_item"`
+	TotalQty     int    `json:"total_qty"`
+}
+
+// GenerateReturnNo membuat nomor RMA lewat numbering service, default RT<YYMMDD><seq>
+func (r *ReturnRepository) GenerateReturnNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocReturn, ownerCode, whsCode, "")
+}
+
+// GetShippedItems mengambil item yang benar-benar terkirim di outbound (dari outbound_barcodes)
+// beserta qty yang sudah diretur di RMA lain yang belum dibatalkan
+func (r *ReturnRepository) GetShippedItems(outboundID int) ([]ShippedItem, error) {
+	sql := `WITH shipped AS (
+		SELECT outbound_detail_id, SUM(quantity) AS qty_shipped
+		FROM outbound_barcodes
+		WHERE outbound_id = ? AND status = 'complete' AND deleted_at IS NULL
+		GROUP BY outbound_detail_id
+	),
+	returned AS (
+		SELECT rd.outbound_detail_id, SUM(rd.quantity) AS qty_returned
+		FROM return_details rd
+		INNER JOIN return_headers rh ON rd.return_id = rh.id
+		WHERE rh.outbound_id = ? AND rh.status <> 'cancel'
+		AND rh.deleted_at IS NULL AND rd.deleted_at IS NULL
+		GROUP BY rd.outbound_detail_id
+	)
+	SELECT od.id AS outbound_detail_id, od.item_id, od.item_code, p.item_name, od.barcode, od.uom,
+	p.has_serial, s.qty_shipped, COALESCE(rt.qty_returned, 0) AS qty_returned
+	FROM outbound_details od
+	INNER JOIN shipped s ON od.id = s.outbound_detail_id
+	LEFT JOIN returned rt ON od.id = rt.outbound_detail_id
+	LEFT JOIN products p ON od.item_id = p.id
+	WHERE od.outbound_id = ? AND od.deleted_at IS NULL
+	ORDER BY od.id`
+
+	var items []ShippedItem
+	if err := r.db.Raw(sql, outboundID, outboundID, outboundID).Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetShippedSerial mencari serial yang terkirim di outbound untuk item tersebut
+func (r *ReturnRepository) GetShippedSerial(outboundID int, itemCode, serialNumber string) (models.OutboundBarcode, error) {
+	var barcode models.OutboundBarcode
+	err := r.db.Where("outbound_id = ? AND item_code = ? AND serial_number = ? AND status = ?", outboundID, itemCode, serialNumber, "complete").
+		First(&barcode).Error
+	return barcode, err
+}
+
+// IsSerialReturned cek apakah serial sudah ada di RMA lain yang belum dibatalkan
+func (r *ReturnRepository) IsSerialReturned(outboundID int, itemCode, serialNumber string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ReturnDetail{}).
+		Joins("INNER JOIN return_headers rh ON return_details.return_id = rh.id").
+		Where("rh.outbound_id = ? AND rh.status <> ? AND rh.deleted_at IS NULL", outboundID, "cancel").
+		Where("return_details.item_code = ? AND return_details.serial_number = ?", itemCode, serialNumber).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ReturnRepository) GetReturnList() ([]ReturnList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "rh.owner_code")
+
+	sql := `WITH rd AS (
+		SELECT return_id, COUNT(DISTINCT item_code) AS total_item, SUM(quantity) AS total_qty
+		FROM return_details
+		WHERE deleted_at IS NULL
+		GROUP BY return_id
+	)
+	SELECT rh.id, rh.return_no, rh.return_date, rh.outbound_no, rh.shipment_id,
+	rh.customer_code, c.customer_name, rh.owner_code, rh.whs_code, rh.reason, rh.status,
+	COALESCE(rd.total_item, 0) AS total_item, COALESCE(rd.total_qty, 0) AS total_qty
+	FROM return_headers rh
+	LEFT JOIN rd ON rh.id = rd.return_id
+	LEFT JOIN customers c ON rh.customer_code = c.customer_code
+	WHERE rh.deleted_at IS NULL` + ownerSQL + `
+	ORDER BY rh.id DESC`
+
+	var list []ReturnList
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
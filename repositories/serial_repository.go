@@ -0,0 +1,323 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type SerialRepository struct {
+	db *gorm.DB
+}
+
+func NewSerialRepository(db *gorm.DB) *SerialRepository {
+	return &SerialRepository{db}
+}
+
+const (
+	SerialReceived  = "received"
+	SerialInStock   = "in_stock"
+	SerialAllocated = "allocated"
+	SerialShipped   = "shipped"
+	SerialReturned  = "returned"
+	SerialVoid      = "void"
+
+	SerialEventReceive       = "RECEIVE"
+	SerialEventReceiveCancel = "RECEIVE_CANCEL"
+	SerialEventPutaway       = "PUTAWAY"
+	SerialEventPick          = "PICK"
+	SerialEventPickCancel    = "PICK_CANCEL"
+	SerialEventShip          = "SHIP"
+	SerialEventReturn        = "RETURN"
+	SerialEventMove          = "MOVE"
+)
+
+// status serial yang barangnya secara fisik masih ada di gudang
+var serialInWarehouse = []string{SerialReceived, SerialInStock, SerialAllocated, SerialReturned}
+
+type SerialHistory struct {
+	models.SerialNumber
+	ItemName string               `json:"item_name"`
+	Events   []models.SerialEvent `json:"events"`
+}
+
+func isSerialInWarehouse(status string) bool {
+	for _, s := range serialInWarehouse {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// find serial tanpa filter owner, satu serial hanya boleh ada sekali per item di semua owner
+func (r *SerialRepository) find(itemCode, serialNo string) (models.SerialNumber, error) {
+	var serial models.SerialNumber
+	err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).
+		Where("item_code = ? AND serial_no = ?", itemCode, serialNo).
+		First(&serial).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return serial, err
+	}
+	return serial, nil
+}
+
+// record menyimpan posisi terakhir serial dan menambah satu baris riwayat
+func (r *SerialRepository) record(serial *models.SerialNumber, eventType, refNo string, userID int) error {
+	db := r.db.Set(helpers.OwnerScopeKey, []string(nil))
+	serial.UpdatedBy = userID
+	if serial.ID == 0 {
+		serial.CreatedBy = userID
+		if err := db.Create(serial).Error; err != nil {
+			return err
+		}
+	} else if err := db.Save(serial).Error; err != nil {
+		return err
+	}
+
+	return db.Create(&models.SerialEvent{
+		SerialID:     serial.ID,
+		ItemCode:     serial.ItemCode,
+		SerialNo:     serial.SerialNo,
+		EventType:    eventType,
+		Status:       serial.Status,
+		OwnerCode:    serial.OwnerCode,
+		WhsCode:      serial.WhsCode,
+		Location:     serial.Location,
+		Pallet:       serial.Pallet,
+		RefNo:        refNo,
+		CustomerCode: serial.CustomerCode,
+		CreatedBy:    userID,
+	}).Error
+}
+
+// Receive mendaftarkan serial yang discan di inbound, ditolak kalau serial masih ada di gudang
+func (r *SerialRepository) Receive(barcode models.InboundBarcode, inboundNo string, userID int) error {
+	serial, err := r.find(barcode.ItemCode, barcode.SerialNumber)
+	if err != nil {
+		return err
+	}
+	if serial.ID > 0 && isSerialInWarehouse(serial.Status) {
+		return &BusinessError{Message: fmt.Sprintf("Serial %s is already %s at %s", barcode.SerialNumber, serial.Status, serial.Location)}
+	}
+
+	serial.ItemID = barcode.ItemID
+	serial.ItemCode = barcode.ItemCode
+	serial.SerialNo = barcode.SerialNumber
+	serial.OwnerCode = barcode.OwnerCode
+	serial.WhsCode = barcode.WhsCode
+	serial.Status = SerialReceived
+	serial.Location = barcode.Location
+	serial.Pallet = barcode.Pallet
+	serial.InventoryID = 0
+	serial.InboundNo = inboundNo
+	return r.record(&serial, SerialEventReceive, inboundNo, userID)
+}
+
+// CancelReceive dipanggil saat scan inbound dihapus sebelum putaway
+func (r *SerialRepository) CancelReceive(barcode models.InboundBarcode, inboundNo string, userID int) error {
+	serial, err := r.find(barcode.ItemCode, barcode.SerialNumber)
+	if err != nil || serial.ID == 0 || serial.Status != SerialReceived || serial.InboundNo != inboundNo {
+		return err
+	}
+
+	serial.Status = SerialVoid
+	return r.record(&serial, SerialEventReceiveCancel, inboundNo, userID)
+}
+
+func (r *SerialRepository) Putaway(barcode models.InboundBarcode, inventory models.Inventory, inboundNo string, userID int) error {
+	serial, err := r.find(barcode.ItemCode, barcode.SerialNumber)
+	if err != nil {
+		return err
+	}
+
+	// serial yang discan sebelum registry ada didaftarkan saat putaway
+	if serial.ID == 0 {
+		serial.ItemID = barcode.ItemID
+		serial.ItemCode = barcode.ItemCode
+		serial.SerialNo = barcode.SerialNumber
+		serial.OwnerCode = barcode.OwnerCode
+		serial.InboundNo = inboundNo
+	}
+
+	serial.WhsCode = inventory.WhsCode
+	serial.Status = SerialInStock
+	serial.Location = inventory.Location
+	serial.Pallet = inventory.Pallet
+	serial.InventoryID = int(inventory.ID)
+	return r.record(&serial, SerialEventPutaway, inboundNo, userID)
+}
+
+// ValidatePick serial harus masih ada di stock. Lokasi tidak dicek karena move sebagian qty
+// tidak menyebut serial mana yang pindah. Serial yang belum terdaftar (stock lama) tidak dicek.
+func (r *SerialRepository) ValidatePick(itemCode, serialNo string) error {
+	serial, err := r.find(itemCode, serialNo)
+	if err != nil || serial.ID == 0 {
+		return err
+	}
+
+	if serial.Status != SerialInStock && serial.Status != SerialReturned {
+		return &BusinessError{Message: fmt.Sprintf("Serial %s is %s, not available for picking", serialNo, serial.Status)}
+	}
+	return nil
+}
+
+// Move dipanggil setiap stock inventory from pindah ke to. Serial tidak discan saat move,
+// jadi serial hanya ikut pindah kalau seluruh on hand from pindah, kalau sebagian posisinya
+// tetap di from. to dengan ID 0 berarti stock keluar (konsumsi kit, adjustment minus).
+func (r *SerialRepository) Move(from, to models.Inventory, qty int, refNo string, userID int) error {
+	if from.ID == 0 || qty < from.QtyOnhand {
+		return nil
+	}
+
+	var serials []models.SerialNumber
+	if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).
+		Where("inventory_id = ? AND status IN ?", from.ID, []string{SerialInStock, SerialReturned}).
+		Find(&serials).Error; err != nil {
+		return err
+	}
+
+	for i := range serials {
+		if to.ID == 0 {
+			serials[i].Status = SerialVoid
+			serials[i].Location = ""
+			serials[i].Pallet = ""
+		} else {
+			serials[i].WhsCode = to.WhsCode
+			serials[i].Location = to.Location
+			serials[i].Pallet = to.Pallet
+		}
+		serials[i].InventoryID = int(to.ID)
+		if err := r.record(&serials[i], SerialEventMove, refNo, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pick menandai serial sudah dipick untuk outbound, lokasi tetap lokasi asal sampai dikirim
+func (r *SerialRepository) Pick(outbound models.OutboundHeader, product models.Product, serialNo string, userID int) error {
+	serial, err := r.find(product.ItemCode, serialNo)
+	if err != nil {
+		return err
+	}
+	if serial.ID == 0 {
+		serial.ItemID = int(product.ID)
+		serial.ItemCode = product.ItemCode
+		serial.SerialNo = serialNo
+		serial.OwnerCode = outbound.OwnerCode
+		serial.WhsCode = outbound.WhsCode
+	}
+
+	serial.Status = SerialAllocated
+	serial.OutboundNo = outbound.OutboundNo
+	serial.CustomerCode = outbound.CustomerCode
+	return r.record(&serial, SerialEventPick, outbound.OutboundNo, userID)
+}
+
+// CancelPick mengembalikan serial ke stock saat scan picking dihapus
+func (r *SerialRepository) CancelPick(itemCode, serialNo, outboundNo string, userID int) error {
+	serial, err := r.find(itemCode, serialNo)
+	if err != nil || serial.ID == 0 || serial.Status != SerialAllocated || serial.OutboundNo != outboundNo {
+		return err
+	}
+
+	serial.Status = SerialInStock
+	return r.record(&serial, SerialEventPickCancel, outboundNo, userID)
+}
+
+// ReleaseOutbound serial yang sudah dipick kembali ke stock saat picking outbound dibatalkan,
+// location diisi kalau barang dipindah ke lokasi sementara
+func (r *SerialRepository) ReleaseOutbound(outboundNo, location string, userID int) error {
+	var serials []models.SerialNumber
+	if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).
+		Where("outbound_no = ? AND status = ?", outboundNo, SerialAllocated).
+		Find(&serials).Error; err != nil {
+		return err
+	}
+
+	for i := range serials {
+		serials[i].Status = SerialInStock
+		if location != "" {
+			serials[i].Location = location
+			serials[i].Pallet = location
+		}
+		if err := r.record(&serials[i], SerialEventPickCancel, outboundNo, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ship semua serial yang dipick untuk outbound jadi shipped ke customer outbound
+func (r *SerialRepository) Ship(outbound models.OutboundHeader, userID int) error {
+	var serials []models.SerialNumber
+	if err := r.db.Set(helpers.OwnerScopeKey, []string(nil)).
+		Where("outbound_no = ? AND status = ?", outbound.OutboundNo, SerialAllocated).
+		Find(&serials).Error; err != nil {
+		return err
+	}
+
+	for i := range serials {
+		serials[i].Status = SerialShipped
+		serials[i].Location = ""
+		serials[i].Pallet = ""
+		serials[i].InventoryID = 0
+		serials[i].CustomerCode = outbound.CustomerCode
+		if err := r.record(&serials[i], SerialEventShip, outbound.OutboundNo, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return serial kembali masuk gudang lewat RMA, outbound dan customer terakhir tetap disimpan
+func (r *SerialRepository) Return(detail models.ReturnDetail, inventory models.Inventory, userID int) error {
+	serial, err := r.find(detail.ItemCode, detail.SerialNumber)
+	if err != nil {
+		return err
+	}
+	if serial.ID == 0 {
+		serial.ItemID = detail.ItemID
+		serial.ItemCode = detail.ItemCode
+		serial.SerialNo = detail.SerialNumber
+	}
+
+	serial.OwnerCode = inventory.OwnerCode
+	serial.WhsCode = inventory.WhsCode
+	serial.Status = SerialReturned
+	serial.Location = inventory.Location
+	serial.Pallet = inventory.Pallet
+	serial.InventoryID = int(inventory.ID)
+	return r.record(&serial, SerialEventReturn, detail.ReturnNo, userID)
+}
+
+// Search mencari serial di semua item beserta riwayatnya: di mana sekarang, dan dikirim ke siapa
+func (r *SerialRepository) Search(serialNo, itemCode string) ([]SerialHistory, error) {
+	query := r.db.Where("serial_no = ?", serialNo)
+	if itemCode != "" {
+		query = query.Where("item_code = ?", itemCode)
+	}
+
+	var serials []models.SerialNumber
+	if err := query.Order("item_code").Find(&serials).Error; err != nil {
+		return nil, err
+	}
+
+	result := []SerialHistory{}
+	for _, serial := range serials {
+		history := SerialHistory{SerialNumber: serial}
+
+		if err := r.db.Model(&models.Product{}).Select("item_name").Where("item_code = ?", serial.ItemCode).Limit(1).Scan(&history.ItemName).Error; err != nil {
+			return nil, err
+		}
+		if err := r.db.Where("serial_id = ?", serial.ID).Order("id").Find(&history.Events).Error; err != nil {
+			return nil, err
+		}
+		result = append(result, history)
+	}
+	return result, nil
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"fiber-app/controllers/helpers"
 	"gorm.io/gorm"
 )
 
@@ -36,6 +37,7 @@ func NewShippingRepository(db *gorm.DB) *ShippingRepository {
 
 func (r *ShippingRepository) GetAllOutboundList() ([]OutboundList, error) {
 	var outboundList []OutboundList
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
 
 	sql := `WITH od AS 
 	 (select outbound_id, count(outbound_id) as total_item, sum(p.cbm) as total_cbm,
@@ -80,10 +82,10 @@ func (r *ShippingRepository) GetAllOutboundList() ([]OutboundList, error) {
 			LEFT JOIN customers cd ON a.deliv_to = cd.customer_code
 			LEFT JOIN order_details odt ON a.id = odt.outbound_id
 			WHERE a.status = 'complete'
-			AND odt.outbound_id IS NULL
+			AND odt.outbound_id IS NULL` + ownerSQL + `
 			order by a.id desc`
 
-	if err := r.db.Raw(sql).Scan(&outboundList).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&outboundList).Error; err != nil {
 		return nil, err
 	}
 
@@ -113,6 +115,15 @@ type OrderList struct {
 
 func (r *ShippingRepository) GetOrderSummaryList() ([]OrderList, error) {
 	var orderList []OrderList
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "sh.owner_code")
+
+	// order bisa berisi banyak outbound, tampilkan kalau ada outbound milik owner user
+	orderScope := ""
+	if ownerSQL != "" {
+		orderScope = `WHERE EXISTS (SELECT 1 FROM order_details sd
+			INNER JOIN outbound_headers sh ON sd.outbound_id = sh.id
+			WHERE sd.order_id = oh.id` + ownerSQL + `)`
+	}
 	sql := `WITH obh AS
 (
 		SELECT a.order_id, 
@@ -150,9 +161,10 @@ dlv.total_drop
 FROM order_headers oh
 LEFT JOIN obh ON oh.id = obh.order_id
 LEFT JOIN dlv ON oh.id = dlv.order_id
+` + orderScope + `
 order by oh.order_no DESC`
 
-	if err := r.db.Raw(sql).Scan(&orderList).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&orderList).Error; err != nil {
 		return nil, err
 	}
 
@@ -176,6 +188,7 @@ type OrderDetailItem struct {
 
 func (r *ShippingRepository) GetOrderDetailItem(outboundID int) ([]OrderDetailItem, error) {
 	var orderDetailItem []OrderDetailItem
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "obh.owner_code")
 	sql := `SELECT od.outbound_id,
 	od.outbound_no,
 	od.deliv_to,
@@ -192,9 +205,10 @@ func (r *ShippingRepository) GetOrderDetailItem(outboundID int) ([]OrderDetailIt
 	FROM order_details od
 	INNER JOIN outbound_details odt ON od.outbound_id = odt.outbound_id
 	LEFT JOIN products p ON odt.item_id = p.id
-	WHERE order_id = ?`
+	INNER JOIN outbound_headers obh ON od.outbound_id = obh.id
+	WHERE order_id = ?` + ownerSQL
 
-	if err := r.db.Raw(sql, outboundID).Scan(&orderDetailItem).Error; err != nil {
+	if err := r.db.Raw(sql, append([]interface{}{outboundID}, ownerArgs...)...).Scan(&orderDetailItem).Error; err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StockSnapshotRepository struct {
+	db *gorm.DB
+}
+
+func NewStockSnapshotRepository(db *gorm.DB) *StockSnapshotRepository {
+	return &StockSnapshotRepository{db: db}
+}
+
+type StockAsOfFilter struct {
+	OwnerCode string
+	WhsCode   string
+	ItemCode  string
+}
+
+type StockAsOf struct {
+	OwnerCode    string `json:"owner_code"`
+	WhsCode      string `json:"whs_code"`
+	ItemID       int    `json:"item_id"`
+	ItemCode     string `json:"item_code"`
+	ItemName     string `json:"item_name"`
+	Location     string `json:"location"`
+	QaStatus     string `json:"qa_status"`
+	QtyOnhand    int    `json:"qty_onhand"`
+	QtyAvailable int    `json:"qty_available"`
+	QtyAllocated int    `json:"qty_allocated"`
+}
+
+type StockAsOfResult struct {
+	AsOfDate        string      `json:"as_of_date"`
+	SnapshotDate    string      `json:"snapshot_date"`
+	SnapshotTakenAt *time.Time  `json:"snapshot_taken_at"`
+	MovementCount   int         `json:"movement_count"`
+	Items           []StockAsOf `json:"items"`
+}
+
+type stockKey struct {
+	OwnerCode string
+	WhsCode   string
+	ItemID    int
+	Location  string
+	QaStatus  string
+}
+
+// TakeSnapshot menyimpan saldo inventory saat ini sebagai snapshot tanggal snapshotDate.
+// Snapshot lama di tanggal yang sama diganti.
+func (r *StockSnapshotRepository) TakeSnapshot(snapshotDate string, userID int) (int, error) {
+	takenAt := time.Now()
+	var rows []models.StockSnapshot
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("snapshot_date = ?", snapshotDate).Delete(&models.StockSnapshot{}).Error; err != nil {
+			return err
+		}
+
+		sql := `select owner_code, whs_code, item_id, item_code, location, qa_status,
+		sum(qty_onhand) as qty_onhand,
+		sum(qty_available) as qty_available,
+		sum(qty_allocated) as qty_allocated
+		from inventories
+		where deleted_at is null
+		group by owner_code, whs_code, item_id, item_code, location, qa_status
+		having sum(qty_onhand) <> 0 or sum(qty_available) <> 0 or sum(qty_allocated) <> 0`
+
+		if err := tx.Raw(sql).Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for i := range rows {
+			rows[i].SnapshotDate = snapshotDate
+			rows[i].TakenAt = takenAt
+			rows[i].CreatedAt = takenAt
+			rows[i].CreatedBy = userID
+		}
+
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rows), nil
+}
+
+// GetStockAsOf menghitung saldo stock di akhir tanggal asOf:
+// snapshot terakhir sebelum akhir hari tersebut + movement setelah snapshot diambil.
+// Kalau belum ada snapshot, saldo dihitung dari seluruh movement.
+func (r *StockSnapshotRepository) GetStockAsOf(asOf time.Time, filter StockAsOfFilter) (StockAsOfResult, error) {
+	endOfDay := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location()).AddDate(0, 0, 1)
+
+	result := StockAsOfResult{
+		AsOfDate: asOf.Format("2006-01-02"),
+		Items:    []StockAsOf{},
+	}
+
+	balances := map[stockKey]*StockAsOf{}
+
+	var lastSnapshot models.StockSnapshot
+	err := r.db.Where("taken_at < ?", endOfDay).Order("taken_at DESC").First(&lastSnapshot).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
+	}
+
+	movementQuery := r.db.Model(&models.InventoryMovement{}).Where("created_at < ?", endOfDay)
+
+	if err == nil {
+		result.SnapshotDate = lastSnapshot.SnapshotDate
+		result.SnapshotTakenAt = &lastSnapshot.TakenAt
+
+		var snapshots []models.StockSnapshot
+		query := r.db.Where("snapshot_date = ? AND taken_at = ?", lastSnapshot.SnapshotDate, lastSnapshot.TakenAt)
+		query = applyStockAsOfFilter(query, filter)
+		if err := query.Find(&snapshots).Error; err != nil {
+			return result, err
+		}
+
+		for _, s := range snapshots {
+			key := stockKey{s.OwnerCode, s.WhsCode, s.ItemID, s.Location, s.QaStatus}
+			balances[key] = &StockAsOf{
+				OwnerCode:    s.OwnerCode,
+				WhsCode:      s.WhsCode,
+				ItemID:       s.ItemID,
+				ItemCode:     s.ItemCode,
+				Location:     s.Location,
+				QaStatus:     s.QaStatus,
+				QtyOnhand:    s.QtyOnhand,
+				QtyAvailable: s.QtyAvailable,
+				QtyAllocated: s.QtyAllocated,
+			}
+		}
+
+		movementQuery = movementQuery.Where("created_at > ?", lastSnapshot.TakenAt)
+	}
+
+	var movements []models.InventoryMovement
+	if err := applyStockAsOfFilter(movementQuery, filter).Find(&movements).Error; err != nil {
+		return result, err
+	}
+	result.MovementCount = len(movements)
+
+	for _, m := range movements {
+		key := stockKey{m.OwnerCode, m.WhsCode, m.ItemID, m.Location, m.QaStatus}
+		balance, ok := balances[key]
+		if !ok {
+			balance = &StockAsOf{
+				OwnerCode: m.OwnerCode,
+				WhsCode:   m.WhsCode,
+				ItemID:    m.ItemID,
+				ItemCode:  m.ItemCode,
+				Location:  m.Location,
+				QaStatus:  m.QaStatus,
+			}
+			balances[key] = balance
+		}
+		balance.QtyOnhand += m.QtyChange
+		balance.QtyAvailable += m.QtyAvailableAfter - m.QtyAvailableBefore
+		balance.QtyAllocated += m.QtyAllocatedAfter - m.QtyAllocatedBefore
+	}
+
+	itemIDs := []int{}
+	for _, b := range balances {
+		if b.QtyOnhand == 0 && b.QtyAvailable == 0 && b.QtyAllocated == 0 {
+			continue
+		}
+		result.Items = append(result.Items, *b)
+		itemIDs = append(itemIDs, b.ItemID)
+	}
+
+	if len(itemIDs) > 0 {
+		var products []models.Product
+		if err := r.db.Select("id, item_name").Where("id IN ?", itemIDs).Find(&products).Error; err != nil {
+			return result, err
+		}
+		names := map[int]string{}
+		for _, p := range products {
+			names[int(p.ID)] = p.ItemName
+		}
+		for i := range result.Items {
+			result.Items[i].ItemName = names[result.Items[i].ItemID]
+		}
+	}
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		a, b := result.Items[i], result.Items[j]
+		if a.OwnerCode != b.OwnerCode {
+			return a.OwnerCode < b.OwnerCode
+		}
+		if a.WhsCode != b.WhsCode {
+			return a.WhsCode < b.WhsCode
+		}
+		if a.ItemCode != b.ItemCode {
+			return a.ItemCode < b.ItemCode
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.QaStatus < b.QaStatus
+	})
+
+	return result, nil
+}
+
+func applyStockAsOfFilter(query *gorm.DB, filter StockAsOfFilter) *gorm.DB {
+	if filter.OwnerCode != "" {
+		query = query.Where("owner_code = ?", filter.OwnerCode)
+	}
+	if filter.WhsCode != "" {
+		query = query.Where("whs_code = ?", filter.WhsCode)
+	}
+	if filter.ItemCode != "" {
+		query = query.Where("item_code = ?", filter.ItemCode)
+	}
+	return query
+}
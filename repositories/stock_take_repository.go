@@ -1,11 +1,21 @@
 package repositories
 
 import (
+	"fiber-app/controllers/helpers"
 	"fiber-app/models"
 
 	"gorm.io/gorm"
 )
 
+const (
+	StockTakeOpen    = "open"
+	StockTakePosted  = "posted"
+	StockTakeRecount = "recount"
+
+	CountFull  = "full"
+	CountCycle = "cycle"
+)
+
 type StockTakeRepository struct {
 	db *gorm.DB
 }
@@ -28,6 +38,13 @@ type ProgressStockTake struct {
 	ProgressQty         float64 `json:"progress_qty"`
 }
 
+// StockTakeFound barcode yang discan di lokasi tapi tidak ada di stock take item
+type StockTakeFound struct {
+	Location string
+	Barcode  string
+	Qty      int
+}
+
 type ViewModelCardStockTake struct {
 	Location string `json:"location"`
 	ItemCode string `json:"item_code"`
@@ -43,6 +60,8 @@ type ViewModelCardStockTake struct {
 
 func (r *StockTakeRepository) GetProgressStockTakeByID(stockTakeID int) ([]ProgressStockTake, error) {
 
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "inv.owner_code")
+
 	sql := `WITH data_system AS (
         SELECT 
             a.stock_take_id, 
@@ -50,6 +69,8 @@ func (r *StockTakeRepository) GetProgressStockTakeByID(stockTakeID int) ([]Progr
             a.location AS location_system,
             SUM(a.system_qty) AS qty_system
         FROM stock_take_items a
+        LEFT JOIN inventories inv ON inv.id = a.inventory_id
+        WHERE 1 = 1` + ownerSQL + `
         GROUP BY a.stock_take_id, a.barcode, a.location
     ),
 
@@ -110,7 +131,7 @@ func (r *StockTakeRepository) GetProgressStockTakeByID(stockTakeID int) ([]Progr
 
 	var progressStockTake []ProgressStockTake
 
-	if err := r.db.Raw(sql, stockTakeID).Scan(&progressStockTake).Error; err != nil {
+	if err := r.db.Raw(sql, append(ownerArgs, stockTakeID)...).Scan(&progressStockTake).Error; err != nil {
 		return nil, err
 	}
 
@@ -119,17 +140,19 @@ func (r *StockTakeRepository) GetProgressStockTakeByID(stockTakeID int) ([]Progr
 }
 
 func (r *StockTakeRepository) GetAllStockCard() ([]ViewModelCardStockTake, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+
 	sql := `WITH inv AS
     (select a.location, a.item_code, a.barcode, a.whs_code, SUM(a.qty_onhand) as quantity 
     from inventories a
-    where a.qty_onhand > 0
+    where a.qty_onhand > 0` + ownerSQL + `
     group by a.location, a.item_code, a.barcode, a.whs_code)
     SELECT distinct location, inv.item_code, inv.barcode, quantity, row, bay, level, bin, itm.item_name, inv.whs_code
     FROM inv 
     INNER JOIN locations loc ON inv.location = loc.location_code
     INNER JOIN products itm ON inv.item_code = itm.item_code`
 	var stockCards []ViewModelCardStockTake
-	if err := r.db.Raw(sql).Scan(&stockCards).Error; err != nil {
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&stockCards).Error; err != nil {
 		return nil, err
 	}
 
@@ -140,12 +163,14 @@ func (r *StockTakeRepository) GetAllStockCard() ([]ViewModelCardStockTake, error
 }
 
 func (r *StockTakeRepository) GetFilteredStockCard(filter models.StockCardFilter) ([]ViewModelCardStockTake, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+
 	sql := `
 	WITH inv AS (
 		SELECT 
 			a.location, a.item_code, a.barcode, a.whs_code, SUM(a.qty_onhand) as quantity 
 		FROM inventories a
-		WHERE a.qty_onhand > 0
+		WHERE a.qty_onhand > 0` + ownerSQL + `
 		GROUP BY a.location, a.item_code, a.barcode, a.whs_code
 	)
 	SELECT DISTINCT 
@@ -162,12 +187,12 @@ func (r *StockTakeRepository) GetFilteredStockCard(filter models.StockCardFilter
 		loc.bin >= ? AND loc.bin <= ?
 	`
 
-	var args []interface{} = []interface{}{
+	var args []interface{} = append(ownerArgs,
 		filter.FromRow, filter.ToRow,
 		filter.FromBay, filter.ToBay,
 		filter.FromLevel, filter.ToLevel,
 		filter.FromBin, filter.ToBin,
-	}
+	)
 
 	// if strings.TrimSpace(filter.Area) != "" {
 	// 	sql += " AND loc.area = ?"
@@ -229,3 +254,83 @@ func (r *StockTakeRepository) GetFilteredStockCard(filter models.StockCardFilter
 
 // 	return stockCards, nil
 // }
+
+// ApplyCounts mengisi counted_qty dan difference stock take item dari hasil scan, pembagian qty
+// lihat splitCounts
+func (r *StockTakeRepository) ApplyCounts(stockTake models.StockTake, userID int) ([]models.StockTakeItem, []StockTakeFound, error) {
+	var counts []StockTakeFound
+	if err := r.db.Model(&models.StockTakeBarcode{}).
+		Select("location, barcode, SUM(counted_qty) AS qty").
+		Where("stock_take_id = ?", stockTake.ID).
+		Group("location, barcode").
+		Scan(&counts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var items []models.StockTakeItem
+	if err := r.db.Where("stock_take_id = ?", stockTake.ID).Order("id").Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+
+	found := splitCounts(items, counts)
+	for i := range items {
+		items[i].UpdatedBy = userID
+		if err := r.db.Model(&models.StockTakeItem{}).Where("id = ?", items[i].ID).Updates(map[string]interface{}{
+			"counted_qty": items[i].CountedQty,
+			"difference":  items[i].Difference,
+			"updated_by":  userID,
+		}).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, found, nil
+}
+
+// splitCounts membagi qty count per lokasi + barcode ke item stock take berurutan: item selain
+// yang terakhir maksimal sebanyak system qty-nya, sisanya ke item terakhir. Count tanpa item
+// di system dikembalikan sebagai found.
+func splitCounts(items []models.StockTakeItem, counts []StockTakeFound) []StockTakeFound {
+	counted := map[string]int{}
+	for _, count := range counts {
+		counted[count.Location+"|"+count.Barcode] += count.Qty
+	}
+
+	last := map[string]int{}
+	for i, item := range items {
+		last[item.Location+"|"+item.Barcode] = i
+	}
+
+	for i := range items {
+		key := items[i].Location + "|" + items[i].Barcode
+		qty := counted[key]
+		if last[key] != i && qty > items[i].SystemQty {
+			qty = items[i].SystemQty
+		}
+		counted[key] -= qty
+
+		items[i].CountedQty = qty
+		items[i].Difference = qty - items[i].SystemQty
+	}
+
+	var found []StockTakeFound
+	for _, count := range counts {
+		key := count.Location + "|" + count.Barcode
+		if _, ok := last[key]; ok || counted[key] <= 0 {
+			continue
+		}
+		found = append(found, StockTakeFound{Location: count.Location, Barcode: count.Barcode, Qty: counted[key]})
+		counted[key] = 0
+	}
+	return found
+}
+
+// Finish menutup stock take dan task count yang masih terbuka
+func (r *StockTakeRepository) Finish(stockTake models.StockTake, status string, userID int) error {
+	if err := r.db.Model(&models.StockTake{}).Where("id = ?", stockTake.ID).Updates(map[string]interface{}{
+		"status":     status,
+		"updated_by": userID,
+	}).Error; err != nil {
+		return err
+	}
+	return NewTaskRepository(r.db).CloseTask(TaskCount, stockTake.Code, userID)
+}
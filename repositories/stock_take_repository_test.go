@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"fiber-app/models"
+	"reflect"
+	"testing"
+)
+
+func TestSplitCounts(t *testing.T) {
+	item := func(location, barcode string, systemQty int) models.StockTakeItem {
+		return models.StockTakeItem{Location: location, Barcode: barcode, SystemQty: systemQty}
+	}
+
+	tests := []struct {
+		name      string
+		items     []models.StockTakeItem
+		counts    []StockTakeFound
+		wantQty   []int
+		wantDiff  []int
+		wantFound []StockTakeFound
+	}{
+		{
+			name:     "exact count",
+			items:    []models.StockTakeItem{item("A-01", "111", 10)},
+			counts:   []StockTakeFound{{Location: "A-01", Barcode: "111", Qty: 10}},
+			wantQty:  []int{10},
+			wantDiff: []int{0},
+		},
+		{
+			name:     "not counted",
+			items:    []models.StockTakeItem{item("A-01", "111", 10)},
+			wantQty:  []int{0},
+			wantDiff: []int{-10},
+		},
+		{
+			name:     "shortage fills items in order",
+			items:    []models.StockTakeItem{item("A-01", "111", 10), item("A-01", "111", 5)},
+			counts:   []StockTakeFound{{Location: "A-01", Barcode: "111", Qty: 12}},
+			wantQty:  []int{10, 2},
+			wantDiff: []int{0, -3},
+		},
+		{
+			name:     "surplus goes to last item",
+			items:    []models.StockTakeItem{item("A-01", "111", 10), item("A-01", "111", 5)},
+			counts:   []StockTakeFound{{Location: "A-01", Barcode: "111", Qty: 20}},
+			wantQty:  []int{10, 10},
+			wantDiff: []int{0, 5},
+		},
+		{
+			name:  "scans split over several rows are summed",
+			items: []models.StockTakeItem{item("A-01", "111", 10), item("B-01", "111", 4)},
+			counts: []StockTakeFound{
+				{Location: "A-01", Barcode: "111", Qty: 6},
+				{Location: "A-01", Barcode: "111", Qty: 3},
+				{Location: "B-01", Barcode: "111", Qty: 4},
+			},
+			wantQty:  []int{9, 4},
+			wantDiff: []int{-1, 0},
+		},
+		{
+			name:  "barcode not in system is found",
+			items: []models.StockTakeItem{item("A-01", "111", 10)},
+			counts: []StockTakeFound{
+				{Location: "A-01", Barcode: "111", Qty: 10},
+				{Location: "A-01", Barcode: "222", Qty: 2},
+				{Location: "A-01", Barcode: "222", Qty: 1},
+			},
+			wantQty:   []int{10},
+			wantDiff:  []int{0},
+			wantFound: []StockTakeFound{{Location: "A-01", Barcode: "222", Qty: 3}},
+		},
+		{
+			name:      "same barcode at another location is found",
+			items:     []models.StockTakeItem{item("A-01", "111", 10)},
+			counts:    []StockTakeFound{{Location: "A-02", Barcode: "111", Qty: 4}},
+			wantQty:   []int{0},
+			wantDiff:  []int{-10},
+			wantFound: []StockTakeFound{{Location: "A-02", Barcode: "111", Qty: 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := splitCounts(tt.items, tt.counts)
+			for i, it := range tt.items {
+				if it.CountedQty != tt.wantQty[i] || it.Difference != tt.wantDiff[i] {
+					t.Errorf("item %d counted %d difference %d, want %d and %d", i, it.CountedQty, it.Difference, tt.wantQty[i], tt.wantDiff[i])
+				}
+			}
+			if !reflect.DeepEqual(found, tt.wantFound) {
+				t.Errorf("found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
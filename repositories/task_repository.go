@@ -0,0 +1,372 @@
+package repositories
+
+import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type TaskRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskRepository(db *gorm.DB) *TaskRepository {
+	return &TaskRepository{db}
+}
+
+const (
+	TaskPutaway   = "putaway"
+	TaskPick      = "pick"
+	TaskReplenish = "replenish"
+	TaskCount     = "count"
+	TaskMove      = "move"
+
+	TaskStatusOpen    = "open"
+	TaskStatusClaimed = "claimed"
+	TaskStatusDone    = "done"
+	TaskStatusCancel  = "cancel"
+)
+
+var TaskTypes = []string{TaskPutaway, TaskPick, TaskReplenish, TaskCount, TaskMove}
+
+// prioritas default, makin besar makin dulu dikerjakan
+var defaultTaskPriority = map[string]int{
+	TaskReplenish: 30,
+	TaskPick:      20,
+	TaskPutaway:   10,
+	TaskMove:      10,
+	TaskCount:     5,
+}
+
+var activeTaskStatus = []string{TaskStatusOpen, TaskStatusClaimed}
+
+// TaskFilter filter daftar task, field kosong tidak difilter
+type TaskFilter struct {
+	TaskType       string `query:"task_type"`
+	Status         string `query:"status"`
+	WhsCode        string `query:"whs_code"`
+	RefNo          string `query:"ref_no"`
+	AssignedUserID int    `query:"assigned_user_id"`
+	AssignedRole   string `query:"assigned_role"`
+	ClaimedBy      int    `query:"claimed_by"`
+}
+
+type TaskList struct {
+	ID             uint       `json:"ID"`
+	TaskType       string     `json:"task_type"`
+	RefNo          string     `json:"ref_no"`
+	WhsCode        string     `json:"whs_code"`
+	OwnerCode      string     `json:"owner_code"`
+	Location       string     `json:"location"`
+	ToLocation     string     `json:"to_location"`
+	ItemCode       string     `json:"item_code"`
+	Quantity       int        `json:"quantity"`
+	Priority       int        `json:"priority"`
+	AssignedUserID int        `json:"assigned_user_id"`
+	AssignedUser   string     `json:"assigned_user"`
+	AssignedRole   string     `json:"assigned_role"`
+	Status         string     `json:"status"`
+	ClaimedBy      int        `json:"claimed_by"`
+	ClaimedUser    string     `json:"claimed_user"`
+	StartedAt      *time.Time `json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+	Remarks        string     `json:"remarks"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (r *TaskRepository) activeTask(taskType, refNo string) (models.WarehouseTask, bool, error) {
+	var task models.WarehouseTask
+	err := r.db.Where("task_type = ? AND ref_no = ? AND status IN ?", taskType, refNo, activeTaskStatus).First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return task, false, nil
+	}
+	return task, err == nil, err
+}
+
+// CreateTask membuat task untuk dokumen, satu dokumen hanya punya satu task aktif per tipe
+func (r *TaskRepository) CreateTask(task models.WarehouseTask) (models.WarehouseTask, error) {
+	existing, found, err := r.activeTask(task.TaskType, task.RefNo)
+	if err != nil || found {
+		return existing, err
+	}
+
+	if task.Priority == 0 {
+		task.Priority = defaultTaskPriority[task.TaskType]
+	}
+	task.Status = TaskStatusOpen
+	task.UpdatedBy = task.CreatedBy
+
+	err = r.db.Create(&task).Error
+	return task, err
+}
+
+func (r *TaskRepository) finish(taskType, refNo, status string, userID int) error {
+	now := time.Now()
+	return r.db.Model(&models.WarehouseTask{}).
+		Where("task_type = ? AND ref_no = ? AND status IN ?", taskType, refNo, activeTaskStatus).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"started_at":   gorm.Expr("COALESCE(started_at, ?)", now),
+			"ended_at":     now,
+			"completed_by": userID,
+			"updated_by":   userID,
+		}).Error
+}
+
+// CloseTask dipanggil flow yang sudah selesai mengerjakan dokumen
+func (r *TaskRepository) CloseTask(taskType, refNo string, userID int) error {
+	return r.finish(taskType, refNo, TaskStatusDone, userID)
+}
+
+func (r *TaskRepository) CancelTask(taskType, refNo string, userID int) error {
+	return r.finish(taskType, refNo, TaskStatusCancel, userID)
+}
+
+// EnsureClaim dipanggil sebelum scan: task yang sedang dikerjakan operator lain ditolak,
+// task yang masih open otomatis di-claim operator yang scan. Dokumen tanpa task dibiarkan.
+func (r *TaskRepository) EnsureClaim(taskType, refNo string, userID int) error {
+	task, found, err := r.activeTask(taskType, refNo)
+	if err != nil || !found {
+		return err
+	}
+
+	if task.Status == TaskStatusClaimed {
+		if task.ClaimedBy != userID {
+			return &BusinessError{Message: fmt.Sprintf("%s task %s is being worked by another operator", taskType, refNo), Status: fiber.StatusConflict}
+		}
+		return nil
+	}
+
+	_, err = r.claim(task, userID)
+	return err
+}
+
+func (r *TaskRepository) claim(task models.WarehouseTask, userID int) (models.WarehouseTask, error) {
+	now := time.Now()
+	result := r.db.Model(&models.WarehouseTask{}).
+		Where("id = ? AND status = ?", task.ID, TaskStatusOpen).
+		Updates(map[string]interface{}{
+			"status":     TaskStatusClaimed,
+			"claimed_by": userID,
+			"claimed_at": now,
+			"started_at": now,
+			"updated_by": userID,
+		})
+	if result.Error != nil {
+		return task, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return task, &BusinessError{Message: "task is already claimed by another operator", Status: fiber.StatusConflict}
+	}
+
+	task.Status = TaskStatusClaimed
+	task.ClaimedBy = userID
+	task.ClaimedAt = &now
+	task.StartedAt = &now
+	return task, nil
+}
+
+func (r *TaskRepository) findTask(taskID uint) (models.WarehouseTask, error) {
+	var task models.WarehouseTask
+	if err := r.db.First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return task, &BusinessError{Message: "task not found", Status: fiber.StatusConflict}
+		}
+		return task, err
+	}
+	return task, nil
+}
+
+func canWorkTask(task models.WarehouseTask, userID int, roles []string) bool {
+	if task.AssignedUserID != 0 && task.AssignedUserID != userID {
+		return false
+	}
+	if task.AssignedRole == "" {
+		return true
+	}
+	for _, role := range roles {
+		if role == task.AssignedRole {
+			return true
+		}
+	}
+	return false
+}
+
+// Claim mengambil task tertentu, hanya kalau masih open dan sesuai assignment user/role
+func (r *TaskRepository) Claim(taskID uint, userID int, roles []string) (models.WarehouseTask, error) {
+	task, err := r.findTask(taskID)
+	if err != nil {
+		return task, err
+	}
+
+	if task.Status == TaskStatusClaimed && task.ClaimedBy == userID {
+		return task, nil
+	}
+	if task.Status != TaskStatusOpen {
+		return task, &BusinessError{Message: "task is " + task.Status, Status: fiber.StatusConflict}
+	}
+	if !canWorkTask(task, userID, roles) {
+		return task, &BusinessError{Message: "task is assigned to another user or role", Status: fiber.StatusConflict}
+	}
+
+	return r.claim(task, userID)
+}
+
+// Release melepas task supaya bisa diambil operator lain, timing dimulai ulang
+func (r *TaskRepository) Release(taskID uint, userID int) error {
+	task, err := r.findTask(taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status != TaskStatusClaimed || task.ClaimedBy != userID {
+		return &BusinessError{Message: "task is not claimed by this user", Status: fiber.StatusConflict}
+	}
+
+	// syarat masih di-claim user ini, task yang baru saja selesai tidak dibuka lagi
+	result := r.db.Model(&models.WarehouseTask{}).
+		Where("id = ? AND status = ? AND claimed_by = ?", task.ID, TaskStatusClaimed, userID).
+		Updates(map[string]interface{}{
+			"status":     TaskStatusOpen,
+			"claimed_by": 0,
+			"claimed_at": nil,
+			"started_at": nil,
+			"updated_by": userID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &BusinessError{Message: "task is not claimed by this user", Status: fiber.StatusConflict}
+	}
+	return nil
+}
+
+// Complete menutup task manual dari RF (count, move), hanya oleh operator yang meng-claim
+func (r *TaskRepository) Complete(taskID uint, userID int) error {
+	task, err := r.findTask(taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status != TaskStatusClaimed || task.ClaimedBy != userID {
+		return &BusinessError{Message: "task is not claimed by this user", Status: fiber.StatusConflict}
+	}
+
+	return r.finish(task.TaskType, task.RefNo, TaskStatusDone, userID)
+}
+
+// NextTask mengembalikan task yang sedang di-claim user, atau meng-claim task open dengan
+// prioritas tertinggi yang boleh dikerjakan user. Nil kalau antrian kosong.
+func (r *TaskRepository) NextTask(userID int, roles []string) (*models.WarehouseTask, error) {
+	var current models.WarehouseTask
+	err := r.db.Where("status = ? AND claimed_by = ?", TaskStatusClaimed, userID).
+		Order("priority DESC, id ASC").First(&current).Error
+	if err == nil {
+		return &current, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	query := r.db.Where("status = ? AND (assigned_user_id = 0 OR assigned_user_id = ?)", TaskStatusOpen, userID)
+	if len(roles) > 0 {
+		query = query.Where("(COALESCE(assigned_role, '') = '' OR assigned_role IN ?)", roles)
+	} else {
+		query = query.Where("COALESCE(assigned_role, '') = ''")
+	}
+
+	var candidates []models.WarehouseTask
+	if err := query.Order("priority DESC, id ASC").Limit(10).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	// kandidat bisa keburu diambil scanner lain, lanjut ke berikutnya
+	for _, candidate := range candidates {
+		task, err := r.claim(candidate, userID)
+		if err == nil {
+			return &task, nil
+		}
+		var taskErr *BusinessError
+		if !errors.As(err, &taskErr) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// UserRoles nama role user, dari users.role dan relasi user_roles
+func (r *TaskRepository) UserRoles(userID int) ([]string, error) {
+	var roles []string
+	if err := r.db.Raw(`SELECT r.name FROM roles r
+		INNER JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = ? AND r.deleted_at IS NULL`, userID).Scan(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := r.db.Select("role").First(&user, userID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if user.Role != "" {
+		roles = append(roles, user.Role)
+	}
+	return roles, nil
+}
+
+// GetTaskList daftar task untuk monitoring, yang aktif di atas diurutkan prioritas
+func (r *TaskRepository) GetTaskList(filter TaskFilter) ([]TaskList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "t.owner_code")
+
+	sql := `SELECT t.id, t.task_type, t.ref_no, t.whs_code, t.owner_code, t.location, t.to_location,
+	t.item_code, t.quantity, t.priority, t.assigned_user_id, au.username AS assigned_user,
+	t.assigned_role, t.status, t.claimed_by, cu.username AS claimed_user,
+	t.started_at, t.ended_at, t.remarks, t.created_at
+	FROM warehouse_tasks t
+	LEFT JOIN users au ON t.assigned_user_id = au.id
+	LEFT JOIN users cu ON t.claimed_by = cu.id
+	WHERE t.deleted_at IS NULL` + ownerSQL
+	args := ownerArgs
+
+	if filter.Status == "active" {
+		sql += " AND t.status IN ?"
+		args = append(args, activeTaskStatus)
+	} else if filter.Status != "" {
+		sql += " AND t.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.TaskType != "" {
+		sql += " AND t.task_type = ?"
+		args = append(args, filter.TaskType)
+	}
+	if filter.WhsCode != "" {
+		sql += " AND t.whs_code = ?"
+		args = append(args, filter.WhsCode)
+	}
+	if filter.RefNo != "" {
+		sql += " AND t.ref_no = ?"
+		args = append(args, filter.RefNo)
+	}
+	if filter.AssignedUserID != 0 {
+		sql += " AND t.assigned_user_id = ?"
+		args = append(args, filter.AssignedUserID)
+	}
+	if filter.AssignedRole != "" {
+		sql += " AND t.assigned_role = ?"
+		args = append(args, filter.AssignedRole)
+	}
+	if filter.ClaimedBy != 0 {
+		sql += " AND t.claimed_by = ?"
+		args = append(args, filter.ClaimedBy)
+	}
+	sql += ` ORDER BY CASE WHEN t.status IN ('open', 'claimed') THEN 0 ELSE 1 END, t.priority DESC, t.id DESC`
+
+	var list []TaskList
+	if err := r.db.Raw(sql, args...).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"fiber-app/controllers/helpers"
+
+	"gorm.io/gorm"
+)
+
+type WaveRepository struct {
+	db *gorm.DB
+}
+
+func NewWaveRepository(db *gorm.DB) *WaveRepository {
+	return &WaveRepository{db}
+}
+
+// WaveFilter adalah kriteria pengelompokan outbound ke dalam wave, field kosong tidak difilter
+type WaveFilter struct {
+	WhsCode         string `json:"whs_code" query:"whs_code"`
+	OwnerCode       string `json:"owner_code" query:"owner_code"`
+	PlanPickupDate  string `json:"plan_pickup_date" query:"plan_pickup_date"`
+	TransporterCode string `json:"transporter_code" query:"transporter_code"`
+	CustCity        string `json:"cust_city" query:"cust_city"`
+	OrderNo         string `json:"order_no" query:"order_no"`
+}
+
+type WaveCandidate struct {
+	ID              int    `json:"ID"`
+	OutboundNo      string `json:"outbound_no"`
+	OutboundDate    string `json:"outbound_date"`
+	OwnerCode       string `json:"owner_code"`
+	WhsCode         string `json:"whs_code"`
+	CustomerCode    string `json:"customer_code"`
+	CustCity        string `json:"cust_city"`
+	TransporterCode string `json:"transporter_code"`
+	PlanPickupDate  string `json:"plan_pickup_date"`
+	TotalItem       int    `json:"total_item"`
+	TotalQty        int    `json:"total_qty"`
+}
+
+type WaveList struct {
+	ID              uint   `json:"ID"`
+	WaveNo          string `json:"wave_no"`
+	WhsCode         string `json:"whs_code"`
+	OwnerCode       string `json:"owner_code"`
+	PlanPickupDate  string `json:"plan_pickup_date"`
+	TransporterCode string `json:"transporter_code"`
+	CustCity        string `json:"cust_city"`
+	OrderNo         string `json:"order_no"`
+	Status          string `json:"status"`
+	TotalOutbound   int    `json:"total_outbound"`
+	QtyPick         int    `json:"qty_pick"`
+	QtySorted       int    `json:"qty_sorted"`
+}
+
+type WavePickItem struct {
+	Location      string `json:"location"`
+	Pallet        string `json:"pallet"`
+	ItemID        int    `json:"item_id"`
+	ItemCode      string `json:"item_code"`
+	ItemName      string `json:"item_name"`
+	Barcode       string `json:"barcode"`
+	LotNo         string `json:"lot_no"`
+	ExpDate       string `json:"exp_date"`
+	QaStatus      string `json:"qa_status"`
+	Quantity      int    `json:"quantity"`
+	TotalOutbound int    `json:"total_outbound"`
+}
+
+type WaveSortItem struct {
+	OutboundID int    `json:"outbound_id"`
+	OutboundNo string `json:"outbound_no"`
+	SortSlot   int    `json:"sort_slot"`
+	ItemCode   string `json:"item_code"`
+	Barcode    string `json:"barcode"`
+	QtyPick    int    `json:"qty_pick"`
+	QtySorted  int    `json:"qty_sorted"`
+}
+
+// active wave = wave yang belum dibatalkan
+const activeWaveOutboundSQL = `SELECT 1 FROM wave_outbounds wo
+	INNER JOIN waves w ON wo.wave_id = w.id
+	WHERE wo.outbound_id = a.id AND w.status <> 'cancel'
+	AND w.deleted_at IS NULL AND wo.deleted_at IS NULL`
+
+func (r *WaveRepository) GenerateWaveNo(ownerCode, whsCode string) (string, error) {
+	return NewNumberingRepository(r.db).Next(DocWave, ownerCode, whsCode, "")
+}
+
+// GetActiveWaveNo mengembalikan nomor wave aktif dari outbound, kosong kalau tidak ada
+func (r *WaveRepository) GetActiveWaveNo(outboundID int) (string, error) {
+	var waveNo []string
+	err := r.db.Raw(`SELECT w.wave_no FROM wave_outbounds wo
+		INNER JOIN waves w ON wo.wave_id = w.id
+		WHERE wo.outbound_id = ? AND w.status <> 'cancel'
+		AND w.deleted_at IS NULL AND wo.deleted_at IS NULL`, outboundID).
+		Scan(&waveNo).Error
+	if err != nil || len(waveNo) == 0 {
+		return "", err
+	}
+	return waveNo[0], nil
+}
+
+// GetCandidates mengambil outbound open yang cocok dengan kriteria dan belum masuk wave lain
+func (r *WaveRepository) GetCandidates(filter WaveFilter) ([]WaveCandidate, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "a.owner_code")
+
+	sql := `WITH od AS (
+		SELECT outbound_id, COUNT(DISTINCT item_code) AS total_item, SUM(quantity) AS total_qty
+		FROM outbound_details
+		WHERE deleted_at IS NULL
+		GROUP BY outbound_id
+	)
+	SELECT a.id, a.outbound_no, a.outbound_date, a.owner_code, a.whs_code, a.customer_code,
+	a.cust_city, a.transporter_code, a.plan_pickup_date,
+	COALESCE(od.total_item, 0) AS total_item, COALESCE(od.total_qty, 0) AS total_qty
+	FROM outbound_headers a
+	LEFT JOIN od ON a.id = od.outbound_id
+	WHERE a.status = 'open' AND a.deleted_at IS NULL
+	AND NOT EXISTS (` + activeWaveOutboundSQL + `)` + ownerSQL
+
+	args := ownerArgs
+	if filter.WhsCode != "" {
+		sql += " AND a.whs_code = ?"
+		args = append(args, filter.WhsCode)
+	}
+	if filter.OwnerCode != "" {
+		sql += " AND a.owner_code = ?"
+		args = append(args, filter.OwnerCode)
+	}
+	if filter.PlanPickupDate != "" {
+		sql += " AND a.plan_pickup_date = ?"
+		args = append(args, filter.PlanPickupDate)
+	}
+	if filter.TransporterCode != "" {
+		sql += " AND a.transporter_code = ?"
+		args = append(args, filter.TransporterCode)
+	}
+	if filter.CustCity != "" {
+		sql += " AND a.cust_city = ?"
+		args = append(args, filter.CustCity)
+	}
+	if filter.OrderNo != "" {
+		sql += " AND EXISTS (SELECT 1 FROM order_details ord WHERE ord.outbound_id = a.id AND ord.order_no = ? AND ord.deleted_at IS NULL)"
+		args = append(args, filter.OrderNo)
+	}
+	sql += " ORDER BY a.outbound_no"
+
+	var candidates []WaveCandidate
+	if err := r.db.Raw(sql, args...).Scan(&candidates).Error; err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (r *WaveRepository) GetWaveList() ([]WaveList, error) {
+	ownerSQL, ownerArgs := helpers.OwnerScopeSQL(r.db, "w.owner_code")
+
+	sql := `WITH wo AS (
+		SELECT wave_id, COUNT(*) AS total_outbound
+		FROM wave_outbounds
+		WHERE deleted_at IS NULL
+		GROUP BY wave_id
+	),
+	pk AS (
+		SELECT wave_id, SUM(quantity) AS qty_pick
+		FROM outbound_pickings
+		WHERE wave_id > 0 AND deleted_at IS NULL
+		GROUP BY wave_id
+	),
+	st AS (
+		SELECT wo.wave_id, SUM(ob.quantity) AS qty_sorted
+		FROM outbound_barcodes ob
+		INNER JOIN wave_outbounds wo ON ob.outbound_id = wo.outbound_id AND wo.deleted_at IS NULL
+		WHERE ob.deleted_at IS NULL
+		GROUP BY wo.wave_id
+	)
+	SELECT w.id, w.wave_no, w.whs_code, w.owner_code, w.plan_pickup_date, w.transporter_code,
+	w.cust_city, w.order_no, w.status,
+	COALESCE(wo.total_outbound, 0) AS total_outbound,
+	COALESCE(pk.qty_pick, 0) AS qty_pick,
+	COALESCE(st.qty_sorted, 0) AS qty_sorted
+	FROM waves w
+	LEFT JOIN wo ON w.id = wo.wave_id
+	LEFT JOIN pk ON w.id = pk.wave_id
+	LEFT JOIN st ON w.id = st.wave_id
+	WHERE w.deleted_at IS NULL` + ownerSQL + `
+	ORDER BY w.id DESC`
+
+	var list []WaveList
+	if err := r.db.Raw(sql, ownerArgs...).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetWavePickList menggabungkan picking sheet semua outbound di wave per lokasi dan item,
+// diurutkan mengikuti jalur lokasi supaya picker cukup sekali jalan
+func (r *WaveRepository) GetWavePickList(waveID uint) ([]WavePickItem, error) {
+	sql := `SELECT p.location, p.pallet, p.item_id, p.item_code, pr.item_name, p.barcode,
+	p.lot_no, p.exp_date, p.qa_status,
+	SUM(p.quantity) AS quantity, COUNT(DISTINCT p.outbound_id) AS total_outbound
+	FROM outbound_pickings p
+	LEFT JOIN locations l ON p.location = l.location_code AND l.deleted_at IS NULL
+	LEFT JOIN products pr ON p.item_id = pr.id
+	WHERE p.wave_id = ? AND p.deleted_at IS NULL
+	GROUP BY p.location, p.pallet, p.item_id, p.item_code, pr.item_name, p.barcode,
+	p.lot_no, p.exp_date, p.qa_status, l.pick_sequence
+	ORDER BY ` + PickPathOrderSQL("l", "p.location") + `, p.item_code`
+
+	var items []WavePickItem
+	if err := r.db.Raw(sql, waveID).Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetSortProgress menampilkan qty picking per outbound dan item serta qty yang sudah disortir ke outbound
+func (r *WaveRepository) GetSortProgress(waveID uint) ([]WaveSortItem, error) {
+	sql := `WITH pk AS (
+		SELECT outbound_id, barcode, item_code, SUM(quantity) AS qty_pick
+		FROM outbound_pickings
+		WHERE wave_id = ? AND deleted_at IS NULL
+		GROUP BY outbound_id, barcode, item_code
+	),
+	st AS (
+		SELECT outbound_id, barcode, SUM(quantity) AS qty_sorted
+		FROM outbound_barcodes
+		WHERE deleted_at IS NULL
+		GROUP BY outbound_id, barcode
+	)
+	SELECT wo.outbound_id, wo.outbound_no, wo.sort_slot, pk.item_code, pk.barcode,
+	pk.qty_pick, COALESCE(st.qty_sorted, 0) AS qty_sorted
+	FROM wave_outbounds wo
+	INNER JOIN pk ON wo.outbound_id = pk.outbound_id
+	LEFT JOIN st ON pk.outbound_id = st.outbound_id AND pk.barcode = st.barcode
+	WHERE wo.wave_id = ? AND wo.deleted_at IS NULL
+	ORDER BY wo.sort_slot, pk.item_code`
+
+	var items []WaveSortItem
+	if err := r.db.Raw(sql, waveID, waveID).Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var adjustmentPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                        "adjustment.view",
+	"POST /reasons":                "adjustment.reason",
+	"DELETE /reasons/:id":          "adjustment.reason",
+	"POST /":                       "adjustment.create",
+	"POST /approve/:adjustment_no": "adjustment.approve",
+	"POST /reject/:adjustment_no":  "adjustment.approve",
+})
+
+func SetupAdjustmentRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/adjustments",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/adjustments", adjustmentPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/reasons", database.Handle((*controllers.AdjustmentController).GetReasons))
+	api.Post("/reasons", database.Handle((*controllers.AdjustmentController).SaveReason))
+	api.Delete("/reasons/:id", database.Handle((*controllers.AdjustmentController).DeleteReason))
+	api.Get("/", database.Handle((*controllers.AdjustmentController).GetAdjustments))
+	api.Get("/:adjustment_no", database.Handle((*controllers.AdjustmentController).GetAdjustmentByNo))
+	api.Post("/", database.Handle((*controllers.AdjustmentController).CreateAdjustment))
+	api.Post("/approve/:adjustment_no", database.Handle((*controllers.AdjustmentController).ApproveAdjustment))
+	api.Post("/reject/:adjustment_no", database.Handle((*controllers.AdjustmentController).RejectAdjustment))
+}
@@ -3,21 +3,40 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupAuthRoutes(app *fiber.App) {
+var authPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"POST /login":                  "",
+	"POST /refresh":                "",
+	"GET /logout":                  "",
+	"POST /logout-all":             "",
+	"GET /sessions":                "",
+	"DELETE /sessions/:session_id": "",
+	"GET /token-setting":           "setting.token",
+	"PUT /token-setting":           "setting.token",
+})
 
-	authController := &controllers.AuthController{}
+func SetupAuthRoutes(app *fiber.App) {
 	api := app.Group(config.MAIN_ROUTES + "/auth")
 	api.Post("/login", middleware.LoginMiddleware, controllers.Login)
 	api.Post("/refresh", middleware.LoginMiddleware, controllers.RefreshToken)
-	// api.Get("/isLoggedIn", middleware.AuthMiddleware, authController.IsLoggedIn)
+	// api.Get("/isLoggedIn", middleware.AuthMiddleware, database.Handle((*controllers.AuthController).IsLoggedIn))
 
-	apiLogout := app.Group(config.MAIN_ROUTES+"/auth", middleware.AuthMiddleware)
-	apiLogout.Use(database.InjectDBMiddleware(authController))
-	apiLogout.Get("/logout", authController.Logout)
+	apiLogout := app.Group(
+		config.MAIN_ROUTES+"/auth",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/auth", authPermissions),
+	)
+	apiLogout.Use(database.InjectDBMiddleware)
+	apiLogout.Get("/logout", database.Handle((*controllers.AuthController).Logout))
+	apiLogout.Post("/logout-all", database.Handle((*controllers.AuthController).LogoutAll))
+	apiLogout.Get("/sessions", database.Handle((*controllers.AuthController).GetSessions))
+	apiLogout.Delete("/sessions/:session_id", database.Handle((*controllers.AuthController).RevokeSession))
+	apiLogout.Get("/token-setting", database.Handle((*controllers.AuthController).GetTokenSetting))
+	apiLogout.Put("/token-setting", database.Handle((*controllers.AuthController).UpdateTokenSetting))
 }
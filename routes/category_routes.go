@@ -3,17 +3,25 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var categoryPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET /": "category.view",
+})
+
 func SetupCategoryRoutes(app *fiber.App) {
 
-	api := app.Group(config.MAIN_ROUTES+"/categories", middleware.AuthMiddleware)
-	productController := &controllers.ProductController{}
-	api.Use(database.InjectDBMiddleware(productController))
+	api := app.Group(
+		config.MAIN_ROUTES+"/categories",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/categories", categoryPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Get("/", productController.GetAllCategory)
+	api.Get("/", database.Handle((*controllers.ProductController).GetAllCategory))
 }
@@ -3,20 +3,31 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var customerPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":       "customer.view",
+	"POST /":      "customer.create",
+	"PUT /:id":    "customer.update",
+	"DELETE /:id": "customer.delete",
+})
+
 func SetupCustomerRoutes(app *fiber.App) {
-	api := app.Group(config.MAIN_ROUTES+"/customers", middleware.AuthMiddleware)
-	customerController := &controllers.CustomerController{}
-	api.Use(database.InjectDBMiddleware(customerController))
+	api := app.Group(
+		config.MAIN_ROUTES+"/customers",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/customers", customerPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Get("/", customerController.GetAllCustomers)
-	api.Post("/", customerController.CreateCustomer)
-	api.Get("/:id", customerController.GetCustomerByID)
-	api.Put("/:id", customerController.UpdateCustomer)
-	api.Delete("/:id", customerController.DeleteCustomer)
+	api.Get("/", database.Handle((*controllers.CustomerController).GetAllCustomers))
+	api.Post("/", database.Handle((*controllers.CustomerController).CreateCustomer))
+	api.Get("/:id", database.Handle((*controllers.CustomerController).GetCustomerByID))
+	api.Put("/:id", database.Handle((*controllers.CustomerController).UpdateCustomer))
+	api.Delete("/:id", database.Handle((*controllers.CustomerController).DeleteCustomer))
 }
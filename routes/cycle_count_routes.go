@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var cycleCountPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":               "cycle_count.view",
+	"POST /classes":       "cycle_count.manage",
+	"DELETE /classes/:id": "cycle_count.manage",
+	"POST /classify":      "cycle_count.manage",
+	"POST /generate":      "cycle_count.generate",
+	"POST /recount/:code": "cycle_count.recount",
+})
+
+func SetupCycleCountRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/cycle-count",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/cycle-count", cycleCountPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/classes", database.Handle((*controllers.CycleCountController).GetClasses))
+	api.Post("/classes", database.Handle((*controllers.CycleCountController).SaveClass))
+	api.Delete("/classes/:id", database.Handle((*controllers.CycleCountController).DeleteClass))
+	api.Post("/classify", database.Handle((*controllers.CycleCountController).Classify))
+	api.Get("/classifications", database.Handle((*controllers.CycleCountController).GetClassifications))
+	api.Get("/due", database.Handle((*controllers.CycleCountController).GetDue))
+	api.Post("/generate", database.Handle((*controllers.CycleCountController).Generate))
+	api.Post("/recount/:code", database.Handle((*controllers.CycleCountController).Recount))
+	api.Get("/accuracy", database.Handle((*controllers.CycleCountController).GetAccuracy))
+}
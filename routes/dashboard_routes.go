@@ -3,16 +3,24 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var dashboardPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET /": "dashboard.view",
+})
+
 func SetupDashboardRoutes(app *fiber.App) {
-	api := app.Group(config.MAIN_ROUTES+"/dashboard", middleware.AuthMiddleware)
-	dashboardController := &controllers.DashboardController{}
-	api.Use(database.InjectDBMiddleware(dashboardController))
+	api := app.Group(
+		config.MAIN_ROUTES+"/dashboard",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/dashboard", dashboardPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Get("/", dashboardController.GetDashboard)
+	api.Get("/", database.Handle((*controllers.DashboardController).GetDashboard))
 }
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var dockPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                             "dock.view",
+	"POST /doors":                       "dock.door",
+	"POST /appointments":                "dock.book",
+	"PUT /appointments/:appointment_no": "dock.book",
+	"POST /appointments/cancel/:appointment_no": "dock.cancel",
+	"POST /appointments/*":                      "dock.checkin",
+})
+
+func SetupDockRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/dock",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/dock", dockPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/doors", database.Handle((*controllers.DockController).GetDoors))
+	api.Post("/doors", database.Handle((*controllers.DockController).SaveDoor))
+	api.Get("/schedule", database.Handle((*controllers.DockController).GetSchedule))
+	api.Get("/appointments", database.Handle((*controllers.DockController).GetAppointments))
+	api.Post("/appointments", database.Handle((*controllers.DockController).BookAppointment))
+	api.Put("/appointments/:appointment_no", database.Handle((*controllers.DockController).RescheduleAppointment))
+	api.Post("/appointments/check-in/:appointment_no", database.Handle((*controllers.DockController).CheckIn))
+	api.Post("/appointments/dock/:appointment_no", database.Handle((*controllers.DockController).Dock))
+	api.Post("/appointments/check-out/:appointment_no", database.Handle((*controllers.DockController).CheckOut))
+	api.Post("/appointments/cancel/:appointment_no", database.Handle((*controllers.DockController).CancelAppointment))
+}
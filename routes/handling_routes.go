@@ -3,31 +3,39 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupHandlingRoutes(app *fiber.App) {
-	handlingController := &controllers.HandlingController{}
-	api := app.Group(config.MAIN_ROUTES+"/handling", middleware.AuthMiddleware)
-
-	api.Use(database.InjectDBMiddleware(handlingController))
-
-	// api.Get("/items", handlingController.GetAllItemHandling)
-	// api.Get("/items/:id", handlingController.GetItemHandlingByID)
-	// api.Put("/items/:id", handlingController.UpdateItemHandlingByID)
-	// api.Delete("/items/:id", handlingController.DeleteItemHandling)
+var handlingPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"POST /": "handling.create",
+})
 
-	api.Post("/", handlingController.Create)
-	// api.Post("/combine", handlingController.CreateCombineHandling)
-	// api.Get("/", handlingController.GetAll)
-	// api.Get("/origin", handlingController.GetAllOriginHandling)
-	// api.Get("/:id", handlingController.GetByID)
-	// api.Put("/:id", handlingController.Update)
-	// api.Delete("/:id", handlingController.Delete)
-
-	// api.Post("/items", handlingController.CreateItemHandling)
+func SetupHandlingRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/handling",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/handling", handlingPermissions),
+	)
+
+	api.Use(database.InjectDBMiddleware)
+
+	// api.Get("/items", database.Handle((*controllers.HandlingController).GetAllItemHandling))
+	// api.Get("/items/:id", database.Handle((*controllers.HandlingController).GetItemHandlingByID))
+	// api.Put("/items/:id", database.Handle((*controllers.HandlingController).UpdateItemHandlingByID))
+	// api.Delete("/items/:id", database.Handle((*controllers.HandlingController).DeleteItemHandling))
+
+	api.Post("/", database.Handle((*controllers.HandlingController).Create))
+	// api.Post("/combine", database.Handle((*controllers.HandlingController).CreateCombineHandling))
+	// api.Get("/", database.Handle((*controllers.HandlingController).GetAll))
+	// api.Get("/origin", database.Handle((*controllers.HandlingController).GetAllOriginHandling))
+	// api.Get("/:id", database.Handle((*controllers.HandlingController).GetByID))
+	// api.Put("/:id", database.Handle((*controllers.HandlingController).Update))
+	// api.Delete("/:id", database.Handle((*controllers.HandlingController).Delete))
+
+	// api.Post("/items", database.Handle((*controllers.HandlingController).CreateItemHandling))
 
 }
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var holdPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                  "hold.view",
+	"POST /reasons":          "hold.reason",
+	"DELETE /reasons/:id":    "hold.reason",
+	"POST /":                 "hold.create",
+	"POST /approve/:hold_no": "hold.approve",
+	"POST /release/:hold_no": "hold.release",
+})
+
+func SetupHoldRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/holds",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/holds", holdPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/reasons", database.Handle((*controllers.HoldController).GetReasons))
+	api.Post("/reasons", database.Handle((*controllers.HoldController).SaveReason))
+	api.Delete("/reasons/:id", database.Handle((*controllers.HoldController).DeleteReason))
+	api.Get("/", database.Handle((*controllers.HoldController).GetHolds))
+	api.Get("/:hold_no", database.Handle((*controllers.HoldController).GetHoldByNo))
+	api.Post("/", database.Handle((*controllers.HoldController).CreateHold))
+	api.Post("/approve/:hold_no", database.Handle((*controllers.HoldController).ApproveHold))
+	api.Post("/release/:hold_no", database.Handle((*controllers.HoldController).ReleaseHold))
+}
@@ -2,30 +2,49 @@ package routes
 
 import (
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupInboundRoutes(app *fiber.App) {
+var inboundPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                        "inbound.view",
+	"POST /":                       "inbound.create",
+	"PUT /:inbound_no":             "inbound.update",
+	"DELETE /item/:id":             "inbound.update",
+	"POST /checking":               "inbound.update",
+	"POST /handle-putaway":         "inbound.putaway",
+	"POST /putaway-bulk":           "inbound.putaway",
+	"POST /cross-dock/:inbound_no": "inbound.putaway",
+	"POST /complete/:inbound_no":   "inbound.complete",
+	"POST /open":                   "inbound.open",
+})
 
-	inboundController := &controllers.InboundController{}
-	api := app.Group("/api/v1/inbound", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(inboundController))
+func SetupInboundRoutes(app *fiber.App) {
+	api := app.Group(
+		"/api/v1/inbound",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission("/api/v1/inbound", inboundPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Post("/handle-putaway", inboundController.PutawayByInboundNo)
-	api.Post("/putaway-bulk", inboundController.PutawayBulk)
+	api.Post("/handle-putaway", database.Handle((*controllers.InboundController).PutawayByInboundNo))
+	api.Post("/putaway-bulk", database.Handle((*controllers.InboundController).PutawayBulk))
 
-	api.Post("/", inboundController.CreateInbound)
-	api.Get("/", inboundController.GetAllListInbound)
-	api.Get("/inventory/:inbound_no", inboundController.GetInventoryByInbound)
-	api.Put("/:inbound_no", inboundController.UpdateInboundByID)
-	api.Get("/:inbound_no", inboundController.GetInboundByID)
-	api.Get("/item/:id", inboundController.GetItem)
-	api.Delete("/item/:id", inboundController.DeleteItem)
-	api.Get("/putaway/sheet/:id", inboundController.GetPutawaySheet)
-	api.Post("/complete/:inbound_no", inboundController.HandleComplete)
-	api.Post("/open", inboundController.HandleOpen)
-	api.Post("/checking", inboundController.HandleChecking)
+	api.Post("/", database.Handle((*controllers.InboundController).CreateInbound))
+	api.Get("/", database.Handle((*controllers.InboundController).GetAllListInbound))
+	api.Get("/inventory/:inbound_no", database.Handle((*controllers.InboundController).GetInventoryByInbound))
+	api.Put("/:inbound_no", database.Handle((*controllers.InboundController).UpdateInboundByID))
+	api.Get("/:inbound_no", database.Handle((*controllers.InboundController).GetInboundByID))
+	api.Get("/item/:id", database.Handle((*controllers.InboundController).GetItem))
+	api.Delete("/item/:id", database.Handle((*controllers.InboundController).DeleteItem))
+	api.Get("/putaway/sheet/:id", database.Handle((*controllers.InboundController).GetPutawaySheet))
+	api.Get("/putaway/suggest/:inbound_no", database.Handle((*controllers.InboundController).SuggestPutaway))
+	api.Get("/cross-dock/:inbound_no", database.Handle((*controllers.InboundController).GetCrossDock))
+	api.Post("/cross-dock/:inbound_no", database.Handle((*controllers.InboundController).ConfirmCrossDock))
+	api.Post("/complete/:inbound_no", database.Handle((*controllers.InboundController).HandleComplete))
+	api.Post("/open", database.Handle((*controllers.InboundController).HandleOpen))
+	api.Post("/checking", database.Handle((*controllers.InboundController).HandleChecking))
 }
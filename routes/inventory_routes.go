@@ -3,20 +3,37 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var inventoryPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":           "inventory.view",
+	"POST /rf/pallet": "inventory.view",
+	"POST /snapshot":  "inventory.snapshot",
+	"POST /rf/move":   "inventory.move",
+	"POST /change":    "inventory.change_status",
+})
+
 func SetupInventoryRoutes(app *fiber.App) {
-	inventoryController := &controllers.InventoryController{}
-	api := app.Group(config.MAIN_ROUTES+"/inventory", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(inventoryController))
+	api := app.Group(
+		config.MAIN_ROUTES+"/inventory",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/inventory", inventoryPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Get("/", inventoryController.GetInventory)
-	api.Get("/excel", inventoryController.ExportExcel)
-	api.Post("/rf/pallet", inventoryController.GetInventoryByPalletAndLocation)
-	api.Post("/rf/move", inventoryController.MoveItem)
-	api.Post("/change", inventoryController.ChangeStatusInventory)
+	api.Get("/", database.Handle((*controllers.InventoryController).GetInventory))
+	api.Get("/excel", database.Handle((*controllers.InventoryController).ExportExcel))
+	api.Get("/stock-card", database.Handle((*controllers.InventoryController).GetStockCard))
+	api.Get("/invalid-location", database.Handle((*controllers.InventoryController).GetInvalidLocationStock))
+	api.Get("/as-of", database.Handle((*controllers.InventoryController).GetStockAsOf))
+	api.Get("/as-of/excel", database.Handle((*controllers.InventoryController).ExportStockAsOf))
+	api.Post("/snapshot", database.Handle((*controllers.InventoryController).TakeStockSnapshot))
+	api.Post("/rf/pallet", database.Handle((*controllers.InventoryController).GetInventoryByPalletAndLocation))
+	api.Post("/rf/move", database.Handle((*controllers.InventoryController).MoveItem))
+	api.Post("/change", database.Handle((*controllers.InventoryController).ChangeStatusInventory))
 }
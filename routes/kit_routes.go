@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var kitPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                               "kitting.view",
+	"POST /boms":                          "kitting.bom",
+	"DELETE /boms/:id":                    "kitting.bom",
+	"POST /orders":                        "kitting.create",
+	"POST /orders/complete/:kit_order_no": "kitting.complete",
+	"POST /orders/cancel/:kit_order_no":   "kitting.cancel",
+})
+
+func SetupKitRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/kitting",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/kitting", kitPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/boms", database.Handle((*controllers.KitController).GetBoms))
+	api.Get("/boms/:kit_item_code", database.Handle((*controllers.KitController).GetBomByItem))
+	api.Post("/boms", database.Handle((*controllers.KitController).SaveBom))
+	api.Delete("/boms/:id", database.Handle((*controllers.KitController).DeleteBom))
+	api.Get("/orders", database.Handle((*controllers.KitController).GetKitOrders))
+	api.Get("/orders/:kit_order_no", database.Handle((*controllers.KitController).GetKitOrderByNo))
+	api.Post("/orders", database.Handle((*controllers.KitController).CreateKitOrder))
+	api.Post("/orders/complete/:kit_order_no", database.Handle((*controllers.KitController).CompleteKitOrder))
+	api.Post("/orders/cancel/:kit_order_no", database.Handle((*controllers.KitController).CancelKitOrder))
+}
@@ -2,26 +2,39 @@ package routes
 
 import (
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var locationPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":               "location.view",
+	"POST /":              "location.create",
+	"POST /pick-sequence": "location.update",
+	"PUT /:id":            "location.update",
+	"DELETE /:id":         "location.delete",
+})
+
 func SetupLocationRoutes(app *fiber.App) {
 	// Group endpoint with prefix and auth middleware
-	api := app.Group("/api/v1/locations", middleware.AuthMiddleware)
+	api := app.Group(
+		"/api/v1/locations",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission("/api/v1/locations", locationPermissions),
+	)
 
 	// Create controller instance
-	locationController := &controllers.LocationController{}
 
 	// Inject DB ke controller
-	api.Use(database.InjectDBMiddleware(locationController))
+	api.Use(database.InjectDBMiddleware)
 
 	// Register endpoints
-	api.Post("/", locationController.CreateLocation)
-	api.Get("/", locationController.GetAllLocations)
-	api.Get("/:id", locationController.GetLocationByID)
-	api.Put("/:id", locationController.UpdateLocation)
-	api.Delete("/:id", locationController.DeleteLocation)
+	api.Post("/", database.Handle((*controllers.LocationController).CreateLocation))
+	api.Get("/", database.Handle((*controllers.LocationController).GetAllLocations))
+	api.Post("/pick-sequence", database.Handle((*controllers.LocationController).SetPickSequence))
+	api.Get("/:id", database.Handle((*controllers.LocationController).GetLocationByID))
+	api.Put("/:id", database.Handle((*controllers.LocationController).UpdateLocation))
+	api.Delete("/:id", database.Handle((*controllers.LocationController).DeleteLocation))
 }
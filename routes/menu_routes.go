@@ -3,26 +3,36 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var menuPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET /user":             "",
+	"GET *":                 "menu.view",
+	"POST /":                "menu.create",
+	"PUT /:id":              "menu.update",
+	"POST /permissions/:id": "menu.update",
+	"DELETE /:id":           "menu.delete",
+})
+
 func SetupMenuRoutes(app *fiber.App) {
-	menuController := &controllers.MenuController{}
 	api := app.Group(
 		config.MAIN_ROUTES+"/menus",
 		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/menus", menuPermissions),
 	)
-	api.Use(database.InjectDBMiddleware(menuController))
+	api.Use(database.InjectDBMiddleware)
 
-	api.Get("/permissions/:id", menuController.GetMenuPermission)
-	api.Post("/permissions/:id", menuController.UpdatePermissionMenus)
-	api.Get("/user", menuController.GetMenuUser)
-	api.Get("/", menuController.GetAllMenus)
-	api.Get("/:id", menuController.GetMenuByID)
-	api.Post("/", menuController.CreateMenu)
-	api.Put("/:id", menuController.UpdateMenu)
-	api.Delete("/:id", menuController.DeleteMenu)
+	api.Get("/permissions/:id", database.Handle((*controllers.MenuController).GetMenuPermission))
+	api.Post("/permissions/:id", database.Handle((*controllers.MenuController).UpdatePermissionMenus))
+	api.Get("/user", database.Handle((*controllers.MenuController).GetMenuUser))
+	api.Get("/", database.Handle((*controllers.MenuController).GetAllMenus))
+	api.Get("/:id", database.Handle((*controllers.MenuController).GetMenuByID))
+	api.Post("/", database.Handle((*controllers.MenuController).CreateMenu))
+	api.Put("/:id", database.Handle((*controllers.MenuController).UpdateMenu))
+	api.Delete("/:id", database.Handle((*controllers.MenuController).DeleteMenu))
 }
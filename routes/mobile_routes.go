@@ -2,6 +2,7 @@ package routes
 
 import (
 	"fiber-app/config"
+	"fiber-app/controllers/helpers"
 	"fiber-app/controllers/mobiles"
 	"fiber-app/database"
 	"fiber-app/middleware"
@@ -9,51 +10,117 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// semua group mobile memakai prefix /mobile, jadi tiap map dipasang ke sub-prefix masing-masing
+var mobileInboundPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                 "mobile.inbound.view",
+	"POST /check":           "mobile.inbound.view",
+	"POST /search/location": "mobile.inbound.view",
+	"POST /scan":            "mobile.inbound.scan",
+	"DELETE /scan/:id":      "mobile.inbound.scan",
+	"PUT /barcode/:id":      "mobile.inbound.scan",
+})
+
+var mobileInventoryPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                           "mobile.inventory.view",
+	"POST /location/barcode":          "mobile.inventory.view",
+	"POST /transfer/location/barcode": "mobile.inventory.transfer",
+	"POST /transfer-by-inventory-id":  "mobile.inventory.transfer",
+	"POST /replenishment/:task_no":    "mobile.inventory.replenish",
+	"POST /dummy":                     "mobile.inventory.dummy",
+	"POST /add-location":              "location.create",
+})
+
+var mobileOutboundPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                           "mobile.outbound.view",
+	"POST /item-check/:outbound_no":   "mobile.outbound.view",
+	"POST /picking/scan/:outbound_no": "mobile.outbound.pick",
+	"DELETE /picking/scan/:id":        "mobile.outbound.pick",
+	"POST /picking/override/:id":      "mobile.outbound.override",
+})
+
+var mobilePackingPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":    "mobile.packing.view",
+	"POST *":   "mobile.packing.pack",
+	"DELETE *": "mobile.packing.pack",
+})
+
+var mobileTaskPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":  "mobile.task.view",
+	"POST *": "mobile.task.work",
+})
+
 func SetupMobileInboundRoutes(app *fiber.App) {
-	mobileInboundController := &mobiles.MobileInboundController{}
-	api := app.Group(config.MAIN_ROUTES+"/mobile", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(mobileInboundController))
-
-	api.Get("/inbound/list/open", mobileInboundController.GetListInbound)
-	api.Post("/inbound/check", mobileInboundController.CheckItem)
-	api.Post("/inbound/scan", mobileInboundController.ScanInbound)
-	api.Get("/inbound/scan/:id", mobileInboundController.GetScanInbound)
-	api.Delete("/inbound/scan/:id", mobileInboundController.DeleteScannedInbound)
-	// api.Put("/inbound/scan/putaway/:inbound_no", mobileInboundController.ConfirmPutaway)
-	api.Get("/inbound/detail/:inbound_no", mobileInboundController.GetInboundDetail)
-	api.Post("/inbound/search/location", mobileInboundController.GetInboundBarcodeByLocation)
-	// api.Post("/inbound/putaway/location/:inbound_no", mobileInboundController.ConfirmPutawayByLocation)
-	api.Put("/inbound/barcode/:id", mobileInboundController.EditInboundBarcode)
-	api.Get("/inbound/barcode/getlocation/:inbound_no", mobileInboundController.GetSequenceLocation)
+	api := app.Group(
+		config.MAIN_ROUTES+"/mobile",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/mobile/inbound", mobileInboundPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/inbound/list/open", database.Handle((*mobiles.MobileInboundController).GetListInbound))
+	api.Post("/inbound/check", database.Handle((*mobiles.MobileInboundController).CheckItem))
+	api.Post("/inbound/scan", database.Handle((*mobiles.MobileInboundController).ScanInbound))
+	api.Get("/inbound/scan/:id", database.Handle((*mobiles.MobileInboundController).GetScanInbound))
+	api.Delete("/inbound/scan/:id", database.Handle((*mobiles.MobileInboundController).DeleteScannedInbound))
+	// api.Put("/inbound/scan/putaway/:inbound_no", database.Handle((*mobiles.MobileInboundController).ConfirmPutaway))
+	api.Get("/inbound/detail/:inbound_no", database.Handle((*mobiles.MobileInboundController).GetInboundDetail))
+	api.Post("/inbound/search/location", database.Handle((*mobiles.MobileInboundController).GetInboundBarcodeByLocation))
+	// api.Post("/inbound/putaway/location/:inbound_no", database.Handle((*mobiles.MobileInboundController).ConfirmPutawayByLocation))
+	api.Put("/inbound/barcode/:id", database.Handle((*mobiles.MobileInboundController).EditInboundBarcode))
+	api.Get("/inbound/barcode/getlocation/:inbound_no", database.Handle((*mobiles.MobileInboundController).GetSequenceLocation))
+	api.Get("/inbound/putaway/suggest/:inbound_no", database.Handle((*mobiles.MobileInboundController).SuggestPutaway))
 }
 
 func SetupMobileInventoryRoutes(app *fiber.App) {
-	mobileInventoryController := &mobiles.MobileInventoryController{}
-	api := app.Group(config.MAIN_ROUTES+"/mobile", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(mobileInventoryController))
-
-	api.Get("/inventory/by-item/:barcode", mobileInventoryController.GetItemsByBarcode)
-	api.Get("/inventory/location/:location", mobileInventoryController.GetItemsByLocation)
-	api.Post("/inventory/dummy", mobileInventoryController.CreateDummyInventory)
-	api.Post("/inventory/location/barcode", mobileInventoryController.GetItemsByLocationAndBarcode)
-	api.Post("/inventory/transfer/location/barcode", mobileInventoryController.ConfirmTransferByLocationAndBarcode)
-	api.Post("/inventory/transfer-by-inventory-id", mobileInventoryController.ConfirmTransferByInventoryID)
-	api.Post("/inventory/add-location", mobileInventoryController.CreateLocation)
+	api := app.Group(
+		config.MAIN_ROUTES+"/mobile",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/mobile/inventory", mobileInventoryPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/inventory/by-item/:barcode", database.Handle((*mobiles.MobileInventoryController).GetItemsByBarcode))
+	api.Get("/inventory/location/:location", database.Handle((*mobiles.MobileInventoryController).GetItemsByLocation))
+	api.Post("/inventory/dummy", database.Handle((*mobiles.MobileInventoryController).CreateDummyInventory))
+	api.Post("/inventory/location/barcode", database.Handle((*mobiles.MobileInventoryController).GetItemsByLocationAndBarcode))
+	api.Post("/inventory/transfer/location/barcode", database.Handle((*mobiles.MobileInventoryController).ConfirmTransferByLocationAndBarcode))
+	api.Post("/inventory/transfer-by-inventory-id", database.Handle((*mobiles.MobileInventoryController).ConfirmTransferByInventoryID))
+	api.Post("/inventory/add-location", database.Handle((*mobiles.MobileInventoryController).CreateLocation))
+	api.Get("/inventory/replenishment", database.Handle((*mobiles.MobileInventoryController).GetReplenishmentTasks))
+	api.Post("/inventory/replenishment/:task_no", database.Handle((*mobiles.MobileInventoryController).ConfirmReplenishment))
 }
 
 func SetupMobileOutboundRoutes(app *fiber.App) {
-	mobileOutboundController := &mobiles.MobileOutboundController{}
-	api := app.Group(config.MAIN_ROUTES+"/mobile", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(mobileOutboundController))
-
-	api.Get("/outbound/list/open", mobileOutboundController.GetListOutbound)
-	api.Get("/outbound/detail/:outbound_no", mobileOutboundController.GetListOutboundDetail)
-	api.Post("/outbound/item-check/:outbound_no", mobileOutboundController.CheckItem)
-	api.Post("/outbound/picking/scan/:outbound_no", mobileOutboundController.ScanPicking)
-	api.Get("/outbound/picking/scan/:id", mobileOutboundController.GetListOutboundBarcode)
-	api.Delete("/outbound/picking/scan/:id", mobileOutboundController.DeleteOutboundBarcode)
-	api.Get("/outbound/picking/list/:outbound_no", mobileOutboundController.GetPickingList)
-	api.Post("/outbound/picking/override/:id", mobileOutboundController.OverridePicking)
+	api := app.Group(
+		config.MAIN_ROUTES+"/mobile",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/mobile/outbound", mobileOutboundPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/outbound/list/open", database.Handle((*mobiles.MobileOutboundController).GetListOutbound))
+	api.Get("/outbound/detail/:outbound_no", database.Handle((*mobiles.MobileOutboundController).GetListOutboundDetail))
+	api.Post("/outbound/item-check/:outbound_no", database.Handle((*mobiles.MobileOutboundController).CheckItem))
+	api.Post("/outbound/picking/scan/:outbound_no", database.Handle((*mobiles.MobileOutboundController).ScanPicking))
+	api.Get("/outbound/picking/scan/:id", database.Handle((*mobiles.MobileOutboundController).GetListOutboundBarcode))
+	api.Delete("/outbound/picking/scan/:id", database.Handle((*mobiles.MobileOutboundController).DeleteOutboundBarcode))
+	api.Get("/outbound/picking/list/:outbound_no", database.Handle((*mobiles.MobileOutboundController).GetPickingList))
+	api.Post("/outbound/picking/override/:id", database.Handle((*mobiles.MobileOutboundController).OverridePicking))
+}
+
+func SetupMobileTaskRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/mobile",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/mobile/tasks", mobileTaskPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/tasks/next", database.Handle((*mobiles.MobileTaskController).GetNextTask))
+	api.Get("/tasks/mine", database.Handle((*mobiles.MobileTaskController).GetMyTasks))
+	api.Post("/tasks/claim/:id", database.Handle((*mobiles.MobileTaskController).ClaimTask))
+	api.Post("/tasks/release/:id", database.Handle((*mobiles.MobileTaskController).ReleaseTask))
+	api.Post("/tasks/complete/:id", database.Handle((*mobiles.MobileTaskController).CompleteTask))
 }
 
 func SetupMobileShippingGuestRoutes(app *fiber.App, shippingGuestController *mobiles.ShippingGuestController) {
@@ -64,13 +131,16 @@ func SetupMobileShippingGuestRoutes(app *fiber.App, shippingGuestController *mob
 }
 
 func SetupMobilePackingRoutes(app *fiber.App) {
-	packingController := &mobiles.MobilePackingController{}
-	api := app.Group(config.MAIN_ROUTES+"/mobile", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(packingController))
-
-	api.Post("/packing/generate", packingController.GenerateKoli)
-	api.Get("/packing/koli/:outbound_no", packingController.GetKoliByOutbound)
-	api.Post("/packing/add", packingController.AddToKoli)
-	api.Delete("/packing/koli/detail/:id", packingController.RemoveItemFromKoli)
-	api.Delete("/packing/koli/:id", packingController.RemoveKoliByID)
+	api := app.Group(
+		config.MAIN_ROUTES+"/mobile",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/mobile/packing", mobilePackingPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Post("/packing/generate", database.Handle((*mobiles.MobilePackingController).GenerateKoli))
+	api.Get("/packing/koli/:outbound_no", database.Handle((*mobiles.MobilePackingController).GetKoliByOutbound))
+	api.Post("/packing/add", database.Handle((*mobiles.MobilePackingController).AddToKoli))
+	api.Delete("/packing/koli/detail/:id", database.Handle((*mobiles.MobilePackingController).RemoveItemFromKoli))
+	api.Delete("/packing/koli/:id", database.Handle((*mobiles.MobilePackingController).RemoveKoliByID))
 }
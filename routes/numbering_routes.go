@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var numberingPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":               "numbering.view",
+	"POST /formats":       "numbering.manage",
+	"DELETE /formats/:id": "numbering.manage",
+})
+
+func SetupNumberingRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/numbering",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/numbering", numberingPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/formats", database.Handle((*controllers.NumberingController).GetFormats))
+	api.Post("/formats", database.Handle((*controllers.NumberingController).SaveFormat))
+	api.Delete("/formats/:id", database.Handle((*controllers.NumberingController).DeleteFormat))
+	api.Get("/preview", database.Handle((*controllers.NumberingController).Preview))
+	api.Get("/sequences", database.Handle((*controllers.NumberingController).GetSequences))
+}
@@ -3,20 +3,29 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupOriginRoutes(app *fiber.App) {
+var originPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":    "origin.view",
+	"POST /":   "origin.create",
+	"PUT /:id": "origin.update",
+})
 
-	originController := &controllers.OriginController{}
-	api := app.Group(config.MAIN_ROUTES+"/origins", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(originController))
-	api.Post("/", originController.Create)
-	api.Get("/", originController.GetAll)
+func SetupOriginRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/origins",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/origins", originPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+	api.Post("/", database.Handle((*controllers.OriginController).Create))
+	api.Get("/", database.Handle((*controllers.OriginController).GetAll))
 	// api.Get("/:id", supplierController.GetSupplierByID)
-	api.Put("/:id", originController.Update)
+	api.Put("/:id", database.Handle((*controllers.OriginController).Update))
 	// api.Delete("/:id", supplierController.DeleteSupplier)
 }
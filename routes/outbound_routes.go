@@ -3,57 +3,71 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var outboundPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                      "outbound.view",
+	"POST /":                     "outbound.create",
+	"PUT /:outbound_no":          "outbound.update",
+	"PUT /handling/:outbound_no": "outbound.update",
+	"DELETE /item/:id":           "outbound.update",
+	"POST /open":                 "outbound.open",
+	"POST /open/process":         "outbound.open",
+	"POST /picking/:id":          "outbound.pick",
+	"POST /picking/complete/:id": "outbound.complete",
+	"POST /packing/generate":     "outbound.pack",
+})
+
 func SetupOutboundRoutes(app *fiber.App) {
-	outboundController := &controllers.OutboundController{}
 	// inboundMidleware := middleware.NewAuthMiddleware(db)
 	api := app.Group(
 		config.MAIN_ROUTES+"/outbound",
 		middleware.AuthMiddleware,
 		// inboundMidleware.CheckPermission("create_inbound"),
+		middleware.RequirePermission(config.MAIN_ROUTES+"/outbound", outboundPermissions),
 	)
 
-	api.Use(database.InjectDBMiddleware(outboundController))
-
-	api.Post("/", outboundController.CreateOutbound)
-	api.Get("/", outboundController.GetOutboundList)
-	api.Get("/vas", outboundController.GetOutboundVasSummary)
-	api.Get("/:outbound_no/vas-items", outboundController.GetOutboundVasByID)
-	api.Get("/serial/:outbound_no", outboundController.GetSerialNumberList)
-	api.Post("/open", outboundController.HandleOpen)
-	api.Post("/open/process", outboundController.ProccesHandleOpen)
-	// api.Post("/open/temp", outboundController.HandleOpenBackToOriginLocation)
-	api.Get("/handling", outboundController.GetOutboundListOutboundHandling)
-	api.Get("/handling/bill/:outbound_no", outboundController.ViewBillHandlingByOutbound)
-	api.Get("/handling/:outbound_no", outboundController.GetOutboundHandlingByID)
-	api.Put("/handling/:outbound_no", outboundController.UpdateOutboundDetailHandling)
-	api.Get("/:outbound_no", outboundController.GetOutboundByID)
-	api.Put("/:outbound_no", outboundController.UpdateOutboundByID)
-	// api.Post("/item/:id", outboundController.SaveItem)
-	api.Get("/item/:id", outboundController.GetItem)
-	api.Delete("/item/:id", outboundController.DeleteItem)
-	api.Post("/picking/:id", outboundController.PickingOutbound)
-	api.Get("/picking/sheet/:id", outboundController.GetPickingSheet)
-	api.Post("/picking/complete/:id", outboundController.PickingComplete)
-	api.Get("/koli-details/:outbound_no", outboundController.GetKoliDetails)
-
-	api.Post("/packing/generate/", outboundController.CreatePacking)
-	api.Get("/packing/all/", outboundController.GetAllPacking)
-	api.Get("/:id/packing/:packing_no", outboundController.GetPackingItems)
-
-	// api.Post("/order", outboundController.CreateOrder)
-
-	// api.Put("/:id", outboundController.SaveOutbound)
-	// api.Get("/draft", outboundController.GetOutboundDraft)
-	// api.Get("/create", outboundController.CreateOutbound)
-	// api.Post("/item", outboundController.CreateItemOutbound)
-	// api.Get("/:id", outboundController.GetOutboundByID)
-	// api.Delete("/item/:id", outboundController.DeleteItemOutbound)
-
-	// api.Post("/picking/complete/:id", outboundController.PickingComplete)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Post("/", database.Handle((*controllers.OutboundController).CreateOutbound))
+	api.Get("/", database.Handle((*controllers.OutboundController).GetOutboundList))
+	api.Get("/vas", database.Handle((*controllers.OutboundController).GetOutboundVasSummary))
+	api.Get("/:outbound_no/vas-items", database.Handle((*controllers.OutboundController).GetOutboundVasByID))
+	api.Get("/serial/:outbound_no", database.Handle((*controllers.OutboundController).GetSerialNumberList))
+	api.Post("/open", database.Handle((*controllers.OutboundController).HandleOpen))
+	api.Post("/open/process", database.Handle((*controllers.OutboundController).ProccesHandleOpen))
+	// api.Post("/open/temp", database.Handle((*controllers.OutboundController).HandleOpenBackToOriginLocation))
+	api.Get("/handling", database.Handle((*controllers.OutboundController).GetOutboundListOutboundHandling))
+	api.Get("/handling/bill/:outbound_no", database.Handle((*controllers.OutboundController).ViewBillHandlingByOutbound))
+	api.Get("/handling/:outbound_no", database.Handle((*controllers.OutboundController).GetOutboundHandlingByID))
+	api.Put("/handling/:outbound_no", database.Handle((*controllers.OutboundController).UpdateOutboundDetailHandling))
+	api.Get("/:outbound_no", database.Handle((*controllers.OutboundController).GetOutboundByID))
+	api.Put("/:outbound_no", database.Handle((*controllers.OutboundController).UpdateOutboundByID))
+	// api.Post("/item/:id", database.Handle((*controllers.OutboundController).SaveItem))
+	api.Get("/item/:id", database.Handle((*controllers.OutboundController).GetItem))
+	api.Delete("/item/:id", database.Handle((*controllers.OutboundController).DeleteItem))
+	api.Post("/picking/:id", database.Handle((*controllers.OutboundController).PickingOutbound))
+	api.Get("/picking/sheet/:id", database.Handle((*controllers.OutboundController).GetPickingSheet))
+	api.Post("/picking/complete/:id", database.Handle((*controllers.OutboundController).PickingComplete))
+	api.Get("/koli-details/:outbound_no", database.Handle((*controllers.OutboundController).GetKoliDetails))
+
+	api.Post("/packing/generate/", database.Handle((*controllers.OutboundController).CreatePacking))
+	api.Get("/packing/all/", database.Handle((*controllers.OutboundController).GetAllPacking))
+	api.Get("/:id/packing/:packing_no", database.Handle((*controllers.OutboundController).GetPackingItems))
+
+	// api.Post("/order", database.Handle((*controllers.OutboundController).CreateOrder))
+
+	// api.Put("/:id", database.Handle((*controllers.OutboundController).SaveOutbound))
+	// api.Get("/draft", database.Handle((*controllers.OutboundController).GetOutboundDraft))
+	// api.Get("/create", database.Handle((*controllers.OutboundController).CreateOutbound))
+	// api.Post("/item", database.Handle((*controllers.OutboundController).CreateItemOutbound))
+	// api.Get("/:id", database.Handle((*controllers.OutboundController).GetOutboundByID))
+	// api.Delete("/item/:id", database.Handle((*controllers.OutboundController).DeleteItemOutbound))
+
+	// api.Post("/picking/complete/:id", database.Handle((*controllers.OutboundController).PickingComplete))
 }
@@ -2,32 +2,53 @@ package routes
 
 import (
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var productPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":       "product.view",
+	"POST /":      "product.create",
+	"PUT /:id":    "product.update",
+	"DELETE /:id": "product.delete",
+})
+
+var uomPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":               "uom.view",
+	"POST /item":          "uom.view",
+	"POST /conversion":    "uom.create",
+	"PUT /conversion/:id": "uom.update",
+})
+
 func SetupProductRoutes(app *fiber.App) {
 
-	api := app.Group("/api/v1/products", middleware.AuthMiddleware)
-	productController := &controllers.ProductController{}
-	api.Use(database.InjectDBMiddleware(productController))
+	api := app.Group(
+		"/api/v1/products",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission("/api/v1/products", productPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Post("/", productController.CreateProduct)
-	api.Get("/:id", productController.GetProductByID)
-	api.Put("/:id", productController.UpdateProduct)
-	api.Get("/", productController.GetAllProducts)
-	api.Delete("/:id", productController.DeleteProduct)
+	api.Post("/", database.Handle((*controllers.ProductController).CreateProduct))
+	api.Get("/:id", database.Handle((*controllers.ProductController).GetProductByID))
+	api.Put("/:id", database.Handle((*controllers.ProductController).UpdateProduct))
+	api.Get("/", database.Handle((*controllers.ProductController).GetAllProducts))
+	api.Delete("/:id", database.Handle((*controllers.ProductController).DeleteProduct))
 
 	// UOM Routes
-	uom := app.Group("/api/v1/uoms", middleware.AuthMiddleware)
-	uomController := &controllers.UomController{}
-	uom.Use(database.InjectDBMiddleware(uomController))
-
-	uom.Get("/", uomController.GetAllUOM)
-	uom.Post("/item/", uomController.GetUomByItemCode)
-	uom.Post("/conversion", uomController.CreateUom)
-	uom.Get("/conversion", uomController.GetAllUOMConversion)
-	uom.Put("/conversion/:id", uomController.UpdateUOMConversion)
+	uom := app.Group(
+		"/api/v1/uoms",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission("/api/v1/uoms", uomPermissions),
+	)
+	uom.Use(database.InjectDBMiddleware)
+
+	uom.Get("/", database.Handle((*controllers.UomController).GetAllUOM))
+	uom.Post("/item/", database.Handle((*controllers.UomController).GetUomByItemCode))
+	uom.Post("/conversion", database.Handle((*controllers.UomController).CreateUom))
+	uom.Get("/conversion", database.Handle((*controllers.UomController).GetAllUOMConversion))
+	uom.Put("/conversion/:id", database.Handle((*controllers.UomController).UpdateUOMConversion))
 }
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var productivityPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":              "productivity.view",
+	"GET /export":        "productivity.export",
+	"GET /leaderboard":   "productivity.leaderboard",
+	"POST /shifts":       "productivity.shift",
+	"DELETE /shifts/:id": "productivity.shift",
+})
+
+func SetupProductivityRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/productivity",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/productivity", productivityPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/", database.Handle((*controllers.ProductivityController).GetProductivity))
+	api.Get("/export", database.Handle((*controllers.ProductivityController).ExportProductivity))
+	api.Get("/leaderboard", database.Handle((*controllers.ProductivityController).GetLeaderboard))
+	api.Get("/shifts", database.Handle((*controllers.ProductivityController).GetShifts))
+	api.Post("/shifts", database.Handle((*controllers.ProductivityController).SaveShift))
+	api.Delete("/shifts/:id", database.Handle((*controllers.ProductivityController).DeleteShift))
+}
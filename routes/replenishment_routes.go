@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var replenishmentPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                       "replenishment.view",
+	"POST /settings":              "replenishment.setting",
+	"DELETE /settings/:id":        "replenishment.setting",
+	"POST /generate":              "replenishment.generate",
+	"POST /tasks/cancel/:task_no": "replenishment.cancel",
+})
+
+func SetupReplenishmentRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/replenishment",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/replenishment", replenishmentPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/settings", database.Handle((*controllers.ReplenishmentController).GetSettings))
+	api.Post("/settings", database.Handle((*controllers.ReplenishmentController).SaveSetting))
+	api.Delete("/settings/:id", database.Handle((*controllers.ReplenishmentController).DeleteSetting))
+	api.Post("/generate", database.Handle((*controllers.ReplenishmentController).GenerateTasks))
+	api.Get("/tasks", database.Handle((*controllers.ReplenishmentController).GetTasks))
+	api.Post("/tasks/cancel/:task_no", database.Handle((*controllers.ReplenishmentController).CancelTask))
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var returnPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                     "return.view",
+	"POST /":                    "return.create",
+	"POST /complete/:return_no": "return.complete",
+	"POST /cancel/:return_no":   "return.cancel",
+})
+
+func SetupReturnRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/returns",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/returns", returnPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/outbound/:outbound_no", database.Handle((*controllers.ReturnController).GetShippedItems))
+	api.Post("/", database.Handle((*controllers.ReturnController).CreateReturn))
+	api.Get("/", database.Handle((*controllers.ReturnController).GetAllReturns))
+	api.Get("/:return_no", database.Handle((*controllers.ReturnController).GetReturnByNo))
+	api.Post("/complete/:return_no", database.Handle((*controllers.ReturnController).CompleteReturn))
+	api.Post("/cancel/:return_no", database.Handle((*controllers.ReturnController).CancelReturn))
+}
@@ -3,15 +3,23 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var warehousePermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET /": "warehouse.view",
+})
+
 func SetupWarehouseRoutes(app *fiber.App) {
-	controller := &controllers.WarehouseController{}
-	api := app.Group(config.MAIN_ROUTES+"/warehouses", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(controller))
-	api.Get("/", controller.GetAllWarehouses)
+	api := app.Group(
+		config.MAIN_ROUTES+"/warehouses",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/warehouses", warehousePermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+	api.Get("/", database.Handle((*controllers.WarehouseController).GetAllWarehouses))
 }
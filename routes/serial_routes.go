@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var serialPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *": "serial.view",
+})
+
+func SetupSerialRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/serials",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/serials", serialPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/:serial_no", database.Handle((*controllers.SerialController).SearchSerial))
+}
@@ -3,33 +3,41 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var orderPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":            "order.view",
+	"POST /":           "order.create",
+	"PUT /:order_no":   "order.update",
+	"DELETE /item/:id": "order.update",
+})
+
 func SetupShippingRoutes(app *fiber.App) {
-	shippingController := &controllers.ShippingController{}
 	// inboundMidleware := middleware.NewAuthMiddleware(db)
 	api := app.Group(
 		config.MAIN_ROUTES+"/order",
 		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/order", orderPermissions),
 	)
 
-	api.Use(database.InjectDBMiddleware(shippingController))
+	api.Use(database.InjectDBMiddleware)
 
-	api.Post("/", shippingController.CreateOrder)
-	api.Get("/", shippingController.GetListOrder)
-	api.Get("/list", shippingController.GetOutboundList)
-	api.Get("/:order_no", shippingController.GetOrderByNo)
-	api.Get("/detail/:order_no", shippingController.GetOrderAndDetailByNo)
-	api.Put("/:order_no", shippingController.UpdateOrderByID)
-	api.Delete("/item/:id", shippingController.DeleteItemOrderByID)
+	api.Post("/", database.Handle((*controllers.ShippingController).CreateOrder))
+	api.Get("/", database.Handle((*controllers.ShippingController).GetListOrder))
+	api.Get("/list", database.Handle((*controllers.ShippingController).GetOutboundList))
+	api.Get("/:order_no", database.Handle((*controllers.ShippingController).GetOrderByNo))
+	api.Get("/detail/:order_no", database.Handle((*controllers.ShippingController).GetOrderAndDetailByNo))
+	api.Put("/:order_no", database.Handle((*controllers.ShippingController).UpdateOrderByID))
+	api.Delete("/item/:id", database.Handle((*controllers.ShippingController).DeleteItemOrderByID))
 
-	// api.Get("/list-order-part", shippingController.GetListDNOpen)
-	// api.Get("/order/:order_no", shippingController.GetOrderByID)
-	// api.Post("/order/ungroup", shippingController.UnGroupOrder)
-	// api.Put("/order/detail/:id", shippingController.UpdateOrderDetailByID)
-	// api.Get("/order/detail/:order_no", shippingController.GetOrderDetailItemsByOrderNo)
+	// api.Get("/list-order-part", database.Handle((*controllers.ShippingController).GetListDNOpen))
+	// api.Get("/order/:order_no", database.Handle((*controllers.ShippingController).GetOrderByID))
+	// api.Post("/order/ungroup", database.Handle((*controllers.ShippingController).UnGroupOrder))
+	// api.Put("/order/detail/:id", database.Handle((*controllers.ShippingController).UpdateOrderDetailByID))
+	// api.Get("/order/detail/:order_no", database.Handle((*controllers.ShippingController).GetOrderDetailItemsByOrderNo))
 }
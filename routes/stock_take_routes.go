@@ -3,27 +3,37 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var stockTakePermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":            "stock_take.view",
+	"POST /stock-card": "stock_take.view",
+	"POST /scan":       "stock_take.scan",
+	"POST /generate":   "stock_take.generate",
+	"POST /post/:code": "stock_take.post",
+})
+
 func SetupStockTakeRoutes(app *fiber.App) {
-	stockTakeController := &controllers.StockTakeController{}
 	api := app.Group(
 		config.MAIN_ROUTES+"/stock-take",
 		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/stock-take", stockTakePermissions),
 	)
 
-	api.Use(database.InjectDBMiddleware(stockTakeController))
+	api.Use(database.InjectDBMiddleware)
 
-	api.Get("/locations", stockTakeController.LoadLocations)
-	api.Post("/stock-card", stockTakeController.GetCardStockTake)
-	api.Get("/progress/:code", stockTakeController.GetProgressStockTakeByCode)
-	api.Post("/scan", stockTakeController.ScanStockTake)
-	api.Get("/barcode/:code", stockTakeController.GetStockTakeBarcodeByCode)
-	api.Get("/", stockTakeController.GetAllStockTake)
-	api.Get("/:code", stockTakeController.GetStockTakeDetail)
-	api.Post("/generate", stockTakeController.GenerateDataStockTake)
+	api.Get("/locations", database.Handle((*controllers.StockTakeController).LoadLocations))
+	api.Post("/stock-card", database.Handle((*controllers.StockTakeController).GetCardStockTake))
+	api.Get("/progress/:code", database.Handle((*controllers.StockTakeController).GetProgressStockTakeByCode))
+	api.Post("/scan", database.Handle((*controllers.StockTakeController).ScanStockTake))
+	api.Get("/barcode/:code", database.Handle((*controllers.StockTakeController).GetStockTakeBarcodeByCode))
+	api.Get("/", database.Handle((*controllers.StockTakeController).GetAllStockTake))
+	api.Get("/:code", database.Handle((*controllers.StockTakeController).GetStockTakeDetail))
+	api.Post("/generate", database.Handle((*controllers.StockTakeController).GenerateDataStockTake))
+	api.Post("/post/:code", database.Handle((*controllers.StockTakeController).PostStockTake))
 }
@@ -3,21 +3,32 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var supplierPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":       "supplier.view",
+	"POST /":      "supplier.create",
+	"PUT /:id":    "supplier.update",
+	"DELETE /:id": "supplier.delete",
+})
+
 func SetupSupplierRoutes(app *fiber.App) {
 
-	api := app.Group(config.MAIN_ROUTES+"/suppliers", middleware.AuthMiddleware)
-	supplierController := &controllers.SupplierController{}
-	api.Use(database.InjectDBMiddleware(supplierController))
+	api := app.Group(
+		config.MAIN_ROUTES+"/suppliers",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/suppliers", supplierPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Post("/", supplierController.CreateSupplier)
-	api.Get("/", supplierController.GetAllSuppliers)
-	api.Get("/:id", supplierController.GetSupplierByID)
-	api.Put("/:id", supplierController.UpdateSupplier)
-	api.Delete("/:id", supplierController.DeleteSupplier)
+	api.Post("/", database.Handle((*controllers.SupplierController).CreateSupplier))
+	api.Get("/", database.Handle((*controllers.SupplierController).GetAllSuppliers))
+	api.Get("/:id", database.Handle((*controllers.SupplierController).GetSupplierByID))
+	api.Put("/:id", database.Handle((*controllers.SupplierController).UpdateSupplier))
+	api.Delete("/:id", database.Handle((*controllers.SupplierController).DeleteSupplier))
 }
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var taskPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":            "task.view",
+	"POST /":           "task.create",
+	"PUT /:id":         "task.assign",
+	"POST /cancel/:id": "task.cancel",
+})
+
+func SetupTaskRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/tasks",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/tasks", taskPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/", database.Handle((*controllers.TaskController).GetTasks))
+	api.Post("/", database.Handle((*controllers.TaskController).CreateTask))
+	api.Put("/:id", database.Handle((*controllers.TaskController).UpdateTask))
+	api.Post("/cancel/:id", database.Handle((*controllers.TaskController).CancelTask))
+}
@@ -2,21 +2,30 @@ package routes
 
 import (
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupTransporterRoutes(app *fiber.App) {
+var transporterPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":    "transporter.view",
+	"POST /":   "transporter.create",
+	"PUT /:id": "transporter.update",
+})
 
-	transporterController := &controllers.TransporterController{}
-	api := app.Group("/api/v1/transporters", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(transporterController))
+func SetupTransporterRoutes(app *fiber.App) {
+	api := app.Group(
+		"/api/v1/transporters",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission("/api/v1/transporters", transporterPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Post("/", transporterController.CreateTransporter)
-	api.Get("/", transporterController.GetAllTransporter)
+	api.Post("/", database.Handle((*controllers.TransporterController).CreateTransporter))
+	api.Get("/", database.Handle((*controllers.TransporterController).GetAllTransporter))
 	// api.Get("/:id", supplierController.GetSupplierByID)
-	api.Put("/:id", transporterController.UpdateTransporter)
+	api.Put("/:id", database.Handle((*controllers.TransporterController).UpdateTransporter))
 	// api.Delete("/:id", supplierController.DeleteSupplier)
 }
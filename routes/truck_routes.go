@@ -3,21 +3,29 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupTruckRoutes(app *fiber.App) {
-
-	truckController := &controllers.TruckController{}
+var truckPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":    "truck.view",
+	"POST /":   "truck.create",
+	"PUT /:id": "truck.update",
+})
 
-	api := app.Group(config.MAIN_ROUTES+"/trucks", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(truckController))
+func SetupTruckRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/trucks",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/trucks", truckPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Post("/", truckController.Create)
-	api.Put("/:id", truckController.Update)
-	api.Get("/", truckController.GetAll)
+	api.Post("/", database.Handle((*controllers.TruckController).Create))
+	api.Put("/:id", database.Handle((*controllers.TruckController).Update))
+	api.Get("/", database.Handle((*controllers.TruckController).GetAll))
 
 }
@@ -3,40 +3,80 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupUserRoutes(app *fiber.App) {
-	userController := &controllers.UserController{}
+var userPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"PUT /me/password":     "",
+	"GET *":                "user.view",
+	"POST /":               "user.create",
+	"PUT /:id":             "user.update",
+	"PUT /:id/owners":      "user.update",
+	"DELETE /:id":          "user.delete",
+	"GET /password-policy": "setting.password",
+	"PUT /password-policy": "setting.password",
+})
+
+var rolePermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                "role.view",
+	"POST /":               "role.create",
+	"PUT /permissions/:id": "role.update",
+})
 
-	api := app.Group(config.MAIN_ROUTES+"/users", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(userController))
+var permissionPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":    "permission.view",
+	"POST /":   "permission.create",
+	"PUT /:id": "permission.update",
+})
+
+func SetupUserRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/users",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/users", userPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Post("/", userController.CreateUser)
-	api.Put("/:id", userController.UpdateUser)
-	api.Get("/:id", userController.GetUserByID)
-	api.Get("/", userController.GetAllUsers)
-	api.Delete("/:id", userController.DeleteUser)
+	api.Put("/me/password", database.Handle((*controllers.UserController).ChangePassword))
+	api.Get("/password-policy", database.Handle((*controllers.UserController).GetPasswordPolicy))
+	api.Put("/password-policy", database.Handle((*controllers.UserController).UpdatePasswordPolicy))
+	api.Post("/", database.Handle((*controllers.UserController).CreateUser))
+	api.Get("/:id/owners", database.Handle((*controllers.UserController).GetUserOwners))
+	api.Put("/:id/owners", database.Handle((*controllers.UserController).UpdateUserOwners))
+	api.Put("/:id", database.Handle((*controllers.UserController).UpdateUser))
+	api.Get("/:id", database.Handle((*controllers.UserController).GetUserByID))
+	api.Get("/", database.Handle((*controllers.UserController).GetAllUsers))
+	api.Delete("/:id", database.Handle((*controllers.UserController).DeleteUser))
 
 	// profile := app.Group("/api/v1/user", middleware.AuthMiddleware)
-	// profile.Get("/profile", userController.GetProfile)
+	// profile.Get("/profile", database.Handle((*controllers.UserController).GetProfile))
 
-	role := app.Group(config.MAIN_ROUTES+"/roles", middleware.AuthMiddleware)
-	role.Use(database.InjectDBMiddleware(userController))
+	role := app.Group(
+		config.MAIN_ROUTES+"/roles",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/roles", rolePermissions),
+	)
+	role.Use(database.InjectDBMiddleware)
 
-	role.Get("/", userController.GetRoles)
-	role.Post("/", userController.CreateRole)
-	role.Put("/permissions/:id", userController.UpdatePermissionsForRole)
+	role.Get("/", database.Handle((*controllers.UserController).GetRoles))
+	role.Post("/", database.Handle((*controllers.UserController).CreateRole))
+	role.Put("/permissions/:id", database.Handle((*controllers.UserController).UpdatePermissionsForRole))
 
-	permission := app.Group(config.MAIN_ROUTES+"/permissions", middleware.AuthMiddleware)
-	permission.Use(database.InjectDBMiddleware(userController))
+	permission := app.Group(
+		config.MAIN_ROUTES+"/permissions",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/permissions", permissionPermissions),
+	)
+	permission.Use(database.InjectDBMiddleware)
 
-	permission.Get("/", userController.GetPermissions)
-	permission.Get("/:id", userController.GetPermissionByID)
-	permission.Post("/", userController.CreatePermission)
-	permission.Put("/:id", userController.UpdatePermission)
+	permission.Get("/", database.Handle((*controllers.UserController).GetPermissions))
+	permission.Get("/routes", database.Handle((*controllers.UserController).GetRoutePermissions))
+	permission.Get("/:id", database.Handle((*controllers.UserController).GetPermissionByID))
+	permission.Post("/", database.Handle((*controllers.UserController).CreatePermission))
+	permission.Put("/:id", database.Handle((*controllers.UserController).UpdatePermission))
 
 }
@@ -3,23 +3,34 @@ package routes
 import (
 	"fiber-app/config"
 	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var vasPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":    "vas.view",
+	"POST *":   "vas.manage",
+	"PUT *":    "vas.manage",
+	"DELETE *": "vas.manage",
+})
+
 func SetupVasRoutes(app *fiber.App) {
-	vasController := &controllers.VasController{}
-	api := app.Group(config.MAIN_ROUTES+"/vas", middleware.AuthMiddleware)
-	api.Use(database.InjectDBMiddleware(vasController))
-	api.Post("/main-vas", vasController.CreateMainVas)
-	api.Get("/main-vas", vasController.GetAllMainVas)
-	api.Put("/main-vas/:id", vasController.UpdateMainVas)
-	api.Delete("/main-vas/:id", vasController.DeleteMainVas)
+	api := app.Group(
+		config.MAIN_ROUTES+"/vas",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/vas", vasPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+	api.Post("/main-vas", database.Handle((*controllers.VasController).CreateMainVas))
+	api.Get("/main-vas", database.Handle((*controllers.VasController).GetAllMainVas))
+	api.Put("/main-vas/:id", database.Handle((*controllers.VasController).UpdateMainVas))
+	api.Delete("/main-vas/:id", database.Handle((*controllers.VasController).DeleteMainVas))
 
-	api.Post("/page", vasController.CreateVas)
-	api.Get("/page", vasController.GetAllVas)
-	api.Put("/page/:id", vasController.UpdateVas)
-	api.Delete("/page/:id", vasController.DeleteVas)
+	api.Post("/page", database.Handle((*controllers.VasController).CreateVas))
+	api.Get("/page", database.Handle((*controllers.VasController).GetAllVas))
+	api.Put("/page/:id", database.Handle((*controllers.VasController).UpdateVas))
+	api.Delete("/page/:id", database.Handle((*controllers.VasController).DeleteVas))
 }
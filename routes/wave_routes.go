@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"fiber-app/config"
+	"fiber-app/controllers"
+	"fiber-app/controllers/helpers"
+	"fiber-app/database"
+	"fiber-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var wavePermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                  "wave.view",
+	"POST /":                 "wave.create",
+	"POST /release/:wave_no": "wave.release",
+	"POST /sort/:wave_no":    "wave.sort",
+	"POST /cancel/:wave_no":  "wave.cancel",
+})
+
+func SetupWaveRoutes(app *fiber.App) {
+	api := app.Group(
+		config.MAIN_ROUTES+"/waves",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/waves", wavePermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
+
+	api.Get("/candidates", database.Handle((*controllers.WaveController).GetCandidates))
+	api.Post("/", database.Handle((*controllers.WaveController).CreateWave))
+	api.Get("/", database.Handle((*controllers.WaveController).GetAllWaves))
+	api.Get("/:wave_no", database.Handle((*controllers.WaveController).GetWaveByNo))
+	api.Get("/:wave_no/pick-list", database.Handle((*controllers.WaveController).GetPickList))
+	api.Get("/:wave_no/sort", database.Handle((*controllers.WaveController).GetSortProgress))
+	api.Post("/release/:wave_no", database.Handle((*controllers.WaveController).ReleaseWave))
+	api.Post("/sort/:wave_no", database.Handle((*controllers.WaveController).SortItem))
+	api.Post("/cancel/:wave_no", database.Handle((*controllers.WaveController).CancelWave))
+}
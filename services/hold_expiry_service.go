@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fiber-app/database"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const holdExpiryInterval = 15 * time.Minute
+
+// StartHoldExpiryJob release otomatis hold yang sudah lewat expires_at di semua business unit aktif
+func StartHoldExpiryJob(mainDB *gorm.DB) {
+	go func() {
+		for {
+			time.Sleep(holdExpiryInterval)
+			ReleaseExpiredHoldsAllUnits(mainDB)
+		}
+	}()
+}
+
+func ReleaseExpiredHoldsAllUnits(mainDB *gorm.DB) {
+	var units []models.BusinessUnit
+	if err := mainDB.Where("is_active = ?", true).Find(&units).Error; err != nil {
+		log.Println("Hold expiry: gagal ambil business unit:", err)
+		return
+	}
+
+	for _, unit := range units {
+		db, err := database.GetDBConnection(unit.DbName)
+		if err != nil {
+			log.Println("Hold expiry: gagal koneksi ke", unit.DbName, ":", err)
+			continue
+		}
+
+		count, err := repositories.NewHoldRepository(db).ReleaseExpired(0)
+		if err != nil {
+			log.Println("Hold expiry: gagal release", unit.DbName, ":", err)
+		}
+		if count > 0 {
+			log.Printf("Hold expiry %s: %d holds released\n", unit.DbName, count)
+		}
+	}
+}
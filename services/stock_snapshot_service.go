@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fiber-app/database"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// jam snapshot harian (waktu server)
+const snapshotHour, snapshotMinute = 23, 55
+
+// StartDailySnapshotJob menjalankan snapshot stock harian untuk semua business unit aktif
+func StartDailySnapshotJob(mainDB *gorm.DB) {
+	go func() {
+		for {
+			next := nextSnapshotTime(time.Now())
+			time.Sleep(time.Until(next))
+			RunSnapshotAllUnits(mainDB, next.Format("2006-01-02"))
+		}
+	}()
+}
+
+func nextSnapshotTime(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), snapshotHour, snapshotMinute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// RunSnapshotAllUnits mengambil snapshot untuk setiap business unit, error di satu unit tidak menghentikan unit lain
+func RunSnapshotAllUnits(mainDB *gorm.DB, snapshotDate string) {
+	var units []models.BusinessUnit
+	if err := mainDB.Where("is_active = ?", true).Find(&units).Error; err != nil {
+		log.Println("Snapshot: gagal ambil business unit:", err)
+		return
+	}
+
+	for _, unit := range units {
+		db, err := database.GetDBConnection(unit.DbName)
+		if err != nil {
+			log.Println("Snapshot: gagal koneksi ke", unit.DbName, ":", err)
+			continue
+		}
+
+		count, err := repositories.NewStockSnapshotRepository(db).TakeSnapshot(snapshotDate, 0)
+		if err != nil {
+			log.Println("Snapshot: gagal snapshot", unit.DbName, ":", err)
+			continue
+		}
+
+		log.Printf("Snapshot %s %s: %d rows\n", unit.DbName, snapshotDate, count)
+	}
+}
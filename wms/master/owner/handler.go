@@ -1,6 +1,14 @@
 package owner
 
 import (
+	"errors"
+	"fiber-app/controllers/helpers"
+	"fiber-app/models"
+	"fiber-app/repositories"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -13,9 +21,128 @@ func NewOwnerHandler(db *gorm.DB) *OwnerHandler {
 	return &OwnerHandler{DB: db}
 }
 
+type ownerSettingInput struct {
+	AllocationRule  string `json:"allocation_rule"`
+	QaStatus        string `json:"qa_status"`
+	DivisionCode    string `json:"division_code"`
+	SerialMandatory bool   `json:"serial_mandatory"`
+	DefaultUom      string `json:"default_uom"`
+	InboundPrefix   string `json:"inbound_prefix" validate:"max=10"`
+	OutboundPrefix  string `json:"outbound_prefix" validate:"max=10"`
+	BillingCurrency string `json:"billing_currency" validate:"omitempty,len=3"`
+
+	AdjustmentApprovalRole  string  `json:"adjustment_approval_role"`
+	AdjustmentApprovalLimit float64 `json:"adjustment_approval_limit" validate:"gte=0"`
+	AdjustmentAnyApprover   bool    `json:"adjustment_any_approver"`
+}
+
+type ownerInput struct {
+	Code        string            `json:"code" validate:"required,max=50"`
+	Name        string            `json:"name" validate:"required"`
+	Description string            `json:"description"`
+	Setting     ownerSettingInput `json:"setting"`
+}
+
+// validateSetting memastikan master yang direferensikan setting memang ada
+func validateSetting(db *gorm.DB, input ownerSettingInput) error {
+	if err := validator.New().Struct(input); err != nil {
+		return err
+	}
+
+	if input.AllocationRule != "" && !repositories.IsValidAllocationStrategy(input.AllocationRule) {
+		return errors.New("Invalid allocation rule " + input.AllocationRule)
+	}
+
+	var count int64
+	if input.QaStatus != "" {
+		if err := db.Model(&models.QaStatus{}).Where("qa_status = ?", input.QaStatus).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("QA status " + input.QaStatus + " not found")
+		}
+	}
+
+	if input.DivisionCode != "" {
+		if err := db.Model(&models.Division{}).Where("code = ?", input.DivisionCode).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("Division " + input.DivisionCode + " not found")
+		}
+	}
+
+	if input.DefaultUom != "" {
+		if err := db.Model(&models.Uom{}).Where("code = ?", input.DefaultUom).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("UOM " + input.DefaultUom + " not found")
+		}
+	}
+
+	if input.AdjustmentApprovalRole != "" {
+		if err := db.Model(&models.Role{}).Where("name = ?", input.AdjustmentApprovalRole).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("Role " + input.AdjustmentApprovalRole + " not found")
+		}
+	}
+
+	return nil
+}
+
+func applySetting(setting *models.OwnerSetting, input ownerSettingInput) {
+	setting.AllocationRule = input.AllocationRule
+	setting.QaStatus = input.QaStatus
+	setting.DivisionCode = input.DivisionCode
+	setting.SerialMandatory = input.SerialMandatory
+	setting.DefaultUom = input.DefaultUom
+	setting.InboundPrefix = strings.ToUpper(strings.TrimSpace(input.InboundPrefix))
+	setting.OutboundPrefix = strings.ToUpper(strings.TrimSpace(input.OutboundPrefix))
+	setting.BillingCurrency = strings.ToUpper(input.BillingCurrency)
+	setting.AdjustmentApprovalRole = input.AdjustmentApprovalRole
+	setting.AdjustmentApprovalLimit = input.AdjustmentApprovalLimit
+	setting.AdjustmentAnyApprover = input.AdjustmentAnyApprover
+}
+
+func (h *OwnerHandler) findOwner(ctx *fiber.Ctx) (Owner, error) {
+	var owner Owner
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return owner, fiber.NewError(fiber.StatusBadRequest, "Invalid ID")
+	}
+
+	if err := h.DB.First(&owner, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return owner, fiber.NewError(fiber.StatusNotFound, "Owner not found")
+		}
+		return owner, err
+	}
+
+	if !helpers.IsOwnerAllowed(h.DB, owner.Code) {
+		return owner, fiber.NewError(fiber.StatusForbidden, helpers.ErrOwnerNotAllowed.Error())
+	}
+
+	return owner, nil
+}
+
+func ownerError(ctx *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return ctx.Status(fiberErr.Code).JSON(fiber.Map{"error": fiberErr.Message})
+	}
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
 func (h *OwnerHandler) GetAllOwners(ctx *fiber.Ctx) error {
 	var owners []Owner
-	if err := h.DB.Find(&owners).Error; err != nil {
+	query := h.DB
+	if codes, ok := helpers.OwnerCodesFromDB(h.DB); ok {
+		query = query.Where("code IN ?", codes)
+	}
+	if err := query.Find(&owners).Error; err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve owners",
 		})
@@ -26,3 +153,312 @@ func (h *OwnerHandler) GetAllOwners(ctx *fiber.Ctx) error {
 		"data":    owners,
 	})
 }
+
+func (h *OwnerHandler) GetOwnerByID(ctx *fiber.Ctx) error {
+	owner, err := h.findOwner(ctx)
+	if err != nil {
+		return ownerError(ctx, err)
+	}
+
+	setting, err := helpers.GetOwnerSetting(h.DB, owner.Code)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Owner retrieved successfully",
+		"data":    fiber.Map{"owner": owner, "setting": setting},
+	})
+}
+
+// CreateOwner adalah onboarding owner baru: owner, setting dan assignment ke user pembuat
+// (kalau user tersebut dibatasi owner) dibuat dalam satu transaksi.
+func (h *OwnerHandler) CreateOwner(ctx *fiber.Ctx) error {
+	var input ownerInput
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	if err := validator.New().Struct(input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateSetting(h.DB, input.Setting); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var count int64
+	if err := h.DB.Unscoped().Model(&Owner{}).Where("code = ? OR name = ?", input.Code, input.Name).Count(&count).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if count > 0 {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Owner code or name already exists"})
+	}
+
+	userID := int(ctx.Locals("userID").(float64))
+
+	tx := h.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	owner := Owner{
+		Code:           input.Code,
+		Name:           input.Name,
+		Description:    input.Description,
+		AllocationRule: input.Setting.AllocationRule,
+		CreatedBy:      userID,
+		UpdatedBy:      userID,
+	}
+	if err := tx.Create(&owner).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	setting := models.OwnerSetting{OwnerCode: owner.Code, CreatedBy: userID, UpdatedBy: userID}
+	applySetting(&setting, input.Setting)
+
+	// owner baru belum ada di scope user, setting dibuat tanpa scope supaya tidak ditolak callback create
+	if err := tx.Set(helpers.OwnerScopeKey, []string(nil)).Create(&setting).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if _, restricted := helpers.OwnerCodesFromDB(h.DB); restricted {
+		assignment := models.UserOwner{
+			UserID:    uint(userID),
+			OwnerCode: owner.Code,
+			CreatedAt: time.Now(),
+			CreatedBy: userID,
+		}
+		if err := tx.Create(&assignment).Error; err != nil {
+			tx.Rollback()
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	helpers.InvalidateOwnerCache()
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Owner created successfully",
+		"data":    fiber.Map{"owner": owner, "setting": setting},
+	})
+}
+
+// UpdateOwner hanya mengubah nama dan deskripsi, code dipakai sebagai referensi di semua transaksi
+func (h *OwnerHandler) UpdateOwner(ctx *fiber.Ctx) error {
+	owner, err := h.findOwner(ctx)
+	if err != nil {
+		return ownerError(ctx, err)
+	}
+
+	var input struct {
+		Name        string `json:"name" validate:"required"`
+		Description string `json:"description"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validator.New().Struct(input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.DB.Model(&Owner{}).Where("id = ?", owner.ID).Updates(map[string]interface{}{
+		"name":        input.Name,
+		"description": input.Description,
+		"updated_by":  int(ctx.Locals("userID").(float64)),
+	}).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	owner.Name = input.Name
+	owner.Description = input.Description
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Owner updated successfully",
+		"data":    owner,
+	})
+}
+
+// DeleteOwner ditolak selama owner masih punya stock
+func (h *OwnerHandler) DeleteOwner(ctx *fiber.Ctx) error {
+	owner, err := h.findOwner(ctx)
+	if err != nil {
+		return ownerError(ctx, err)
+	}
+
+	var stock int64
+	if err := h.DB.Model(&models.Inventory{}).Where("owner_code = ? AND qty_onhand > 0", owner.Code).Count(&stock).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if stock > 0 {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Owner still has stock on hand"})
+	}
+
+	tx := h.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Model(&Owner{}).Where("id = ?", owner.ID).Update("deleted_by", int(ctx.Locals("userID").(float64))).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := tx.Delete(&Owner{}, owner.ID).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := tx.Where("owner_code = ?", owner.Code).Delete(&models.OwnerSetting{}).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := tx.Where("owner_code = ?", owner.Code).Delete(&models.UserOwner{}).Error; err != nil {
+		tx.Rollback()
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	helpers.InvalidateOwnerCache()
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Owner deleted successfully",
+		"data":    owner,
+	})
+}
+
+func (h *OwnerHandler) GetOwnerSetting(ctx *fiber.Ctx) error {
+	code := ctx.Params("code")
+	if !helpers.IsOwnerAllowed(h.DB, code) {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": helpers.ErrOwnerNotAllowed.Error()})
+	}
+
+	var count int64
+	if err := h.DB.Model(&Owner{}).Where("code = ?", code).Count(&count).Error; err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if count == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Owner not found"})
+	}
+
+	setting, err := helpers.GetOwnerSetting(h.DB, code)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(fiber.Map{"success": true, "data": setting})
+}
+
+func (h *OwnerHandler) UpdateOwnerSetting(ctx *fiber.Ctx) error {
+	code := ctx.Params("code")
+	if !helpers.IsOwnerAllowed(h.DB, code) {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": helpers.ErrOwnerNotAllowed.Error()})
+	}
+
+	var input ownerSettingInput
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateSetting(h.DB, input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	setting, err := h.saveSetting(code, int(ctx.Locals("userID").(float64)), func(s *models.OwnerSetting) {
+		applySetting(s, input)
+	})
+	if err != nil {
+		return ownerError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Owner setting updated successfully",
+		"data":    setting,
+	})
+}
+
+// saveSetting upsert setting owner, allocation rule juga disalin ke tabel owners supaya list owner tetap sesuai
+func (h *OwnerHandler) saveSetting(code string, userID int, apply func(*models.OwnerSetting)) (models.OwnerSetting, error) {
+	var owner Owner
+	if err := h.DB.Where("code = ?", code).First(&owner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.OwnerSetting{}, fiber.NewError(fiber.StatusNotFound, "Owner not found")
+		}
+		return models.OwnerSetting{}, err
+	}
+
+	var setting models.OwnerSetting
+	if err := h.DB.Where("owner_code = ?", code).First(&setting).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return setting, err
+	}
+
+	if setting.ID == 0 {
+		setting.OwnerCode = code
+		setting.CreatedBy = userID
+	}
+	apply(&setting)
+	setting.UpdatedBy = userID
+
+	tx := h.DB.Begin()
+	if err := tx.Save(&setting).Error; err != nil {
+		tx.Rollback()
+		return setting, err
+	}
+	if err := tx.Model(&Owner{}).Where("id = ?", owner.ID).Updates(map[string]interface{}{
+		"allocation_rule": setting.AllocationRule,
+		"updated_by":      userID,
+	}).Error; err != nil {
+		tx.Rollback()
+		return setting, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return setting, err
+	}
+
+	return helpers.GetOwnerSetting(h.DB, code)
+}
+
+func (h *OwnerHandler) UpdateAllocationRule(ctx *fiber.Ctx) error {
+	code := ctx.Params("code")
+
+	var input struct {
+		AllocationRule string `json:"allocation_rule"`
+	}
+	if err := ctx.BodyParser(&input); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if !helpers.IsOwnerAllowed(h.DB, code) {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": helpers.ErrOwnerNotAllowed.Error()})
+	}
+
+	if !repositories.IsValidAllocationStrategy(input.AllocationRule) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid allocation rule " + input.AllocationRule})
+	}
+
+	setting, err := h.saveSetting(code, int(ctx.Locals("userID").(float64)), func(s *models.OwnerSetting) {
+		s.AllocationRule = input.AllocationRule
+	})
+	if err != nil {
+		return ownerError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"message": "Allocation rule updated successfully",
+		"data":    setting,
+	})
+}
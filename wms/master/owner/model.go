@@ -1,18 +1,18 @@
 package owner
 
 import (
-	"fiber-app/types"
-
 	"gorm.io/gorm"
 )
 
+// Owner memakai tabel owners yang di-migrate dari models.Owner (id auto increment)
 type Owner struct {
 	gorm.Model
-	ID          types.SnowflakeID `json:"id" gorm:"primaryKey"`
-	Code        string            `json:"code" gorm:"unique"`
-	Name        string            `json:"name" gorm:"unique"`
-	Description string            `json:"description"`
-	CreatedBy   int
-	UpdatedBy   int
-	DeletedBy   int
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	Code           string `json:"code" gorm:"unique"`
+	Name           string `json:"name" gorm:"unique"`
+	Description    string `json:"description"`
+	AllocationRule string `json:"allocation_rule"`
+	CreatedBy      int
+	UpdatedBy      int
+	DeletedBy      int
 }
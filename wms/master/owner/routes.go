@@ -2,21 +2,37 @@ package owner
 
 import (
 	"fiber-app/config"
+	"fiber-app/controllers/helpers"
 	"fiber-app/database"
 	"fiber-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var ownerPermissions = helpers.RegisterPermissions(helpers.PermissionMap{
+	"GET *":                      "owner.view",
+	"POST /":                     "owner.create",
+	"PUT /:id":                   "owner.update",
+	"DELETE /:id":                "owner.delete",
+	"PUT /:code/settings":        "owner.update",
+	"PUT /:code/allocation-rule": "owner.update",
+})
+
 func SetupOwnerRoutes(app *fiber.App) {
 	// To fix import cycle, temporarily remove middleware usage here.
-	api := app.Group(config.MAIN_ROUTES+"/owners", middleware.AuthMiddleware)
-	ownerController := &OwnerHandler{}
-	api.Use(database.InjectDBMiddleware(ownerController))
+	api := app.Group(
+		config.MAIN_ROUTES+"/owners",
+		middleware.AuthMiddleware,
+		middleware.RequirePermission(config.MAIN_ROUTES+"/owners", ownerPermissions),
+	)
+	api.Use(database.InjectDBMiddleware)
 
-	api.Get("/", ownerController.GetAllOwners)
-	// api.Post("/", ownerController.CreateOwner)
-	// api.Get("/:id", ownerController.GetOwnerByID)
-	// api.Put("/:id", ownerController.UpdateOwner)
-	// api.Delete("/:id", ownerController.DeleteOwner)
+	api.Get("/", database.Handle((*OwnerHandler).GetAllOwners))
+	api.Get("/:code/settings", database.Handle((*OwnerHandler).GetOwnerSetting))
+	api.Put("/:code/settings", database.Handle((*OwnerHandler).UpdateOwnerSetting))
+	api.Put("/:code/allocation-rule", database.Handle((*OwnerHandler).UpdateAllocationRule))
+	api.Post("/", database.Handle((*OwnerHandler).CreateOwner))
+	api.Get("/:id", database.Handle((*OwnerHandler).GetOwnerByID))
+	api.Put("/:id", database.Handle((*OwnerHandler).UpdateOwner))
+	api.Delete("/:id", database.Handle((*OwnerHandler).DeleteOwner))
 }
@@ -1,6 +1,8 @@
 package owner
 
 import (
+	"fiber-app/models"
+
 	"gorm.io/gorm"
 )
 
@@ -17,5 +19,17 @@ func SeedOwner(db *gorm.DB) {
 				db.Create(&o)
 			}
 		}
+
+		var setting models.OwnerSetting
+		if err := db.Where("owner_code = ?", o.Code).First(&setting).Error; err == gorm.ErrRecordNotFound {
+			db.Create(&models.OwnerSetting{
+				OwnerCode:       o.Code,
+				QaStatus:        "A",
+				DivisionCode:    "REGULAR",
+				InboundPrefix:   "IN",
+				OutboundPrefix:  "OB",
+				BillingCurrency: "IDR",
+			})
+		}
 	}
 }